@@ -0,0 +1,829 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"coworking-booking/internal/auth"
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// session — сессия пользователя, вошедшего в интерактивное меню
+var session *models.LoginResponse
+
+// runCLI запускает интерактивное меню в терминале
+func runCLI(ctx context.Context) {
+	reader := bufio.NewReader(os.Stdin)
+
+	if !authenticate(ctx, reader) {
+		return
+	}
+	defer logout(ctx)
+	ctx = database.WithActor(ctx, database.UserActor(session.User))
+
+	for {
+		fmt.Printf("\n Главное меню (%s, %s):\n", session.User.FullName, session.User.Email)
+		fmt.Println("1. Коворкинги и комнаты")
+		fmt.Println("2. Поиск свободных комнат")
+		fmt.Println("3. Создать бронирование")
+		fmt.Println("4. Управление платежами")
+		fmt.Println("5. Мои бронирования (История)")
+		fmt.Println("6. Отчёты (Менеджер, Администратор)")
+		fmt.Println("7. Демонстрация транзакций")
+		fmt.Println("8. Перенести или продлить бронирование")
+		fmt.Println("9. Повторяющиеся бронирования")
+		fmt.Println("10. Лист ожидания")
+		fmt.Println("11. Часы работы, праздники и буферы")
+		fmt.Println("12. Блокировки комнат")
+		fmt.Println("13. Тарифы и политика отмены")
+		fmt.Println("14. Промокоды")
+		fmt.Println("15. Журнал изменений (Администратор)")
+		fmt.Println("0. Выход")
+		fmt.Print("\nВыберите действие: ")
+
+		choice, _ := reader.ReadString('\n')
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "1":
+			manageCoworkingsAndRooms(ctx, reader)
+		case "2":
+			searchAvailableRooms(ctx, reader)
+		case "3":
+			createBooking(ctx, reader)
+		case "4":
+			managePayments(ctx, reader)
+		case "5":
+			viewUserBookings(ctx)
+		case "6":
+			viewReports(ctx, reader)
+		case "7":
+			demonstrateTransactions(ctx, reader)
+		case "8":
+			rescheduleBooking(ctx, reader)
+		case "9":
+			manageSeries(ctx, reader)
+		case "10":
+			manageWaitlist(ctx, reader)
+		case "11":
+			manageSchedule(ctx, reader)
+		case "12":
+			manageBlackouts(ctx, reader)
+		case "13":
+			managePricing(ctx, reader)
+		case "14":
+			managePromoCodes(ctx, reader)
+		case "15":
+			viewAuditLog(ctx, reader)
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный выбор, попробуйте снова")
+		}
+	}
+}
+
+// authenticate выполняет вход или регистрацию; false — пользователь вышел
+func authenticate(ctx context.Context, reader *bufio.Reader) bool {
+	for {
+		fmt.Println("\n Вход в систему:")
+		fmt.Println("1. Войти")
+		fmt.Println("2. Зарегистрироваться")
+		fmt.Println("0. Выход")
+		fmt.Print("\nВыберите действие: ")
+
+		choice, _ := reader.ReadString('\n')
+		choice = strings.TrimSpace(choice)
+
+		switch choice {
+		case "1":
+			fmt.Print("Email: ")
+			email, _ := reader.ReadString('\n')
+			fmt.Print("Пароль: ")
+			password, _ := reader.ReadString('\n')
+
+			resp, err := authService.Login(ctx, strings.TrimSpace(email), strings.TrimSpace(password))
+			if err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			session = resp
+			fmt.Printf("Добро пожаловать, %s! Сессия действует до %s\n",
+				resp.User.FullName, resp.ExpiresAt.Format("2006-01-02 15:04"))
+			return true
+
+		case "2":
+			fmt.Print("Email: ")
+			email, _ := reader.ReadString('\n')
+			fmt.Print("Полное имя: ")
+			fullName, _ := reader.ReadString('\n')
+			fmt.Printf("Пароль (не менее %d символов): ", auth.MinPasswordLength)
+			password, _ := reader.ReadString('\n')
+
+			user, err := authService.Register(ctx, strings.TrimSpace(email), strings.TrimSpace(password), strings.TrimSpace(fullName))
+			if err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				continue
+			}
+			fmt.Printf("Пользователь зарегистрирован: ID=%d. Теперь войдите в систему.\n", user.UserID)
+
+		case "0", "":
+			return false
+		default:
+			fmt.Println("Неверный выбор, попробуйте снова")
+		}
+	}
+}
+
+// logout отзывает токен текущей сессии
+func logout(ctx context.Context) {
+	if session == nil {
+		return
+	}
+	if err := authService.Logout(ctx, session.Token); err != nil {
+		log.Printf("Error: %v\n", err)
+	}
+	session = nil
+}
+
+// printError выводит ошибку; отказ в доступе показывается отдельным сообщением
+func printError(err error) {
+	var accessErr *auth.AccessError
+	if errors.As(err, &accessErr) {
+		fmt.Printf("Доступ запрещён: %s\n", accessErr.Reason)
+		return
+	}
+	fmt.Printf("Ошибка: %v\n", err)
+}
+
+func manageCoworkingsAndRooms(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nКоворкинги и комнаты:")
+	fmt.Println("1. Показать все коворкинги")
+	fmt.Println("2. Создать новый коворкинг")
+	fmt.Println("3. Показать комнаты в коворкинге")
+	fmt.Println("4. Создать новую комнату")
+	fmt.Println("5. Изменить, архивировать или удалить коворкинг")
+	fmt.Println("6. Комната: подробности, изменение, архив, удаление")
+	fmt.Println("7. Оборудование")
+	fmt.Println("8. Бронирования на перенос из архивных комнат")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		coworkings, err := db.GetAllCoworkings(ctx, true)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Println("\nСписок коворкингов:")
+		for _, c := range coworkings {
+			fmt.Printf("ID: %d | %s | %s | %s%s\n", c.CoworkingID, c.Name, c.Address, c.TimeZone, archivedMark(c.ArchivedAt))
+		}
+
+	case "2":
+		if err := authorizer.CanManageCoworkings(ctx, session.User); err != nil {
+			printError(err)
+			return
+		}
+
+		fmt.Print("Название: ")
+		name, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(name)
+
+		fmt.Print("Адрес: ")
+		address, _ := reader.ReadString('\n')
+		address = strings.TrimSpace(address)
+
+		fmt.Print("Описание (необязательно): ")
+		desc, _ := reader.ReadString('\n')
+		desc = strings.TrimSpace(desc)
+		var description *string
+		if desc != "" {
+			description = &desc
+		}
+
+		fmt.Printf("Часовой пояс IANA (по умолчанию %s): ", localtime.DefaultZone)
+		timeZone, _ := reader.ReadString('\n')
+		timeZone = strings.TrimSpace(timeZone)
+		if timeZone == "" {
+			timeZone = localtime.DefaultZone
+		}
+		if err := localtime.Validate(timeZone); err != nil {
+			fmt.Println("Неизвестный часовой пояс, например: Europe/Moscow, Asia/Yekaterinburg")
+			return
+		}
+
+		c, err := db.CreateCoworking(ctx, name, address, description, timeZone)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Коворкинг создан: ID=%d, Название=%s, Часовой пояс=%s\n", c.CoworkingID, c.Name, c.TimeZone)
+
+	case "3":
+		fmt.Print("ID коворкинга: ")
+		idStr, _ := reader.ReadString('\n')
+		coworkingID, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+
+		rooms, err := db.GetRoomsByCoworking(ctx, coworkingID, true)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Println("\n🚪 Комнаты:")
+		for _, r := range rooms {
+			fmt.Printf("ID: %d | %s | Вместимость: %d | Ставка: %s руб/час",
+				r.RoomID, r.Name, r.Capacity, r.HourlyRate)
+			if r.SetupMinutes > 0 || r.TeardownMinutes > 0 {
+				fmt.Printf(" | Буфер: %d/%d мин", r.SetupMinutes, r.TeardownMinutes)
+			}
+			fmt.Println(archivedMark(r.ArchivedAt))
+		}
+
+	case "4":
+		if err := authorizer.CanManageRooms(ctx, session.User); err != nil {
+			printError(err)
+			return
+		}
+
+		fmt.Print("ID коворкинга: ")
+		idStr, _ := reader.ReadString('\n')
+		coworkingID, err := strconv.Atoi(strings.TrimSpace(idStr))
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+
+		fmt.Print("Название комнаты: ")
+		name, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(name)
+
+		fmt.Print("Вместимость: ")
+		capStr, _ := reader.ReadString('\n')
+		capacity, _ := strconv.Atoi(strings.TrimSpace(capStr))
+
+		fmt.Print("Площадь (кв.м, необязательно): ")
+		areaStr, _ := reader.ReadString('\n')
+		areaStr = strings.TrimSpace(areaStr)
+		var areaSqm *float64
+		if areaStr != "" {
+			area, _ := strconv.ParseFloat(areaStr, 64)
+			areaSqm = &area
+		}
+
+		fmt.Print("Почасовая ставка (руб): ")
+		rateStr, _ := reader.ReadString('\n')
+		rate, err := money.Parse(rateStr)
+		if err != nil {
+			fmt.Println("Неверная ставка: укажите сумму с точностью до копейки, например 1500.50")
+			return
+		}
+
+		r, err := db.CreateRoom(ctx, coworkingID, name, capacity, areaSqm, rate)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+		fmt.Printf("Комната создана: ID=%d, Название=%s\n", r.RoomID, r.Name)
+
+	case "5":
+		editCoworking(ctx, reader)
+	case "6":
+		editRoom(ctx, reader)
+	case "7":
+		manageEquipment(ctx, reader)
+	case "8":
+		viewRelocations(ctx)
+	}
+}
+
+func searchAvailableRooms(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\n🔍 Поиск свободных комнат")
+
+	fmt.Print("ID коворкинга (необязательно): ")
+	coworkingStr, _ := reader.ReadString('\n')
+	var coworkingID int
+	if coworkingStr = strings.TrimSpace(coworkingStr); coworkingStr != "" {
+		id, err := strconv.Atoi(coworkingStr)
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+		coworkingID = id
+	}
+
+	fmt.Println("Время указывается по местным часам коворкинга")
+	fmt.Print("Начало (YYYY-MM-DD HH:MM, например 2024-12-25 10:00): ")
+	startsStr, _ := reader.ReadString('\n')
+	startsStr = strings.TrimSpace(startsStr)
+	if _, err := time.Parse(localtime.Layout, startsStr); err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Print("Окончание (YYYY-MM-DD HH:MM): ")
+	endsStr, _ := reader.ReadString('\n')
+	endsStr = strings.TrimSpace(endsStr)
+	if _, err := time.Parse(localtime.Layout, endsStr); err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Print("Минимальная вместимость (необязательно): ")
+	capStr, _ := reader.ReadString('\n')
+	capStr = strings.TrimSpace(capStr)
+	var minCapacity *int
+	if capStr != "" {
+		cap, _ := strconv.Atoi(capStr)
+		minCapacity = &cap
+	}
+
+	// Фильтры, общие для всех коворкингов; время подставляется для каждого отдельно
+	filter := models.SearchRoomParams{MinCapacity: minCapacity}
+	filter.City = readOptionalText(reader, "Город (необязательно): ")
+	if rateStr := readOptionalText(reader, "Максимальная ставка, руб/час (необязательно): "); rateStr != nil {
+		rate, err := money.Parse(*rateStr)
+		if err != nil {
+			fmt.Println("Неверная ставка: укажите сумму с точностью до копейки, например 1500.50")
+			return
+		}
+		filter.MaxRate = &rate
+	}
+	if !printEquipmentCatalog(ctx) {
+		return
+	}
+	if equipmentStr := readOptionalText(reader, "Оборудование — ID или названия через запятую (необязательно): "); equipmentStr != nil {
+		var err error
+		if filter.EquipmentIDs, filter.EquipmentNames, err = parseEquipmentList(*equipmentStr); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	fmt.Print("Сортировка: 1 — дешевле, 2 — вместительнее, 3 — просторнее (по умолчанию 1): ")
+	switch readLine(reader) {
+	case "", "1":
+		filter.Sort = database.SortByPrice
+	case "2":
+		filter.Sort = "-" + database.SortByCapacity
+	case "3":
+		filter.Sort = "-" + database.SortByArea
+	default:
+		fmt.Println("Неверный выбор")
+		return
+	}
+
+	coworkings, err := db.GetAllCoworkings(ctx, false)
+	if err != nil {
+		log.Printf("Error: %v\n", err)
+		return
+	}
+
+	// Одно и то же местное время в коворкингах разных поясов — разные моменты,
+	// поэтому поиск идёт по каждому коворкингу отдельно
+	waitlistZone := localtime.DefaultZone
+	var rooms []models.Room
+	for _, c := range coworkings {
+		if coworkingID != 0 && c.CoworkingID != coworkingID {
+			continue
+		}
+		if coworkingID != 0 {
+			waitlistZone = c.TimeZone
+		}
+		params := filter
+		params.StartsAt, _ = localtime.Parse(localtime.Layout, startsStr, c.TimeZone)
+		params.EndsAt, _ = localtime.Parse(localtime.Layout, endsStr, c.TimeZone)
+		params.CoworkingID = &c.CoworkingID
+
+		found, err := db.SearchAvailableRooms(ctx, params)
+		if err != nil {
+			printError(err)
+			return
+		}
+		rooms = append(rooms, found...)
+	}
+	// Каждый коворкинг отсортирован отдельно — общий список упорядочивается заново
+	database.SortRooms(rooms, filter.Sort)
+
+	if len(rooms) == 0 {
+		fmt.Println("Свободных комнат не найдено")
+		// Лист ожидания не хранит город и порядок
+		params := filter
+		params.City, params.Sort = nil, ""
+		params.StartsAt, _ = localtime.Parse(localtime.Layout, startsStr, waitlistZone)
+		params.EndsAt, _ = localtime.Parse(localtime.Layout, endsStr, waitlistZone)
+		offerWaitlist(ctx, reader, params)
+		return
+	}
+
+	fmt.Printf("\nНайдено комнат: %d\n\n", len(rooms))
+	for i, r := range rooms {
+		fmt.Printf("%d. %s (%s)\n", i+1, r.Name, r.CoworkingName)
+		fmt.Printf("   Адрес: %s (%s)\n", r.CoworkingAddress, r.TimeZone)
+		fmt.Printf("   Вместимость: %d человек\n", r.Capacity)
+		if r.AreaSqm != nil {
+			fmt.Printf("   Площадь: %.1f кв.м\n", *r.AreaSqm)
+		}
+		fmt.Printf("   Ставка: %s руб/час\n", r.HourlyRate)
+		if r.QuotedPrice != nil {
+			fmt.Printf("   Стоимость за период: %s руб\n", *r.QuotedPrice)
+		}
+		if len(r.EquipmentList) > 0 {
+			equipment := make([]string, len(r.EquipmentList))
+			for i, name := range r.EquipmentList {
+				equipment[i] = fmt.Sprintf("%s (#%d)", name, r.EquipmentIDs[i])
+			}
+			fmt.Printf("   Оборудование: %s\n", strings.Join(equipment, ", "))
+		}
+		fmt.Printf("   [ID комнаты: %d]\n\n", r.RoomID)
+	}
+}
+
+func createBooking(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nСоздание бронирования")
+
+	fmt.Print("ID комнаты: ")
+	roomIDStr, _ := reader.ReadString('\n')
+	roomID, err := strconv.Atoi(strings.TrimSpace(roomIDStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+
+	zone, err := db.GetRoomTimeZone(ctx, roomID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Начало (YYYY-MM-DD HH:MM, %s): ", zone)
+	startsStr, _ := reader.ReadString('\n')
+	startsAt, err := localtime.Parse(localtime.Layout, strings.TrimSpace(startsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Printf("Окончание (YYYY-MM-DD HH:MM, %s): ", zone)
+	endsStr, _ := reader.ReadString('\n')
+	endsAt, err := localtime.Parse(localtime.Layout, strings.TrimSpace(endsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Print("Способ оплаты (card/cash/bank_transfer): ")
+	paymentMethod, _ := reader.ReadString('\n')
+	paymentMethod = strings.TrimSpace(paymentMethod)
+
+	fmt.Print("Промокод (пусто — без промокода): ")
+	promoCode, _ := reader.ReadString('\n')
+	promoCode = strings.TrimSpace(promoCode)
+
+	// Создание бронирования с платежом в транзакции
+	booking, payment, err := db.CreateBookingWithPayment(ctx, roomID, session.User.UserID, startsAt, endsAt, paymentMethod, promoCode)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Println("\nБронирование создано успешно!")
+	fmt.Printf("   ID бронирования: %d\n", booking.BookingID)
+	fmt.Printf("   Время: %s - %s\n", booking.StartsAt.Format("2006-01-02 15:04"), booking.EndsAt.Format("2006-01-02 15:04"))
+	fmt.Printf("   Сумма: %s руб\n", booking.TotalAmount)
+	printPriceLines(booking.PriceLines)
+	fmt.Printf("   Статус: %s\n", booking.Status)
+	if payment == nil {
+		fmt.Println("   Бронирование бесплатное — оплата не требуется")
+		return
+	}
+	fmt.Printf("\n   ID платежа: %d\n", payment.PaymentID)
+	fmt.Printf("   Статус платежа: %s\n", payment.Status)
+	if payment.ProviderRef != nil {
+		fmt.Printf("   Намерение оплаты (%s): %s\n", *payment.Provider, *payment.ProviderRef)
+	}
+	printInvoice(ctx, "   ", booking.BookingID, payment.PaymentID)
+}
+
+func rescheduleBooking(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nПеренос или продление бронирования (пустой ввод — оставить как есть)")
+
+	fmt.Print("ID бронирования: ")
+	bookingIDStr, _ := reader.ReadString('\n')
+	bookingID, err := strconv.Atoi(strings.TrimSpace(bookingIDStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+
+	if err := authorizer.CanRescheduleBooking(ctx, session.User, bookingID); err != nil {
+		printError(err)
+		return
+	}
+
+	var req models.RescheduleBookingRequest
+
+	fmt.Print("Новый ID комнаты: ")
+	roomIDStr, _ := reader.ReadString('\n')
+	if roomIDStr = strings.TrimSpace(roomIDStr); roomIDStr != "" {
+		roomID, err := strconv.Atoi(roomIDStr)
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+		req.RoomID = &roomID
+	}
+
+	// Новое время вводится по часам коворкинга той комнаты, куда переносится бронирование
+	var zone string
+	if req.RoomID != nil {
+		zone, err = db.GetRoomTimeZone(ctx, *req.RoomID)
+	} else {
+		zone, err = db.GetBookingTimeZone(ctx, bookingID)
+	}
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Новое начало (YYYY-MM-DD HH:MM, %s): ", zone)
+	startsStr, _ := reader.ReadString('\n')
+	if startsStr = strings.TrimSpace(startsStr); startsStr != "" {
+		startsAt, err := localtime.Parse(localtime.Layout, startsStr, zone)
+		if err != nil {
+			fmt.Println("Неверный формат даты")
+			return
+		}
+		req.StartsAt = &startsAt
+	}
+
+	fmt.Printf("Новое окончание (YYYY-MM-DD HH:MM, %s): ", zone)
+	endsStr, _ := reader.ReadString('\n')
+	if endsStr = strings.TrimSpace(endsStr); endsStr != "" {
+		endsAt, err := localtime.Parse(localtime.Layout, endsStr, zone)
+		if err != nil {
+			fmt.Println("Неверный формат даты")
+			return
+		}
+		req.EndsAt = &endsAt
+	}
+
+	result, err := db.RescheduleBooking(ctx, bookingID, session.User.UserID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	b := result.Booking
+	fmt.Println("\nБронирование изменено!")
+	fmt.Printf("   Комната ID: %d\n", b.RoomID)
+	fmt.Printf("   Время: %s - %s\n", b.StartsAt.Format("2006-01-02 15:04"), b.EndsAt.Format("2006-01-02 15:04"))
+	fmt.Printf("   Сумма: %s руб\n", b.TotalAmount)
+	printPriceLines(b.PriceLines)
+	printPaymentChanges("   ", result)
+}
+
+func managePayments(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nУправление платежами:")
+	fmt.Println("1. Подтвердить оплату (paid)")
+	fmt.Println("2. Добавить платёж по бронированию")
+	fmt.Println("3. Платежи и баланс бронирования")
+	fmt.Println("4. Уведомление платёжного шлюза (имитация)")
+	fmt.Println("5. Счета и квитанции бронирования")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		fmt.Print("ID платежа: ")
+		paymentIDStr, _ := reader.ReadString('\n')
+		paymentID, err := strconv.Atoi(strings.TrimSpace(paymentIDStr))
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+
+		if err := authorizer.CanConfirmPayment(ctx, session.User, paymentID); err != nil {
+			printError(err)
+			return
+		}
+
+		payment, booking, err := db.ConfirmPaymentAndBooking(ctx, paymentID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+
+		if booking.Status == "confirmed" {
+			fmt.Println("\nПлатёж подтверждён, бронирование оплачено!")
+		} else {
+			fmt.Println("\nПлатёж подтверждён")
+		}
+		fmt.Printf("   Платёж ID: %d | Статус: %s\n", payment.PaymentID, payment.Status)
+		fmt.Printf("   Бронирование ID: %d | Статус: %s\n", booking.BookingID, booking.Status)
+		if balance, err := db.GetBookingBalance(ctx, booking.BookingID); err == nil {
+			printBalance("   ", balance)
+		}
+	case "2":
+		addPayment(ctx, reader)
+	case "3":
+		viewBookingLedger(ctx, reader)
+	case "4":
+		simulatePaymentWebhook(ctx, reader)
+	case "5":
+		viewInvoices(ctx, reader)
+	}
+}
+
+func viewUserBookings(ctx context.Context) {
+	userID := session.User.UserID
+
+	bookings, err := db.GetUserBookings(ctx, userID)
+	if err != nil {
+		log.Printf("Error: %v\n", err)
+		return
+	}
+
+	if len(bookings) == 0 {
+		fmt.Println("Бронирования не найдены")
+		return
+	}
+
+	// Статистика
+	stats, err := db.GetUserStatistics(ctx, userID)
+	if err == nil {
+		fmt.Println("\nCтатистика пользователя:")
+		fmt.Printf("   Имя: %s (%s)\n", stats.FullName, stats.Email)
+		fmt.Printf("   Всего бронирований: %d\n", stats.TotalBookings)
+		fmt.Printf("   Подтверждённых: %d\n", stats.ConfirmedBookings)
+		fmt.Printf("   Завершённых: %d\n", stats.CompletedBookings)
+		fmt.Printf("   Отменённых: %d\n", stats.CancelledBookings)
+		fmt.Printf("   Потрачено: %s руб\n", stats.TotalPaid)
+	}
+
+	fmt.Println("\nИстория бронирований:")
+	for i, b := range bookings {
+		fmt.Printf("\n%d. Бронирование #%d\n", i+1, b.BookingID)
+		fmt.Printf("   Комната: %s (%s)\n", b.RoomName, b.CoworkingName)
+		fmt.Printf("   Адрес: %s\n", b.CoworkingAddress)
+		fmt.Printf("   Время: %s - %s\n", b.StartsAt.Format("2006-01-02 15:04"), b.EndsAt.Format("2006-01-02 15:04"))
+		fmt.Printf("   Сумма: %s руб\n", b.TotalAmount)
+		fmt.Printf("   Статус брони: %s\n", b.Status)
+		if b.PaymentStatus != nil {
+			fmt.Printf("   Статус оплаты: %s\n", *b.PaymentStatus)
+		}
+		if b.NeedsRelocation {
+			fmt.Println("   ⚠ Комната закрыта — бронирование нужно перенести")
+		}
+	}
+}
+
+func viewReports(ctx context.Context, reader *bufio.Reader) {
+	scope, err := authorizer.ReportScope(ctx, session.User)
+	if err != nil {
+		printError(err)
+		return
+	}
+
+	fmt.Println("\nОтчёты:")
+	fmt.Println("1. Загрузка комнат")
+	fmt.Println("2. Выручка по коворкингам")
+	fmt.Print("\nВыберите отчёт: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	// Даты — по местному календарю каждого коворкинга; конечная дата включается целиком
+	fmt.Print("Начальная дата (YYYY-MM-DD): ")
+	startStr, _ := reader.ReadString('\n')
+	startDate, _ := time.Parse("2006-01-02", strings.TrimSpace(startStr))
+
+	fmt.Print("Конечная дата (YYYY-MM-DD): ")
+	endStr, _ := reader.ReadString('\n')
+	endDate, _ := time.Parse("2006-01-02", strings.TrimSpace(endStr))
+	endDate = endDate.AddDate(0, 0, 1)
+
+	switch choice {
+	case "1":
+		occupancies, err := db.GetRoomOccupancy(ctx, startDate, endDate, scope)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Println("\nОтчёт о загрузке комнат:")
+		for i, o := range occupancies {
+			fmt.Printf("%d. %s (%s)\n", i+1, o.RoomName, o.CoworkingName)
+			fmt.Printf("   Бронирований: %d\n", o.TotalBookings)
+			fmt.Printf("   Занято часов: %.2f из %.2f\n", o.BookedHours, o.TotalHours-o.BlackoutHours)
+			if o.BlackoutHours > 0 {
+				fmt.Printf("   Заблокировано часов: %.2f\n", o.BlackoutHours)
+			}
+			fmt.Printf("   Загрузка: %.2f%%\n\n", o.OccupancyPercentage)
+		}
+
+	case "2":
+		reports, err := db.GetRevenueReport(ctx, startDate, endDate, scope)
+		if err != nil {
+			log.Printf("Error: %v\n", err)
+			return
+		}
+
+		fmt.Println("\nОтчёт о выручке:")
+		totalRevenue := money.Zero()
+		for i, r := range reports {
+			fmt.Printf("%d. %s\n", i+1, r.CoworkingName)
+			fmt.Printf("   Адрес: %s\n", r.Address)
+			fmt.Printf("   Бронирований: %d\n", r.TotalBookings)
+			fmt.Printf("   Общая выручка: %s руб\n", r.TotalRevenue)
+			fmt.Printf("   Подтверждённая: %s руб\n", r.ConfirmedRevenue)
+			fmt.Printf("   Ожидает оплаты: %s руб\n", r.PendingRevenue)
+			if !r.PromoDiscount.IsZero() {
+				fmt.Printf("   Скидки по промокодам: %s руб\n", r.PromoDiscount)
+			}
+			for _, item := range r.PriceBreakdown {
+				fmt.Printf("     %s: %s руб\n", item.Description, item.Amount)
+			}
+			fmt.Println()
+			totalRevenue = totalRevenue.Add(r.ConfirmedRevenue)
+		}
+		fmt.Printf("═══════════════════════════════════\n")
+		fmt.Printf("ИТОГО подтверждённая выручка: %s руб\n", totalRevenue)
+	}
+}
+
+func demonstrateTransactions(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nДемонстрация транзакций")
+	fmt.Println("1. Создание брони + платёж (транзакция)")
+	fmt.Println("2. Подтверждение оплаты + брони (транзакция)")
+	fmt.Println("3. Отмена брони + возврат средств (транзакция)")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		fmt.Println("\nТранзакция: Создание бронирования с платежом")
+		fmt.Println("   Обе операции выполняются атомарно - либо создаются обе записи, либо ни одна")
+		createBooking(ctx, reader)
+
+	case "2":
+		fmt.Println("\nТранзакция: Подтверждение оплаты и бронирования")
+		fmt.Println("   Статусы обновляются атомарно - нет полусостояний")
+		managePayments(ctx, reader)
+
+	case "3":
+		fmt.Println("\nТранзакция: Отмена с возвратом")
+		fmt.Print("ID бронирования: ")
+		bookingIDStr, _ := reader.ReadString('\n')
+		bookingID, _ := strconv.Atoi(strings.TrimSpace(bookingIDStr))
+
+		if err := authorizer.CanCancelBooking(ctx, session.User, bookingID); err != nil {
+			printError(err)
+			return
+		}
+
+		quote, err := db.QuoteCancellation(ctx, bookingID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printCancellationQuote(quote)
+		fmt.Print("Отменить бронирование? (y/n): ")
+		if answer := readLine(reader); answer != "y" && answer != "Y" {
+			fmt.Println("Отмена не выполнена")
+			return
+		}
+
+		result, err := db.CancelBookingWithRefund(ctx, bookingID, session.User.UserID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if len(result.Refunds) > 0 {
+			fmt.Printf("Бронирование отменено, возвращено %s руб (%d%%)\n", result.Quote.RefundAmount, result.Quote.RefundPercent)
+			printRefunds("   ", result.Refunds)
+		} else {
+			fmt.Println("Бронирование отменено, возврата нет")
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"coworking-booking/internal/models"
+)
+
+// recentAuditEntries — сколько последних изменений показывает журнал без фильтра
+const recentAuditEntries = 30
+
+func viewAuditLog(ctx context.Context, reader *bufio.Reader) {
+	if err := authorizer.CanViewAuditLog(ctx, session.User); err != nil {
+		printError(err)
+		return
+	}
+
+	fmt.Println("\nЖурнал изменений:")
+	fmt.Println("1. История бронирования")
+	fmt.Println("2. История платежа")
+	fmt.Println("3. Последние изменения")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		bookingID, ok := readID(reader, "ID бронирования: ")
+		if !ok {
+			return
+		}
+		entries, err := db.GetAuditLog(ctx, models.AuditFilter{BookingID: bookingID})
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printAuditHistory(entries, "booking", bookingID)
+	case "2":
+		paymentID, ok := readID(reader, "ID платежа: ")
+		if !ok {
+			return
+		}
+		entries, err := db.GetAuditLog(ctx, models.AuditFilter{PaymentID: paymentID})
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printAuditHistory(entries, "payment", paymentID)
+	case "3":
+		entries, err := db.GetAuditLog(ctx, models.AuditFilter{Limit: recentAuditEntries})
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("Журнал пуст")
+			return
+		}
+		for _, e := range entries {
+			printAuditEntry(e)
+		}
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+// printAuditHistory выводит изменения и состояние строки entity/id, восстановленное по журналу
+func printAuditHistory(entries []models.AuditEntry, entity string, id int) {
+	if len(entries) == 0 {
+		fmt.Println("Записей в журнале нет")
+		return
+	}
+	for _, e := range entries {
+		printAuditEntry(e)
+	}
+
+	state := replayAudit(entries, entity, id)
+	if state == nil {
+		return
+	}
+	fmt.Printf("\nСостояние %s #%d по журналу:\n", entity, id)
+	for _, key := range sortedKeys(state) {
+		fmt.Printf("   %s = %s\n", key, formatAuditValue(state[key]))
+	}
+}
+
+// printAuditEntry выводит запись журнала: для insert и delete — всю строку, для update — изменения
+func printAuditEntry(e models.AuditEntry) {
+	row := e.Entity
+	if e.EntityID != nil {
+		row = fmt.Sprintf("%s #%d", e.Entity, *e.EntityID)
+	}
+	fmt.Printf("\n%s  %-6s %s  (%s, транзакция %d)\n",
+		e.OccurredAt.Format("2006-01-02 15:04:05"), e.Action, row, e.Actor, e.TxID)
+
+	switch e.Action {
+	case "insert":
+		for _, key := range sortedKeys(e.NewValues) {
+			fmt.Printf("   %s = %s\n", key, formatAuditValue(e.NewValues[key]))
+		}
+	case "update":
+		for _, key := range sortedKeys(e.NewValues) {
+			fmt.Printf("   %s: %s → %s\n", key, formatAuditValue(e.OldValues[key]), formatAuditValue(e.NewValues[key]))
+		}
+	case "delete":
+		for _, key := range sortedKeys(e.OldValues) {
+			fmt.Printf("   %s было %s\n", key, formatAuditValue(e.OldValues[key]))
+		}
+	}
+}
+
+// replayAudit применяет записи журнала строки entity/id по порядку; nil — строка удалена
+// или её вставка не попала в выборку
+func replayAudit(entries []models.AuditEntry, entity string, id int) map[string]any {
+	var state map[string]any
+	for _, e := range entries {
+		if e.Entity != entity || e.EntityID == nil || *e.EntityID != id {
+			continue
+		}
+		switch e.Action {
+		case "insert":
+			state = make(map[string]any, len(e.NewValues))
+			for k, v := range e.NewValues {
+				state[k] = v
+			}
+		case "update":
+			if state == nil {
+				continue
+			}
+			for k, v := range e.NewValues {
+				state[k] = v
+			}
+		case "delete":
+			state = nil
+		}
+	}
+	return state
+}
+
+func sortedKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatAuditValue(v any) string {
+	if v == nil {
+		return "—"
+	}
+	return fmt.Sprint(v)
+}
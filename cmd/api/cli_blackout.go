@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+)
+
+func manageBlackouts(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nБлокировки (уборка, ремонт, мероприятия):")
+	fmt.Println("1. Показать блокировки коворкинга")
+	fmt.Println("2. Заблокировать комнату")
+	fmt.Println("3. Заблокировать весь коворкинг")
+	fmt.Println("4. Удалить блокировку")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		blackouts, err := db.GetBlackouts(ctx, coworkingID, time.Now())
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if len(blackouts) == 0 {
+			fmt.Println("Блокировок нет")
+			return
+		}
+		for _, b := range blackouts {
+			target := "весь коворкинг"
+			if b.RoomID != nil {
+				target = fmt.Sprintf("комната %d", *b.RoomID)
+			}
+			fmt.Printf("   ID: %d | %s | %s – %s | %s\n", b.BlackoutID, target,
+				b.StartsAt.Format(localtime.Layout), b.EndsAt.Format(localtime.Layout), b.Reason)
+		}
+	case "2":
+		roomID, ok := readID(reader, "ID комнаты: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageRoomSchedule(ctx, session.User, roomID); err != nil {
+			printError(err)
+			return
+		}
+		zone, err := db.GetRoomTimeZone(ctx, roomID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		createBlackout(ctx, reader, &roomID, nil, zone)
+	case "3":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		zone, err := db.GetCoworkingTimeZone(ctx, coworkingID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		createBlackout(ctx, reader, nil, &coworkingID, zone)
+	case "4":
+		blackoutID, ok := readID(reader, "ID блокировки: ")
+		if !ok {
+			return
+		}
+		coworkingID, err := db.GetBlackoutCoworkingID(ctx, blackoutID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		if err := db.DeleteBlackout(ctx, blackoutID); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Блокировка удалена")
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+// createBlackout запрашивает период и причину блокировки по местному времени zone
+// и выводит бронирования, которые с ней пересекаются
+func createBlackout(ctx context.Context, reader *bufio.Reader, roomID, coworkingID *int, zone string) {
+	var req models.CreateBlackoutRequest
+	var err error
+
+	fmt.Printf("Начало (YYYY-MM-DD HH:MM, %s): ", zone)
+	startsStr, _ := reader.ReadString('\n')
+	req.StartsAt, err = localtime.Parse(localtime.Layout, strings.TrimSpace(startsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Printf("Окончание (YYYY-MM-DD HH:MM, %s): ", zone)
+	endsStr, _ := reader.ReadString('\n')
+	req.EndsAt, err = localtime.Parse(localtime.Layout, strings.TrimSpace(endsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Print("Причина: ")
+	reason, _ := reader.ReadString('\n')
+	req.Reason = strings.TrimSpace(reason)
+
+	result, err := db.CreateBlackout(ctx, session.User.UserID, roomID, coworkingID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Printf("Блокировка создана: ID=%d\n", result.Blackout.BlackoutID)
+
+	if len(result.ConflictingBookings) == 0 {
+		return
+	}
+	fmt.Println("\nПересекающиеся бронирования — перенесите или отмените их:")
+	for _, b := range result.ConflictingBookings {
+		fmt.Printf("   Бронирование #%d | %s | %s – %s | %s (%s) | %s\n", b.BookingID, b.RoomName,
+			b.StartsAt.Format(localtime.Layout), b.EndsAt.Format(localtime.Layout), b.UserName, b.UserEmail, b.Status)
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+)
+
+func editCoworking(ctx context.Context, reader *bufio.Reader) {
+	if err := authorizer.CanManageCoworkings(ctx, session.User); err != nil {
+		printError(err)
+		return
+	}
+	coworkingID, ok := readID(reader, "ID коворкинга: ")
+	if !ok {
+		return
+	}
+	c, err := db.GetCoworkingByID(ctx, coworkingID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	fmt.Printf("\n%s | %s | %s%s\n", c.Name, c.Address, c.TimeZone, archivedMark(c.ArchivedAt))
+	if c.Description != nil {
+		fmt.Printf("Описание: %s\n", *c.Description)
+	}
+
+	fmt.Println("\n1. Изменить")
+	fmt.Println("2. Удалить")
+	if c.ArchivedAt == nil {
+		fmt.Println("3. Архивировать")
+	} else {
+		fmt.Println("3. Восстановить из архива")
+	}
+	fmt.Print("\nВыберите действие: ")
+
+	switch readLine(reader) {
+	case "1":
+		var req models.UpdateCoworkingRequest
+		fmt.Println("Пустой ввод оставляет значение без изменений")
+		req.Name = readOptionalText(reader, "Название: ")
+		req.Address = readOptionalText(reader, "Адрес: ")
+		req.Description = readOptionalText(reader, "Описание (\"-\" — удалить): ")
+		if req.Description != nil && *req.Description == "-" {
+			empty := ""
+			req.Description = &empty
+		}
+		req.TimeZone = readOptionalText(reader, "Часовой пояс IANA: ")
+		if req.TimeZone != nil {
+			if err := localtime.Validate(*req.TimeZone); err != nil {
+				fmt.Println("Неизвестный часовой пояс, например: Europe/Moscow, Asia/Yekaterinburg")
+				return
+			}
+		}
+
+		c, err := db.UpdateCoworking(ctx, coworkingID, req)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Printf("Коворкинг изменён: %s | %s | %s\n", c.Name, c.Address, c.TimeZone)
+	case "2":
+		fmt.Printf("Удалить коворкинг «%s» со всеми комнатами? (y/n): ", c.Name)
+		if strings.ToLower(readLine(reader)) != "y" {
+			return
+		}
+		if err := db.DeleteCoworking(ctx, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println("Коворкинг удалён")
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+func editRoom(ctx context.Context, reader *bufio.Reader) {
+	roomID, ok := readID(reader, "ID комнаты: ")
+	if !ok {
+		return
+	}
+	room, err := db.GetRoomByID(ctx, roomID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	printRoomDetails(room)
+
+	if authorizer.CanManageRooms(ctx, session.User) != nil {
+		return
+	}
+	fmt.Println("\n1. Изменить")
+	fmt.Println("2. Удалить")
+	fmt.Println("3. Добавить оборудование")
+	fmt.Println("4. Снять оборудование")
+	if room.ArchivedAt == nil {
+		fmt.Println("5. Архивировать")
+	} else {
+		fmt.Println("5. Восстановить из архива")
+	}
+	fmt.Println("0. Назад")
+	fmt.Print("\nВыберите действие: ")
+
+	choice := readLine(reader)
+	switch choice {
+	case "1":
+		var req models.UpdateRoomRequest
+		fmt.Println("Пустой ввод оставляет значение без изменений")
+		req.Name = readOptionalText(reader, "Название: ")
+		capacity, ok := readOptionalNumber(reader, "Вместимость: ")
+		if !ok {
+			return
+		}
+		req.Capacity = capacity
+		if areaStr := readOptionalText(reader, "Площадь (кв.м): "); areaStr != nil {
+			area, err := strconv.ParseFloat(*areaStr, 64)
+			if err != nil || area <= 0 {
+				fmt.Println("Площадь должна быть положительным числом")
+				return
+			}
+			req.AreaSqm = &area
+		}
+		if rateStr := readOptionalText(reader, "Почасовая ставка (руб): "); rateStr != nil {
+			rate, err := money.Parse(*rateStr)
+			if err != nil {
+				fmt.Println("Неверная ставка: укажите сумму с точностью до копейки, например 1500.50")
+				return
+			}
+			req.HourlyRate = &rate
+		}
+
+		r, err := db.UpdateRoom(ctx, roomID, req)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Printf("Комната изменена: %s | Вместимость: %d | Ставка: %s руб/час\n", r.Name, r.Capacity, r.HourlyRate)
+	case "2":
+		fmt.Printf("Удалить комнату «%s»? (y/n): ", room.Name)
+		if strings.ToLower(readLine(reader)) != "y" {
+			return
+		}
+		if err := db.DeleteRoom(ctx, roomID); err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println("Комната удалена")
+	case "3", "4":
+		if !printEquipmentCatalog(ctx) {
+			return
+		}
+		equipmentID, ok := readID(reader, "ID оборудования: ")
+		if !ok {
+			return
+		}
+		change, done := db.AddEquipmentToRoom, "Оборудование добавлено"
+		if choice == "4" {
+			change, done = db.RemoveEquipmentFromRoom, "Оборудование снято"
+		}
+		if err := change(ctx, roomID, equipmentID); err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println(done)
+	case "5":
+		if room.ArchivedAt != nil {
+			if _, err := db.RestoreRoom(ctx, roomID); err != nil {
+				printError(err)
+				return
+			}
+			fmt.Println("Комната восстановлена")
+			return
+		}
+		fmt.Printf("Архивировать комнату «%s»? Она пропадёт из поиска (y/n): ", room.Name)
+		if strings.ToLower(readLine(reader)) != "y" {
+			return
+		}
+		result, err := db.ArchiveRoom(ctx, roomID)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println("Комната архивирована")
+		printRelocationBookings(result.RelocationBookings)
+	case "0", "":
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+func manageEquipment(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nОборудование:")
+	fmt.Println("1. Справочник оборудования")
+	fmt.Println("2. Добавить тип оборудования")
+	fmt.Println("3. Изменить тип оборудования")
+	fmt.Println("4. Удалить тип оборудования")
+	fmt.Print("\nВыберите действие: ")
+
+	choice := readLine(reader)
+	if choice == "1" {
+		printEquipmentCatalog(ctx)
+		return
+	}
+	if choice != "2" && choice != "3" && choice != "4" {
+		fmt.Println("Неверный выбор")
+		return
+	}
+	if err := authorizer.CanManageRooms(ctx, session.User); err != nil {
+		printError(err)
+		return
+	}
+
+	switch choice {
+	case "2":
+		fmt.Print("Название: ")
+		req := models.EquipmentRequest{Name: readLine(reader)}
+		if req.Name == "" {
+			fmt.Println("Название обязательно")
+			return
+		}
+		req.Description = readOptionalText(reader, "Описание (необязательно): ")
+
+		e, err := db.CreateEquipment(ctx, req.Name, req.Description)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Printf("Оборудование сохранено: ID=%d, Название=%s\n", e.EquipmentID, e.Name)
+	case "3":
+		equipmentID, ok := readID(reader, "ID оборудования: ")
+		if !ok {
+			return
+		}
+		e, err := db.GetEquipmentByID(ctx, equipmentID)
+		if err != nil {
+			printError(err)
+			return
+		}
+		req := models.EquipmentRequest{Name: e.Name, Description: e.Description}
+		if name := readOptionalText(reader, fmt.Sprintf("Название (Enter — «%s»): ", e.Name)); name != nil {
+			req.Name = *name
+		}
+		if desc := readOptionalText(reader, "Описание (Enter — без изменений, \"-\" — удалить): "); desc != nil {
+			if *desc == "-" {
+				*desc = ""
+			}
+			req.Description = desc
+		}
+
+		e, err = db.UpdateEquipment(ctx, equipmentID, req)
+		if err != nil {
+			printError(err)
+			return
+		}
+		fmt.Printf("Оборудование изменено: ID=%d, Название=%s\n", e.EquipmentID, e.Name)
+	case "4":
+		equipmentID, ok := readID(reader, "ID оборудования: ")
+		if !ok {
+			return
+		}
+		fmt.Print("Оборудование будет снято со всех комнат. Удалить? (y/n): ")
+		if strings.ToLower(readLine(reader)) != "y" {
+			return
+		}
+		if err := db.DeleteEquipment(ctx, equipmentID); err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println("Оборудование удалено")
+	}
+}
+
+// viewRelocations выводит предстоящие бронирования архивных комнат: менеджеру — своих коворкингов
+func viewRelocations(ctx context.Context) {
+	scope, err := authorizer.RelocationScope(ctx, session.User)
+	if err != nil {
+		printError(err)
+		return
+	}
+	bookings, err := db.GetBookingsNeedingRelocation(ctx, scope)
+	if err != nil {
+		printError(err)
+		return
+	}
+	printRelocationBookings(bookings)
+}
+
+func printRelocationBookings(bookings []models.Booking) {
+	if len(bookings) == 0 {
+		fmt.Println("Предстоящих бронирований на перенос нет")
+		return
+	}
+	fmt.Printf("\nБронирования на перенос (%d):\n", len(bookings))
+	for _, b := range bookings {
+		fmt.Printf("#%d | %s (%s) | %s - %s | %s <%s> | %s\n", b.BookingID, b.RoomName, b.CoworkingName,
+			b.StartsAt.Format("2006-01-02 15:04"), b.EndsAt.Format("15:04"), b.UserName, b.UserEmail, b.Status)
+	}
+}
+
+// archivedMark — пометка архивной записи в списках
+func archivedMark(archivedAt *time.Time) string {
+	if archivedAt == nil {
+		return ""
+	}
+	return " [архив с " + archivedAt.Format("2006-01-02") + "]"
+}
+
+func printRoomDetails(r *models.Room) {
+	fmt.Printf("\nКомната #%d «%s» — %s, %s (%s)%s\n", r.RoomID, r.Name, r.CoworkingName, r.CoworkingAddress, r.TimeZone,
+		archivedMark(r.ArchivedAt))
+	fmt.Printf("Вместимость: %d | Ставка: %s руб/час", r.Capacity, r.HourlyRate)
+	if r.AreaSqm != nil {
+		fmt.Printf(" | Площадь: %.1f кв.м", *r.AreaSqm)
+	}
+	if r.SetupMinutes > 0 || r.TeardownMinutes > 0 {
+		fmt.Printf(" | Буфер: %d/%d мин", r.SetupMinutes, r.TeardownMinutes)
+	}
+	fmt.Println()
+	if len(r.EquipmentList) > 0 {
+		fmt.Printf("Оборудование: %s\n", strings.Join(r.EquipmentList, ", "))
+	} else {
+		fmt.Println("Оборудование: нет")
+	}
+}
+
+// printEquipmentCatalog выводит справочник оборудования; false — при ошибке
+func printEquipmentCatalog(ctx context.Context) bool {
+	equipment, err := db.GetAllEquipment(ctx)
+	if err != nil {
+		printError(err)
+		return false
+	}
+	fmt.Println("\nСправочник оборудования:")
+	if len(equipment) == 0 {
+		fmt.Println("  пусто")
+	}
+	for _, e := range equipment {
+		fmt.Printf("ID: %d | %s", e.EquipmentID, e.Name)
+		if e.Description != nil {
+			fmt.Printf(" — %s", *e.Description)
+		}
+		fmt.Println()
+	}
+	return true
+}
+
+// readOptionalText читает необязательную строку: пустой ввод — nil
+func readOptionalText(reader *bufio.Reader, prompt string) *string {
+	fmt.Print(prompt)
+	s := readLine(reader)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"coworking-booking/internal/invoice"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/payments"
+)
+
+// addPayment выставляет новый платёж по бронированию: депозит или оплату частями
+func addPayment(ctx context.Context, reader *bufio.Reader) {
+	bookingID, ok := readID(reader, "ID бронирования: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanPayBooking(ctx, session.User, bookingID); err != nil {
+		printError(err)
+		return
+	}
+
+	balance, err := db.GetBookingBalance(ctx, bookingID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	printBalance("   ", balance)
+
+	var req models.AddPaymentRequest
+	fmt.Print("Сумма (руб, пусто — весь остаток): ")
+	if s := readLine(reader); s != "" {
+		amount, err := money.Parse(s)
+		if err != nil {
+			fmt.Println("Неверная сумма: укажите сумму с точностью до копейки, например 1500.50")
+			return
+		}
+		req.Amount = &amount
+	}
+	fmt.Print("Способ оплаты (card/cash/bank_transfer, по умолчанию card): ")
+	if req.PaymentMethod = readLine(reader); req.PaymentMethod == "" {
+		req.PaymentMethod = "card"
+	}
+
+	payment, err := db.AddPayment(ctx, bookingID, req)
+	if err != nil {
+		printError(err)
+		return
+	}
+	fmt.Printf("\nПлатёж выставлен: ID %d | Сумма: %s руб | Статус: %s\n", payment.PaymentID, payment.Amount, payment.Status)
+}
+
+// viewBookingLedger показывает платежи, возвраты и баланс бронирования
+func viewBookingLedger(ctx context.Context, reader *bufio.Reader) {
+	bookingID, ok := readID(reader, "ID бронирования: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanViewBooking(ctx, session.User, bookingID); err != nil {
+		printError(err)
+		return
+	}
+
+	ledger, err := db.GetBookingLedger(ctx, bookingID)
+	if err != nil {
+		printError(err)
+		return
+	}
+
+	fmt.Printf("\nБронирование #%d\n", bookingID)
+	printBalance("   ", ledger.Balance)
+	fmt.Println("\nПлатежи:")
+	if len(ledger.Payments) == 0 {
+		fmt.Println("   нет")
+	}
+	for _, p := range ledger.Payments {
+		method := "-"
+		if p.PaymentMethod != nil {
+			method = *p.PaymentMethod
+		}
+		fmt.Printf("   ID: %d | %s | %s руб | %s | %s\n",
+			p.PaymentID, p.CreatedAt.Format("2006-01-02 15:04"), p.Amount, method, p.Status)
+		if p.ProviderRef != nil {
+			fmt.Printf("      Намерение оплаты (%s): %s\n", *p.Provider, *p.ProviderRef)
+		}
+		if p.FailureReason != nil {
+			fmt.Printf("      Причина отказа: %s\n", *p.FailureReason)
+		}
+	}
+	if len(ledger.Refunds) > 0 {
+		fmt.Println("\nВозвраты:")
+		printRefunds("   ", ledger.Refunds)
+	}
+}
+
+// simulatePaymentWebhook формирует уведомление встроенного шлюза о платеже, подписывает его
+// и проводит через ту же проверку подписи и обработку, что и POST /api/v1/payments/webhook
+func simulatePaymentWebhook(ctx context.Context, reader *bufio.Reader) {
+	fake, ok := paymentProvider.(*payments.Fake)
+	if !ok {
+		fmt.Println("Имитация доступна только со встроенным шлюзом (PAYMENT_PROVIDER=fake)")
+		return
+	}
+
+	paymentID, ok := readID(reader, "ID платежа: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanConfirmPayment(ctx, session.User, paymentID); err != nil {
+		printError(err)
+		return
+	}
+	payment, err := db.GetPayment(ctx, paymentID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	if payment.ProviderRef == nil {
+		fmt.Println("Платёж проводится без шлюза: подтвердите его вручную")
+		return
+	}
+
+	fmt.Println("Событие: 1 — оплата прошла, 2 — оплата отклонена, 3 — возврат")
+	fmt.Print("Выберите событие: ")
+	ev := payments.Event{Ref: *payment.ProviderRef}
+	switch readLine(reader) {
+	case "1":
+		ev.Type = payments.EventSucceeded
+	case "2":
+		ev.Type = payments.EventFailed
+		fmt.Print("Причина отказа (пусто — без причины): ")
+		ev.Reason = readLine(reader)
+	case "3":
+		ev.Type = payments.EventRefunded
+		fmt.Print("Сумма возврата (руб, пусто — весь платёж): ")
+		if s := readLine(reader); s != "" {
+			amount, err := money.Parse(s)
+			if err != nil || amount.Sign() <= 0 {
+				fmt.Println("Неверная сумма: укажите сумму с точностью до копейки, например 1500.50")
+				return
+			}
+			ev.Amount = &amount
+		}
+	default:
+		fmt.Println("Неверный выбор")
+		return
+	}
+
+	body, signature, err := fake.Sign(ev, time.Now())
+	if err != nil {
+		printError(err)
+		return
+	}
+	header := http.Header{}
+	header.Set(payments.SignatureHeader, signature)
+	parsed, err := fake.ParseWebhook(body, header)
+	if err != nil {
+		printError(err)
+		return
+	}
+	result, err := db.ApplyPaymentEvent(ctx, fake.Name(), *parsed)
+	if err != nil {
+		printError(err)
+		return
+	}
+
+	fmt.Printf("\nСобытие %s (%s): %s\n", result.EventID, result.EventType, result.Outcome)
+	if result.Payment != nil {
+		fmt.Printf("   Платёж ID: %d | Статус: %s\n", result.Payment.PaymentID, result.Payment.Status)
+	}
+	if len(result.Refunds) > 0 {
+		printRefunds("   ", result.Refunds)
+	}
+	fmt.Printf("   Бронирование ID: %d | Статус: %s\n", payment.BookingID, result.BookingStatus)
+}
+
+// viewInvoices показывает счета и квитанции бронирования и сохраняет выбранный документ в HTML
+func viewInvoices(ctx context.Context, reader *bufio.Reader) {
+	bookingID, ok := readID(reader, "ID бронирования: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanViewBooking(ctx, session.User, bookingID); err != nil {
+		printError(err)
+		return
+	}
+
+	invoices, err := db.GetBookingInvoices(ctx, bookingID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	if len(invoices) == 0 {
+		fmt.Println("Документов нет")
+		return
+	}
+	fmt.Printf("\nДокументы бронирования #%d:\n", bookingID)
+	for _, inv := range invoices {
+		fmt.Printf("   ID: %d | %s | %s | %s руб | платёж %d: %s\n",
+			inv.InvoiceID, invoice.Title(&inv), inv.IssuedAt.Format("2006-01-02 15:04"), inv.Amount, inv.PaymentID, inv.PaymentStatus)
+	}
+
+	fmt.Print("\nID документа для сохранения в HTML (пусто — не сохранять): ")
+	s := readLine(reader)
+	if s == "" {
+		return
+	}
+	invoiceID, err := strconv.Atoi(s)
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+	inv, err := db.GetInvoice(ctx, invoiceID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	if inv.BookingID != bookingID {
+		fmt.Println("Документ не найден среди документов бронирования")
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s.html", inv.Kind, inv.Number)
+	f, err := os.Create(name)
+	if err != nil {
+		printError(err)
+		return
+	}
+	defer f.Close()
+	if err := invoice.RenderHTML(f, inv); err != nil {
+		printError(err)
+		return
+	}
+	fmt.Printf("Сохранено: %s\n", name)
+}
+
+// printInvoice выводит счёт, выставленный на платёж, если он есть
+func printInvoice(ctx context.Context, indent string, bookingID, paymentID int) {
+	invoices, err := db.GetBookingInvoices(ctx, bookingID)
+	if err != nil {
+		printError(err)
+		return
+	}
+	for _, inv := range invoices {
+		if inv.PaymentID == paymentID && inv.Kind == "invoice" {
+			fmt.Printf("%s%s на %s руб (ID документа: %d)\n", indent, invoice.Title(&inv), inv.Amount, inv.InvoiceID)
+		}
+	}
+}
+
+// printBalance выводит баланс бронирования
+func printBalance(indent string, b *models.BookingBalance) {
+	fmt.Printf("%sСтоимость: %s руб | Оплачено: %s руб | Возвращено: %s руб | Ожидает оплаты: %s руб\n",
+		indent, b.TotalAmount, b.PaidAmount, b.RefundedAmount, b.PendingAmount)
+	fmt.Printf("%sБаланс: %s руб | Остаток к оплате: %s руб | Статус оплаты: %s\n",
+		indent, b.Balance, b.AmountDue, b.PaymentStatus)
+}
+
+// printRefunds выводит возвраты по платежам
+func printRefunds(indent string, refunds []models.Refund) {
+	for _, r := range refunds {
+		fmt.Printf("%sВозврат ID: %d | Платёж ID: %d | %s руб | %s | %s\n",
+			indent, r.RefundID, r.PaymentID, r.Amount, r.Reason, r.CreatedAt.Format("2006-01-02 15:04"))
+	}
+}
+
+// printPaymentChanges выводит, как перенос бронирования изменил его платежи
+func printPaymentChanges(indent string, r *models.RescheduleResult) {
+	for _, p := range r.Payments {
+		fmt.Printf("%sПлатёж ID: %d | Сумма: %s руб | Статус: %s\n", indent, p.PaymentID, p.Amount, p.Status)
+	}
+	if len(r.Refunds) > 0 {
+		fmt.Printf("%sВозврат разницы:\n", indent)
+		printRefunds(indent+"   ", r.Refunds)
+	}
+	if r.Balance != nil && r.Balance.AmountDue.Sign() > 0 {
+		fmt.Printf("%sТребуется доплата: %s руб\n", indent, r.Balance.AmountDue)
+	}
+}
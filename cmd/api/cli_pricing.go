@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/pricing"
+)
+
+func managePricing(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nТарифы:")
+	fmt.Println("1. Показать правила цены коворкинга")
+	fmt.Println("2. Показать правила цены комнаты")
+	fmt.Println("3. Добавить правило коворкингу")
+	fmt.Println("4. Добавить правило комнате")
+	fmt.Println("5. Удалить правило")
+	fmt.Println("6. Политика отмены коворкинга")
+	fmt.Println("7. Политика отмены комнаты")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		rules, err := db.GetCoworkingPricingRules(ctx, coworkingID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printPricingRules(rules)
+	case "2":
+		roomID, ok := readID(reader, "ID комнаты: ")
+		if !ok {
+			return
+		}
+		rules, err := db.GetRoomPricingRules(ctx, roomID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printPricingRules(rules)
+	case "3":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManagePricing(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		createPricingRule(ctx, reader, nil, &coworkingID)
+	case "4":
+		roomID, ok := readID(reader, "ID комнаты: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageRoomPricing(ctx, session.User, roomID); err != nil {
+			printError(err)
+			return
+		}
+		createPricingRule(ctx, reader, &roomID, nil)
+	case "5":
+		ruleID, ok := readID(reader, "ID правила: ")
+		if !ok {
+			return
+		}
+		coworkingID, err := db.GetPricingRuleCoworkingID(ctx, ruleID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if err := authorizer.CanManagePricing(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		if err := db.DeletePricingRule(ctx, ruleID); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Правило удалено")
+	case "6":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		tiers, err := db.GetCoworkingCancellationPolicy(ctx, coworkingID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printCancellationTiers(tiers, "полный возврат")
+		if err := authorizer.CanManagePricing(ctx, session.User, coworkingID); err != nil {
+			return
+		}
+		if tiers, ok := readCancellationTiers(reader); ok {
+			if err := db.SetCoworkingCancellationPolicy(ctx, coworkingID, tiers); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				return
+			}
+			fmt.Println("Политика отмены сохранена")
+		}
+	case "7":
+		roomID, ok := readID(reader, "ID комнаты: ")
+		if !ok {
+			return
+		}
+		tiers, err := db.GetRoomCancellationPolicy(ctx, roomID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		printCancellationTiers(tiers, "политика коворкинга")
+		if err := authorizer.CanManageRoomPricing(ctx, session.User, roomID); err != nil {
+			return
+		}
+		if tiers, ok := readCancellationTiers(reader); ok {
+			if err := db.SetRoomCancellationPolicy(ctx, roomID, tiers); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				return
+			}
+			fmt.Println("Политика отмены сохранена")
+		}
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+// createPricingRule запрашивает вид правила и его параметры
+func createPricingRule(ctx context.Context, reader *bufio.Reader, roomID, coworkingID *int) {
+	var req models.CreatePricingRuleRequest
+
+	fmt.Print("Вид (time_window/weekend/min_duration/duration_discount): ")
+	req.Kind = readLine(reader)
+	fmt.Print("Название (например, Пиковые часы): ")
+	req.Name = readLine(reader)
+
+	switch req.Kind {
+	case models.PricingTimeWindow:
+		fmt.Print("Дни недели через запятую (1 — пн, 7 — вс; пусто — все): ")
+		if days := readLine(reader); days != "" {
+			for _, d := range strings.Split(days, ",") {
+				day, err := strconv.Atoi(strings.TrimSpace(d))
+				if err != nil {
+					fmt.Println("Неверный день недели")
+					return
+				}
+				req.Weekdays = append(req.Weekdays, day)
+			}
+		}
+		fmt.Print("С (HH:MM): ")
+		opens := readLine(reader)
+		fmt.Print("До (HH:MM): ")
+		closes := readLine(reader)
+		req.OpensAt, req.ClosesAt = &opens, &closes
+		if req.RatePercent = readNumber(reader, "Ставка, % от базовой: "); req.RatePercent == nil {
+			return
+		}
+	case models.PricingWeekend:
+		if req.RatePercent = readNumber(reader, "Ставка в выходные, % от базовой: "); req.RatePercent == nil {
+			return
+		}
+	case models.PricingMinDuration:
+		if req.MinMinutes = readNumber(reader, "Минимальная длительность, мин: "); req.MinMinutes == nil {
+			return
+		}
+	case models.PricingDurationDiscount:
+		if req.MinMinutes = readNumber(reader, "Скидка от длительности, мин: "); req.MinMinutes == nil {
+			return
+		}
+		if req.DiscountPercent = readNumber(reader, "Скидка, %: "); req.DiscountPercent == nil {
+			return
+		}
+	default:
+		fmt.Println("Неизвестный вид правила")
+		return
+	}
+
+	rule, err := db.CreatePricingRule(ctx, roomID, coworkingID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Printf("Правило создано (ID: %d)\n", rule.PricingRuleID)
+}
+
+func printPricingRules(rules []models.PricingRule) {
+	if len(rules) == 0 {
+		fmt.Println("Правил нет — действует базовая ставка")
+		return
+	}
+	for _, r := range rules {
+		fmt.Printf("   ID: %d | %s | %s", r.PricingRuleID, r.Kind, r.Name)
+		switch r.Kind {
+		case models.PricingTimeWindow:
+			fmt.Printf(" | %s–%s", *r.OpensAt, *r.ClosesAt)
+			if len(r.Weekdays) > 0 {
+				fmt.Printf(" | дни %v", r.Weekdays)
+			}
+			fmt.Printf(" | %d%%", *r.RatePercent)
+		case models.PricingWeekend:
+			fmt.Printf(" | %d%%", *r.RatePercent)
+		case models.PricingMinDuration:
+			fmt.Printf(" | от %d мин", *r.MinMinutes)
+		case models.PricingDurationDiscount:
+			fmt.Printf(" | от %d мин | -%d%%", *r.MinMinutes, *r.DiscountPercent)
+		}
+		fmt.Println()
+	}
+}
+
+func printCancellationTiers(tiers []models.CancellationTier, empty string) {
+	if len(tiers) == 0 {
+		fmt.Printf("   Ступеней нет — %s\n", empty)
+		return
+	}
+	for _, t := range tiers {
+		fmt.Printf("   не позднее чем за %d ч — возврат %d%%\n", t.MinHoursBefore, t.RefundPercent)
+	}
+}
+
+// readCancellationTiers читает новую политику вида "48:100, 24:50"; ok = false — оставить как есть
+func readCancellationTiers(reader *bufio.Reader) ([]models.CancellationTier, bool) {
+	fmt.Print("Новая политика (часы:процент через запятую, \"-\" — очистить, пусто — не менять): ")
+	input := readLine(reader)
+	switch input {
+	case "":
+		return nil, false
+	case "-":
+		return []models.CancellationTier{}, true
+	}
+	var tiers []models.CancellationTier
+	for _, part := range strings.Split(input, ",") {
+		hoursStr, percentStr, found := strings.Cut(strings.TrimSpace(part), ":")
+		hours, err1 := strconv.Atoi(hoursStr)
+		percent, err2 := strconv.Atoi(percentStr)
+		if !found || err1 != nil || err2 != nil {
+			fmt.Println("Неверный формат, ожидается часы:процент")
+			return nil, false
+		}
+		tiers = append(tiers, models.CancellationTier{MinHoursBefore: hours, RefundPercent: percent})
+	}
+	return tiers, true
+}
+
+// printCancellationQuote выводит расчёт возврата перед подтверждением отмены
+func printCancellationQuote(q *models.CancellationQuote) {
+	fmt.Printf("   Начало: %s (через %.1f ч)\n", q.StartsAt.Format(localtime.Layout), q.HoursBefore)
+	if len(q.Policy) > 0 {
+		fmt.Println("   Политика отмены:")
+		printCancellationTiers(q.Policy, "")
+	}
+	fmt.Printf("   Оплачено: %s руб\n", q.PaidAmount)
+	fmt.Printf("   К возврату: %s руб (%d%%)\n", q.RefundAmount, q.RefundPercent)
+}
+
+// printPriceLines выводит расчёт стоимости бронирования
+func printPriceLines(lines []models.PriceLine) {
+	for _, l := range lines {
+		if l.Kind == pricing.LineDiscount {
+			fmt.Printf("     %s: %s руб\n", l.Description, l.Amount)
+			continue
+		}
+		fmt.Printf("     %s: %d мин × %s руб/час = %s руб\n", l.Description, l.Minutes, l.HourlyRate, l.Amount)
+	}
+}
+
+func readLine(reader *bufio.Reader) string {
+	s, _ := reader.ReadString('\n')
+	return strings.TrimSpace(s)
+}
+
+// readNumber читает целое число (проценты, минуты); nil — ввод некорректен
+func readNumber(reader *bufio.Reader, prompt string) *int {
+	fmt.Print(prompt)
+	n, err := strconv.Atoi(readLine(reader))
+	if err != nil {
+		fmt.Println("Ожидается целое число")
+		return nil
+	}
+	return &n
+}
+
+// readOptionalNumber читает необязательное целое число: пустой ввод — nil; ok = false, если ввод некорректен
+func readOptionalNumber(reader *bufio.Reader, prompt string) (*int, bool) {
+	fmt.Print(prompt)
+	s := readLine(reader)
+	if s == "" {
+		return nil, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		fmt.Println("Ожидается целое число")
+		return nil, false
+	}
+	return &n, true
+}
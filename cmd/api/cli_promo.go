@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+)
+
+func managePromoCodes(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nПромокоды:")
+	fmt.Println("1. Показать промокоды")
+	fmt.Println("2. Создать промокод")
+	fmt.Println("3. Отключить промокод")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		scope, err := authorizer.PromoCodeScope(ctx, session.User)
+		if err != nil {
+			printError(err)
+			return
+		}
+		promos, err := db.GetPromoCodes(ctx, scope)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if len(promos) == 0 {
+			fmt.Println("Промокодов нет")
+			return
+		}
+		for _, p := range promos {
+			printPromoCode(p)
+		}
+	case "2":
+		createPromoCode(ctx, reader)
+	case "3":
+		promoCodeID, ok := readID(reader, "ID промокода: ")
+		if !ok {
+			return
+		}
+		coworkingID, err := db.GetPromoCodeCoworkingID(ctx, promoCodeID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if err := authorizer.CanManagePromoCodes(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		if err := db.DeactivatePromoCode(ctx, promoCodeID); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Промокод отключён")
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+// createPromoCode запрашивает параметры промокода; сроки действия — по московскому времени
+func createPromoCode(ctx context.Context, reader *bufio.Reader) {
+	var req models.CreatePromoCodeRequest
+
+	fmt.Print("Код: ")
+	req.Code = readLine(reader)
+
+	fmt.Print("Скидка (например, 10% или 500): ")
+	discount := readLine(reader)
+	if percentStr, ok := strings.CutSuffix(discount, "%"); ok {
+		percent, err := strconv.Atoi(strings.TrimSpace(percentStr))
+		if err != nil {
+			fmt.Println("Неверный процент")
+			return
+		}
+		req.DiscountPercent = &percent
+	} else {
+		amount, err := money.Parse(discount)
+		if err != nil {
+			fmt.Println("Неверная сумма")
+			return
+		}
+		req.DiscountAmount = &amount
+	}
+
+	fmt.Printf("Действует до (YYYY-MM-DD HH:MM, %s; пусто — бессрочно): ", localtime.DefaultZone)
+	if until := readLine(reader); until != "" {
+		validUntil, err := localtime.Parse(localtime.Layout, until, localtime.DefaultZone)
+		if err != nil {
+			fmt.Println("Неверный формат даты")
+			return
+		}
+		req.ValidUntil = &validUntil
+	}
+
+	var ok bool
+	if req.MaxRedemptions, ok = readOptionalNumber(reader, "Лимит погашений (пусто — без лимита): "); !ok {
+		return
+	}
+	if req.MaxPerUser, ok = readOptionalNumber(reader, "Лимит на пользователя (пусто — без лимита): "); !ok {
+		return
+	}
+	if req.CoworkingID, ok = readOptionalNumber(reader, "ID коворкинга (пусто — все коворкинги): "); !ok {
+		return
+	}
+	if req.CoworkingID == nil {
+		if req.RoomID, ok = readOptionalNumber(reader, "ID комнаты (пусто — все комнаты): "); !ok {
+			return
+		}
+	}
+
+	coworkingID := req.CoworkingID
+	if req.RoomID != nil {
+		id, err := db.GetRoomCoworkingID(ctx, *req.RoomID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		coworkingID = &id
+	}
+	if err := authorizer.CanManagePromoCodes(ctx, session.User, coworkingID); err != nil {
+		printError(err)
+		return
+	}
+
+	promo, err := db.CreatePromoCode(ctx, session.User.UserID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Printf("Промокод %s создан (ID: %d)\n", promo.Code, promo.PromoCodeID)
+}
+
+func printPromoCode(p models.PromoCode) {
+	discount := ""
+	if p.DiscountPercent != nil {
+		discount = fmt.Sprintf("%d%%", *p.DiscountPercent)
+	} else if p.DiscountAmount != nil {
+		discount = fmt.Sprintf("%s руб", *p.DiscountAmount)
+	}
+	status := "активен"
+	if !p.IsActive {
+		status = "отключён"
+	}
+	fmt.Printf("   ID: %d | %s | -%s | %s | погашений: %d", p.PromoCodeID, p.Code, discount, status, p.Redemptions)
+	if p.MaxRedemptions != nil {
+		fmt.Printf(" из %d", *p.MaxRedemptions)
+	}
+	if p.ValidUntil != nil {
+		fmt.Printf(" | до %s", p.ValidUntil.Format(localtime.Layout))
+	}
+	switch {
+	case p.RoomID != nil:
+		fmt.Printf(" | комната %d", *p.RoomID)
+	case p.CoworkingID != nil:
+		fmt.Printf(" | коворкинг %d", *p.CoworkingID)
+	}
+	fmt.Println()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/schedule"
+)
+
+var weekdayNames = []string{"", "Пн", "Вт", "Ср", "Чт", "Пт", "Сб", "Вс"}
+
+func manageSchedule(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nЧасы работы, праздники и буферы:")
+	fmt.Println("1. Показать расписание коворкинга")
+	fmt.Println("2. Изменить часы работы коворкинга")
+	fmt.Println("3. Изменить часы работы комнаты")
+	fmt.Println("4. Добавить праздничный или сокращённый день")
+	fmt.Println("5. Удалить праздничный день")
+	fmt.Println("6. Буферы подготовки и уборки комнаты")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		showSchedule(ctx, reader)
+	case "2":
+		coworkingID, ok := readID(reader, "ID коворкинга: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		hours, ok := readWeeklyHours(reader)
+		if !ok {
+			return
+		}
+		if err := db.SetCoworkingHours(ctx, coworkingID, hours); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Часы работы коворкинга обновлены")
+	case "3":
+		roomID, ok := readID(reader, "ID комнаты: ")
+		if !ok {
+			return
+		}
+		if err := authorizer.CanManageRoomSchedule(ctx, session.User, roomID); err != nil {
+			printError(err)
+			return
+		}
+		fmt.Println("Пустое расписание (все дни пустые) — комната работает по часам коворкинга")
+		hours, ok := readWeeklyHours(reader)
+		if !ok {
+			return
+		}
+		if err := db.SetRoomHours(ctx, roomID, hours); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Часы работы комнаты обновлены")
+	case "4":
+		addHoliday(ctx, reader)
+	case "5":
+		holidayID, ok := readID(reader, "ID праздничного дня: ")
+		if !ok {
+			return
+		}
+		coworkingID, err := db.GetHolidayCoworkingID(ctx, holidayID)
+		if err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+			printError(err)
+			return
+		}
+		if err := db.DeleteHoliday(ctx, holidayID); err != nil {
+			fmt.Printf("Ошибка: %v\n", err)
+			return
+		}
+		fmt.Println("Праздничный день удалён")
+	case "6":
+		setRoomBuffer(ctx, reader)
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+func showSchedule(ctx context.Context, reader *bufio.Reader) {
+	coworkingID, ok := readID(reader, "ID коворкинга: ")
+	if !ok {
+		return
+	}
+
+	hours, err := db.GetCoworkingHours(ctx, coworkingID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Println("\nЧасы работы (местное время):")
+	if len(hours) == 0 {
+		fmt.Println("   Расписание не задано — круглосуточно")
+	}
+	printWeeklyHours(hours)
+
+	holidays, err := db.GetHolidays(ctx, coworkingID, time.Now().Format(schedule.DayLayout))
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Println("\nБлижайшие праздничные дни:")
+	if len(holidays) == 0 {
+		fmt.Println("   Нет")
+	}
+	for _, h := range holidays {
+		hoursStr := "закрыто"
+		if h.OpensAt != nil {
+			hoursStr = *h.OpensAt + "–" + *h.ClosesAt
+		}
+		fmt.Printf("   ID: %d | %s | %s | %s\n", h.HolidayID, h.Day, h.Name, hoursStr)
+	}
+}
+
+func addHoliday(ctx context.Context, reader *bufio.Reader) {
+	coworkingID, ok := readID(reader, "ID коворкинга: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanManageSchedule(ctx, session.User, coworkingID); err != nil {
+		printError(err)
+		return
+	}
+
+	var req models.CreateHolidayRequest
+
+	fmt.Print("Дата (YYYY-MM-DD): ")
+	day, _ := reader.ReadString('\n')
+	req.Day = strings.TrimSpace(day)
+
+	fmt.Print("Название: ")
+	name, _ := reader.ReadString('\n')
+	req.Name = strings.TrimSpace(name)
+
+	fmt.Print("Часы сокращённого дня (HH:MM-HH:MM, пусто — закрыто весь день): ")
+	hoursStr, _ := reader.ReadString('\n')
+	if hoursStr = strings.TrimSpace(hoursStr); hoursStr != "" {
+		opens, closes, found := strings.Cut(hoursStr, "-")
+		if !found {
+			fmt.Println("Неверный формат часов")
+			return
+		}
+		opens, closes = strings.TrimSpace(opens), strings.TrimSpace(closes)
+		req.OpensAt, req.ClosesAt = &opens, &closes
+	}
+
+	h, err := db.CreateHoliday(ctx, coworkingID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Printf("Праздничный день добавлен: ID=%d, %s\n", h.HolidayID, h.Day)
+}
+
+// setRoomBuffer задаёт минуты подготовки перед бронированием и уборки после него
+func setRoomBuffer(ctx context.Context, reader *bufio.Reader) {
+	roomID, ok := readID(reader, "ID комнаты: ")
+	if !ok {
+		return
+	}
+	if err := authorizer.CanManageRoomSchedule(ctx, session.User, roomID); err != nil {
+		printError(err)
+		return
+	}
+
+	fmt.Print("Подготовка перед бронированием, минут: ")
+	setupStr, _ := reader.ReadString('\n')
+	setup, err := strconv.Atoi(strings.TrimSpace(setupStr))
+	if err != nil {
+		fmt.Println("Неверное число")
+		return
+	}
+
+	fmt.Print("Уборка после бронирования, минут: ")
+	teardownStr, _ := reader.ReadString('\n')
+	teardown, err := strconv.Atoi(strings.TrimSpace(teardownStr))
+	if err != nil {
+		fmt.Println("Неверное число")
+		return
+	}
+
+	if err := db.SetRoomBuffer(ctx, roomID, setup, teardown); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Println("Буферы комнаты обновлены; действуют для новых и перенесённых бронирований")
+}
+
+// readWeeklyHours запрашивает часы работы по дням недели: "09:00-21:00",
+// несколько интервалов через запятую, пусто — выходной
+func readWeeklyHours(reader *bufio.Reader) ([]models.OpeningHours, bool) {
+	fmt.Println("Часы по дням недели: HH:MM-HH:MM, несколько интервалов через запятую, пусто — выходной")
+	var hours []models.OpeningHours
+	for weekday := 1; weekday <= 7; weekday++ {
+		fmt.Printf("%s: ", weekdayNames[weekday])
+		line, _ := reader.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		for _, part := range strings.Split(line, ",") {
+			opens, closes, found := strings.Cut(strings.TrimSpace(part), "-")
+			if !found {
+				fmt.Println("Неверный формат часов")
+				return nil, false
+			}
+			h := models.OpeningHours{Weekday: weekday, OpensAt: strings.TrimSpace(opens), ClosesAt: strings.TrimSpace(closes)}
+			if _, err := schedule.ParseWindow(h.OpensAt, h.ClosesAt); err != nil {
+				fmt.Printf("Ошибка: %v\n", err)
+				return nil, false
+			}
+			hours = append(hours, h)
+		}
+	}
+	return hours, true
+}
+
+func printWeeklyHours(hours []models.OpeningHours) {
+	if len(hours) == 0 {
+		return
+	}
+	byDay := make(map[int][]string)
+	for _, h := range hours {
+		byDay[h.Weekday] = append(byDay[h.Weekday], h.OpensAt+"–"+h.ClosesAt)
+	}
+	for weekday := 1; weekday <= 7; weekday++ {
+		windows := strings.Join(byDay[weekday], ", ")
+		if windows == "" {
+			windows = "выходной"
+		}
+		fmt.Printf("   %s: %s\n", weekdayNames[weekday], windows)
+	}
+}
+
+func readID(reader *bufio.Reader, prompt string) (int, bool) {
+	fmt.Print(prompt)
+	idStr, _ := reader.ReadString('\n')
+	id, err := strconv.Atoi(strings.TrimSpace(idStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return 0, false
+	}
+	return id, true
+}
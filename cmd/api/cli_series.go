@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/recurrence"
+)
+
+func manageSeries(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nПовторяющиеся бронирования:")
+	fmt.Println("1. Создать серию")
+	fmt.Println("2. Показать серию")
+	fmt.Println("3. Изменить вхождения серии")
+	fmt.Println("4. Отменить серию")
+	fmt.Print("\nВыберите действие: ")
+
+	choice, _ := reader.ReadString('\n')
+	choice = strings.TrimSpace(choice)
+
+	switch choice {
+	case "1":
+		createSeries(ctx, reader)
+	case "2":
+		showSeries(ctx, reader)
+	case "3":
+		updateSeries(ctx, reader)
+	case "4":
+		cancelSeries(ctx, reader)
+	default:
+		fmt.Println("Неверный выбор")
+	}
+}
+
+func createSeries(ctx context.Context, reader *bufio.Reader) {
+	fmt.Println("\nСоздание серии бронирований")
+
+	var req models.CreateSeriesRequest
+
+	fmt.Print("ID комнаты: ")
+	roomIDStr, _ := reader.ReadString('\n')
+	roomID, err := strconv.Atoi(strings.TrimSpace(roomIDStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+	req.RoomID = roomID
+
+	zone, err := db.GetRoomTimeZone(ctx, roomID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Начало первого вхождения (YYYY-MM-DD HH:MM, %s): ", zone)
+	startsStr, _ := reader.ReadString('\n')
+	req.StartsAt, err = localtime.Parse(localtime.Layout, strings.TrimSpace(startsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Printf("Окончание первого вхождения (YYYY-MM-DD HH:MM, %s): ", zone)
+	endsStr, _ := reader.ReadString('\n')
+	req.EndsAt, err = localtime.Parse(localtime.Layout, strings.TrimSpace(endsStr), zone)
+	if err != nil {
+		fmt.Println("Неверный формат даты")
+		return
+	}
+
+	fmt.Print("Правило повторения (например FREQ=WEEKLY;BYDAY=TU;COUNT=10): ")
+	rruleStr, _ := reader.ReadString('\n')
+	loc, err := localtime.Location(zone)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	rule, err := recurrence.Parse(rruleStr, loc)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	req.RRule = rule.String()
+
+	fmt.Print("Способ оплаты (card/cash/bank_transfer): ")
+	paymentMethod, _ := reader.ReadString('\n')
+	req.PaymentMethod = strings.TrimSpace(paymentMethod)
+
+	result, err := db.CreateBookingSeries(ctx, session.User.UserID, req, rule)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nСерия создана! ID серии: %d\n", result.Series.SeriesID)
+	printSeriesBookings(result.Bookings)
+	if len(result.Conflicts) > 0 {
+		fmt.Println("\nПропущены:")
+		for _, c := range result.Conflicts {
+			reason := "комната занята"
+			if c.Reason == "closed" {
+				reason = "нерабочее время"
+			}
+			fmt.Printf("   %s - %s (%s)\n", c.StartsAt.Format("2006-01-02 15:04"), c.EndsAt.Format("2006-01-02 15:04"), reason)
+		}
+	}
+}
+
+func showSeries(ctx context.Context, reader *bufio.Reader) {
+	seriesID, ok := readSeriesID(ctx, reader)
+	if !ok {
+		return
+	}
+
+	result, err := db.GetBookingSeries(ctx, seriesID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	s := result.Series
+	fmt.Printf("\nСерия %d | Комната ID: %d | %s | %s | Статус: %s\n", s.SeriesID, s.RoomID, s.RRule, s.TimeZone, s.Status)
+	printSeriesBookings(result.Bookings)
+}
+
+func updateSeries(ctx context.Context, reader *bufio.Reader) {
+	seriesID, ok := readSeriesID(ctx, reader)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSeriesRequest
+
+	fmt.Print("ID вхождения: ")
+	bookingIDStr, _ := reader.ReadString('\n')
+	bookingID, err := strconv.Atoi(strings.TrimSpace(bookingIDStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+	req.BookingID = bookingID
+
+	fmt.Print("Изменить (this — только это, following — это и следующие, all — все): ")
+	scope, _ := reader.ReadString('\n')
+	req.Scope = strings.TrimSpace(scope)
+	switch req.Scope {
+	case models.SeriesScopeThis, models.SeriesScopeFollowing, models.SeriesScopeAll:
+	default:
+		fmt.Println("Неверная область изменения")
+		return
+	}
+
+	zone, err := db.GetBookingTimeZone(ctx, bookingID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Новые значения для выбранного вхождения, время по часам %s (пустой ввод — оставить как есть)\n", zone)
+
+	fmt.Print("Новый ID комнаты: ")
+	roomIDStr, _ := reader.ReadString('\n')
+	if roomIDStr = strings.TrimSpace(roomIDStr); roomIDStr != "" {
+		roomID, err := strconv.Atoi(roomIDStr)
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+		req.RoomID = &roomID
+	}
+
+	fmt.Print("Новое начало (YYYY-MM-DD HH:MM): ")
+	startsStr, _ := reader.ReadString('\n')
+	if startsStr = strings.TrimSpace(startsStr); startsStr != "" {
+		startsAt, err := localtime.Parse(localtime.Layout, startsStr, zone)
+		if err != nil {
+			fmt.Println("Неверный формат даты")
+			return
+		}
+		req.StartsAt = &startsAt
+	}
+
+	fmt.Print("Новое окончание (YYYY-MM-DD HH:MM): ")
+	endsStr, _ := reader.ReadString('\n')
+	if endsStr = strings.TrimSpace(endsStr); endsStr != "" {
+		endsAt, err := localtime.Parse(localtime.Layout, endsStr, zone)
+		if err != nil {
+			fmt.Println("Неверный формат даты")
+			return
+		}
+		req.EndsAt = &endsAt
+	}
+
+	results, err := db.UpdateBookingSeries(ctx, seriesID, session.User.UserID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nИзменено вхождений: %d\n", len(results))
+	for _, r := range results {
+		b := r.Booking
+		fmt.Printf("   ID: %d | Комната ID: %d | %s - %s | %s руб\n",
+			b.BookingID, b.RoomID, b.StartsAt.Format("2006-01-02 15:04"), b.EndsAt.Format("2006-01-02 15:04"), b.TotalAmount)
+		printPaymentChanges("      ", &r)
+	}
+}
+
+func cancelSeries(ctx context.Context, reader *bufio.Reader) {
+	seriesID, ok := readSeriesID(ctx, reader)
+	if !ok {
+		return
+	}
+
+	var req models.CancelSeriesRequest
+
+	fmt.Print("Отменить начиная с ID вхождения (пусто — все будущие): ")
+	fromStr, _ := reader.ReadString('\n')
+	if fromStr = strings.TrimSpace(fromStr); fromStr != "" {
+		fromID, err := strconv.Atoi(fromStr)
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+		req.FromBookingID = &fromID
+	}
+
+	cancelled, err := db.CancelBookingSeries(ctx, seriesID, session.User.UserID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nОтменено вхождений: %d (оплаченные возвращены)\n", len(cancelled))
+}
+
+// readSeriesID запрашивает ID серии и проверяет, что пользователь ей владеет
+func readSeriesID(ctx context.Context, reader *bufio.Reader) (int, bool) {
+	fmt.Print("ID серии: ")
+	seriesIDStr, _ := reader.ReadString('\n')
+	seriesID, err := strconv.Atoi(strings.TrimSpace(seriesIDStr))
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return 0, false
+	}
+
+	if err := authorizer.CanManageSeries(ctx, session.User, seriesID); err != nil {
+		printError(err)
+		return 0, false
+	}
+	return seriesID, true
+}
+
+func printSeriesBookings(bookings []models.BookingWithPayment) {
+	fmt.Printf("\nВхождения (%d):\n", len(bookings))
+	for _, bp := range bookings {
+		b := bp.Booking
+		fmt.Printf("   ID: %d | %s - %s | %s руб | %s",
+			b.BookingID, b.StartsAt.Format("2006-01-02 15:04"), b.EndsAt.Format("2006-01-02 15:04"),
+			b.TotalAmount, b.Status)
+		if bp.Payment != nil {
+			fmt.Printf(" | Платёж ID: %d (%s)", bp.Payment.PaymentID, bp.Payment.Status)
+		}
+		fmt.Println()
+	}
+}
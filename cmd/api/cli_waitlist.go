@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+)
+
+// offerWaitlist предлагает встать в лист ожидания, когда поиск не нашёл свободных комнат
+func offerWaitlist(ctx context.Context, reader *bufio.Reader, params models.SearchRoomParams) {
+	fmt.Print("Встать в лист ожидания? (y/n): ")
+	answer, _ := reader.ReadString('\n')
+	if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+		return
+	}
+
+	req := models.JoinWaitlistRequest{SearchRoomParams: params}
+
+	fmt.Print("ID конкретной комнаты (пусто — любая подходящая): ")
+	roomIDStr, _ := reader.ReadString('\n')
+	if roomIDStr = strings.TrimSpace(roomIDStr); roomIDStr != "" {
+		roomID, err := strconv.Atoi(roomIDStr)
+		if err != nil {
+			fmt.Println("Неверный ID")
+			return
+		}
+		req.RoomID = &roomID
+	}
+
+	fmt.Print("Способ оплаты (card/cash/bank_transfer): ")
+	paymentMethod, _ := reader.ReadString('\n')
+	req.PaymentMethod = strings.TrimSpace(paymentMethod)
+
+	entry, err := db.JoinWaitlist(ctx, session.User.UserID, req)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	fmt.Printf("\nВы в листе ожидания (заявка ID: %d).\n", entry.EntryID)
+	fmt.Println("Когда слот освободится, вам будет создано бронирование — его нужно оплатить в течение",
+		database.WaitlistOfferWindow)
+}
+
+func manageWaitlist(ctx context.Context, reader *bufio.Reader) {
+	entries, err := db.GetUserWaitlist(ctx, session.User.UserID)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("\nЛист ожидания пуст")
+		return
+	}
+
+	fmt.Printf("\nЗаявки в листе ожидания (%d):\n", len(entries))
+	for _, e := range entries {
+		room := "любая подходящая"
+		if e.City != nil {
+			room = fmt.Sprintf("любая подходящая в городе %s", *e.City)
+		}
+		if e.CoworkingID != nil {
+			room = fmt.Sprintf("любая подходящая в коворкинге ID %d", *e.CoworkingID)
+		}
+		if e.RoomID != nil {
+			room = fmt.Sprintf("ID %d", *e.RoomID)
+		}
+		fmt.Printf("   ID: %d | Комната: %s | %s - %s | %s\n", e.EntryID, room,
+			e.StartsAt.Format("2006-01-02 15:04"), e.EndsAt.Format("2006-01-02 15:04"), e.Status)
+		if e.Status == "offered" && e.OfferedBookingID != nil && e.OfferExpiresAt != nil {
+			fmt.Printf("      Предложено бронирование ID %d — оплатите до %s\n",
+				*e.OfferedBookingID, e.OfferExpiresAt.Format("2006-01-02 15:04"))
+		}
+	}
+
+	fmt.Print("\nID заявки для отмены (пусто — назад): ")
+	entryIDStr, _ := reader.ReadString('\n')
+	entryIDStr = strings.TrimSpace(entryIDStr)
+	if entryIDStr == "" {
+		return
+	}
+	entryID, err := strconv.Atoi(entryIDStr)
+	if err != nil {
+		fmt.Println("Неверный ID")
+		return
+	}
+
+	if err := db.LeaveWaitlist(ctx, entryID, session.User.UserID); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	fmt.Println("Заявка снята")
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/auth"
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+)
+
+// Коды завершения неинтерактивных команд; скрипты могут на них полагаться
+const (
+	exitOK              = 0
+	exitError           = 1 // внутренняя ошибка, БД недоступна
+	exitUsage           = 2 // неизвестная команда, неверные флаги или данные
+	exitUnauthenticated = 3 // нет COWORKING_TOKEN, токен истёк, неверный пароль
+	exitForbidden       = 4 // у пользователя нет прав на операцию
+	exitNotFound        = 5 // запись не найдена
+	exitConflict        = 6 // комната занята или закрыта, неверное состояние, недействительный промокод
+)
+
+// tokenEnv — переменная окружения с токеном сессии для команд, требующих входа.
+// Токен не передаётся флагом, чтобы не попадать в список процессов и историю shell
+const tokenEnv = "COWORKING_TOKEN"
+
+// commandFunc выполняет подкоманду с аргументами после её имени и печатает результат
+type commandFunc func(ctx context.Context, args []string) error
+
+// commands — неинтерактивные подкоманды: группа → действие
+var commands = map[string]map[string]commandFunc{
+	"auth": {
+		"login":  cmdAuthLogin,
+		"logout": cmdAuthLogout,
+	},
+	"rooms": {
+		"search": cmdRoomsSearch,
+	},
+	"equipment": {
+		"list": cmdEquipmentList,
+	},
+	"bookings": {
+		"list":   cmdBookingsList,
+		"create": cmdBookingsCreate,
+		"cancel": cmdBookingsCancel,
+	},
+	"payments": {
+		"list":    cmdPaymentsList,
+		"confirm": cmdPaymentsConfirm,
+	},
+	"reports": {
+		"occupancy": cmdReportsOccupancy,
+		"revenue":   cmdReportsRevenue,
+	},
+}
+
+const commandUsage = `
+Неинтерактивные команды (результат — в stdout, ошибки — в stderr):
+  auth login --email E [--password P]      вход; пароль также из COWORKING_PASSWORD
+  auth logout                              завершение сессии COWORKING_TOKEN
+  rooms search --from T --to T [--coworking ID] [--city C] [--min-capacity N] [--max-rate R]
+               [--equipment 1,Проектор] [--sort price|capacity|area|-capacity|...]
+  equipment list                           справочник оборудования
+  bookings list
+  bookings create --room ID --from T --to T [--payment-method card|cash|bank_transfer] [--promo-code C]
+  bookings cancel --id ID
+  payments list --booking ID
+  payments confirm --id ID
+  reports occupancy --from D --to D
+  reports revenue --from D --to D
+
+Время T — RFC 3339 (2025-03-01T10:00:00+03:00) или местное время коворкинга
+(2025-03-01T10:00). Период отчёта D — YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS.
+Все команды, кроме auth login, берут токен сессии из COWORKING_TOKEN:
+  export COWORKING_TOKEN=$(coworking-booking auth login --email admin@coworking.com --output json | jq -r .token)
+Формат вывода: --output table (по умолчанию), json или csv.
+
+Коды завершения: 0 — успех, 1 — внутренняя ошибка, 2 — неверная команда или данные,
+3 — требуется вход, 4 — нет прав, 5 — не найдено, 6 — конфликт (слот занят, неверное состояние).
+`
+
+// usageError — ошибка в командной строке; завершается кодом exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// lookupCommand находит подкоманду по os.Args[1:] (группа, действие)
+func lookupCommand(args []string) (commandFunc, error) {
+	group, ok := commands[args[0]]
+	if !ok {
+		return nil, usagef("неизвестная команда %q", args[0])
+	}
+	if len(args) < 2 {
+		return nil, usagef("укажите действие: %s %s", args[0], strings.Join(sortedActions(group), " | "))
+	}
+	fn, ok := group[args[1]]
+	if !ok {
+		return nil, usagef("неизвестное действие %q: %s %s", args[1], args[0], strings.Join(sortedActions(group), " | "))
+	}
+	return fn, nil
+}
+
+func sortedActions(group map[string]commandFunc) []string {
+	actions := make([]string, 0, len(group))
+	for a := range group {
+		actions = append(actions, a)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// runCommand выполняет подкоманду и возвращает код завершения; ошибка печатается в stderr
+func runCommand(ctx context.Context, fn commandFunc, args []string) int {
+	err := fn(ctx, args)
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "Ошибка: %v\n", err)
+	return exitCode(err)
+}
+
+// exitCode переводит ошибку команды в код завершения (как writeDBError — в HTTP-статус)
+func exitCode(err error) int {
+	var usageErr *usageError
+	switch {
+	case errors.As(err, &usageErr), errors.Is(err, auth.ErrInvalidInput):
+		return exitUsage
+	case errors.Is(err, auth.ErrUnauthenticated), errors.Is(err, auth.ErrInvalidCredentials):
+		return exitUnauthenticated
+	case errors.Is(err, auth.ErrForbidden):
+		return exitForbidden
+	case errors.Is(err, database.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, database.ErrRoomUnavailable), errors.Is(err, database.ErrOutsideOpeningHours),
+		errors.Is(err, database.ErrConflict), errors.Is(err, database.ErrPromoCodeInvalid):
+		return exitConflict
+	default:
+		return exitError
+	}
+}
+
+// newFlagSet создаёт набор флагов подкоманды с общим флагом --output
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	output := fs.String("output", formatTable, "формат вывода: table, json или csv")
+	return fs, output
+}
+
+// parseFlags разбирает флаги подкоманды; лишние аргументы и неизвестный формат — usageError
+func parseFlags(fs *flag.FlagSet, args []string, output *string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usagef("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() > 0 {
+		return usagef("%s: лишние аргументы %q", fs.Name(), fs.Args())
+	}
+	switch *output {
+	case formatTable, formatJSON, formatCSV:
+	default:
+		return usagef("--output: ожидается table, json или csv, получено %q", *output)
+	}
+	return nil
+}
+
+// requireFlag проверяет, что обязательный флаг задан
+func requireFlag(fs *flag.FlagSet, name string) error {
+	found := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	if !found {
+		return usagef("%s: требуется --%s", fs.Name(), name)
+	}
+	return nil
+}
+
+// commandUser возвращает пользователя по токену из COWORKING_TOKEN; его изменения
+// записываются в audit_log от его имени
+func commandUser(ctx context.Context) (context.Context, *models.User, error) {
+	token := os.Getenv(tokenEnv)
+	if token == "" {
+		return ctx, nil, fmt.Errorf("задайте %s (coworking-booking auth login): %w", tokenEnv, auth.ErrUnauthenticated)
+	}
+	user, err := authService.Authenticate(ctx, token)
+	if err != nil {
+		return ctx, nil, err
+	}
+	return database.WithActor(ctx, database.UserActor(user)), user, nil
+}
+
+// parseEquipmentList разбирает оборудование через запятую: числа — ID, остальное — названия
+func parseEquipmentList(value string) ([]int, []string, error) {
+	var ids []int
+	var names []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.Atoi(part)
+		switch {
+		case err != nil:
+			names = append(names, part)
+		case id <= 0:
+			return nil, nil, fmt.Errorf("неверный ID %q", part)
+		default:
+			ids = append(ids, id)
+		}
+	}
+	return ids, names, nil
+}
+
+// commandTime — момент времени из флага: RFC 3339 со смещением (Instant) или местное время
+// коворкинга без смещения (Wall, YYYY-MM-DDTHH:MM или "YYYY-MM-DD HH:MM")
+type commandTime struct {
+	Instant *time.Time
+	Wall    string
+}
+
+func parseCommandTime(name, value string) (commandTime, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return commandTime{Instant: &t}, nil
+	}
+	wall := strings.Replace(value, "T", " ", 1)
+	if _, err := time.Parse(localtime.Layout, wall); err != nil {
+		return commandTime{}, usagef("--%s: ожидается RFC 3339 или местное время YYYY-MM-DDTHH:MM", name)
+	}
+	return commandTime{Wall: wall}, nil
+}
+
+// In возвращает момент времени; местное время читается в часовом поясе zone
+func (t commandTime) In(zone string) (time.Time, error) {
+	if t.Instant != nil {
+		return *t.Instant, nil
+	}
+	return localtime.Parse(localtime.Layout, t.Wall, zone)
+}
+
+// parsePeriodFlags читает период отчёта --from/--to по местным часам коворкингов, как API:
+// YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS; дата без времени в --to включает весь день
+func parsePeriodFlags(from, to string) (time.Time, time.Time, error) {
+	start, _, err := parseReportTime(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, usagef("--from: ожидается YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS")
+	}
+	end, dateOnly, err := parseReportTime(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, usagef("--to: ожидается YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS")
+	}
+	if dateOnly {
+		end = end.AddDate(0, 0, 1)
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, usagef("--from должно быть раньше --to")
+	}
+	return start, end, nil
+}
+
+func parseReportTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
+
+// printCommandUsage выводит список подкоманд в w
+func printCommandUsage(w io.Writer) {
+	fmt.Fprint(w, commandUsage)
+}
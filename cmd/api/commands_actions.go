@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+)
+
+func cmdAuthLogin(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("auth login")
+	email := fs.String("email", "", "email пользователя")
+	password := fs.String("password", "", "пароль; без флага читается из COWORKING_PASSWORD")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	if err := requireFlag(fs, "email"); err != nil {
+		return err
+	}
+	if *password == "" {
+		*password = getEnv("COWORKING_PASSWORD", "")
+	}
+
+	resp, err := authService.Login(ctx, *email, *password)
+	if err != nil {
+		return err
+	}
+	t := table{header: []string{"token", "expires_at", "user_id", "email", "role"}}
+	t.add(resp.Token, cellTime(resp.ExpiresAt), cellInt(resp.User.UserID), resp.User.Email, resp.User.Role)
+	return printResult(*output, resp, t)
+}
+
+func cmdAuthLogout(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("auth logout")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	if _, _, err := commandUser(ctx); err != nil {
+		return err
+	}
+	return authService.Logout(ctx, getEnv(tokenEnv, ""))
+}
+
+// cmdRoomsSearch: rooms search --from --to [--coworking] [--city] [--min-capacity] [--max-rate]
+// [--equipment 1,Проектор] [--sort].
+// Местное время без смещения читается по часам каждого коворкинга, как в интерактивном меню
+func cmdRoomsSearch(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("rooms search")
+	fromStr := fs.String("from", "", "начало: RFC 3339 или местное время YYYY-MM-DDTHH:MM")
+	toStr := fs.String("to", "", "окончание: RFC 3339 или местное время YYYY-MM-DDTHH:MM")
+	coworkingID := fs.Int("coworking", 0, "ID коворкинга")
+	minCapacity := fs.Int("min-capacity", 0, "минимальная вместимость")
+	maxRate := fs.String("max-rate", "", "максимальная ставка за час, например 1500.00")
+	city := fs.String("city", "", "город коворкинга, без учёта регистра")
+	equipment := fs.String("equipment", "", "ID или названия оборудования через запятую; нужно всё перечисленное")
+	sortOrder := fs.String("sort", "", "порядок: price (по умолчанию), capacity, area; «-» — по убыванию")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	for _, name := range []string{"from", "to"} {
+		if err := requireFlag(fs, name); err != nil {
+			return err
+		}
+	}
+
+	from, err := parseCommandTime("from", *fromStr)
+	if err != nil {
+		return err
+	}
+	to, err := parseCommandTime("to", *toStr)
+	if err != nil {
+		return err
+	}
+	var params models.SearchRoomParams
+	if *minCapacity > 0 {
+		params.MinCapacity = minCapacity
+	}
+	if *maxRate != "" {
+		rate, err := money.Parse(*maxRate)
+		if err != nil {
+			return usagef("--max-rate: %v", err)
+		}
+		params.MaxRate = &rate
+	}
+	if *city != "" {
+		params.City = city
+	}
+	if params.EquipmentIDs, params.EquipmentNames, err = parseEquipmentList(*equipment); err != nil {
+		return usagef("--equipment: %v", err)
+	}
+	if err := database.ValidateRoomSort(*sortOrder); err != nil {
+		return usagef("--sort: %v", err)
+	}
+	params.Sort = *sortOrder
+
+	coworkings, err := db.GetAllCoworkings(ctx, false)
+	if err != nil {
+		return err
+	}
+	rooms := []models.Room{}
+	found := false
+	for _, c := range coworkings {
+		if *coworkingID != 0 && c.CoworkingID != *coworkingID {
+			continue
+		}
+		found = true
+		if params.StartsAt, err = from.In(c.TimeZone); err != nil {
+			return err
+		}
+		if params.EndsAt, err = to.In(c.TimeZone); err != nil {
+			return err
+		}
+		if !params.StartsAt.Before(params.EndsAt) {
+			return usagef("--from должно быть раньше --to")
+		}
+		params.CoworkingID = &c.CoworkingID
+		available, err := db.SearchAvailableRooms(ctx, params)
+		if err != nil {
+			return err
+		}
+		rooms = append(rooms, available...)
+	}
+	if *coworkingID != 0 && !found {
+		return usagef("--coworking: коворкинг %d не найден", *coworkingID)
+	}
+
+	// Каждый коворкинг отсортирован отдельно — общий список упорядочивается заново
+	if err := database.SortRooms(rooms, *sortOrder); err != nil {
+		return err
+	}
+
+	t := table{header: []string{"room_id", "name", "coworking", "capacity", "area_sqm", "hourly_rate", "quoted_price",
+		"equipment", "equipment_ids"}}
+	for _, r := range rooms {
+		area := ""
+		if r.AreaSqm != nil {
+			area = strconv.FormatFloat(*r.AreaSqm, 'f', -1, 64)
+		}
+		equipmentIDs := make([]string, len(r.EquipmentIDs))
+		for i, id := range r.EquipmentIDs {
+			equipmentIDs[i] = strconv.Itoa(id)
+		}
+		t.add(cellInt(r.RoomID), r.Name, r.CoworkingName, cellInt(r.Capacity), area, r.HourlyRate.String(),
+			cellMoneyPtr(r.QuotedPrice), strings.Join(r.EquipmentList, "; "), strings.Join(equipmentIDs, ";"))
+	}
+	return printResult(*output, rooms, t)
+}
+
+// cmdEquipmentList: equipment list — справочник оборудования для --equipment
+func cmdEquipmentList(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("equipment list")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+
+	equipment, err := db.GetAllEquipment(ctx)
+	if err != nil {
+		return err
+	}
+	if equipment == nil {
+		equipment = []models.Equipment{}
+	}
+
+	t := table{header: []string{"equipment_id", "name", "description"}}
+	for _, e := range equipment {
+		description := ""
+		if e.Description != nil {
+			description = *e.Description
+		}
+		t.add(cellInt(e.EquipmentID), e.Name, description)
+	}
+	return printResult(*output, equipment, t)
+}
+
+func cmdBookingsList(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("bookings list")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	bookings, err := db.GetUserBookings(ctx, user.UserID)
+	if err != nil {
+		return err
+	}
+	if bookings == nil {
+		bookings = []models.Booking{}
+	}
+	t := table{header: []string{"booking_id", "room", "coworking", "starts_at", "ends_at", "total_amount", "status", "payment_status"}}
+	for _, b := range bookings {
+		t.add(cellInt(b.BookingID), b.RoomName, b.CoworkingName, cellTime(b.StartsAt), cellTime(b.EndsAt),
+			b.TotalAmount.String(), b.Status, cellString(b.PaymentStatus))
+	}
+	return printResult(*output, bookings, t)
+}
+
+// cmdBookingsCreate: bookings create --room --from --to [--payment-method] [--promo-code].
+// Местное время без смещения читается по часам коворкинга комнаты
+func cmdBookingsCreate(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("bookings create")
+	roomID := fs.Int("room", 0, "ID комнаты")
+	fromStr := fs.String("from", "", "начало: RFC 3339 или местное время YYYY-MM-DDTHH:MM")
+	toStr := fs.String("to", "", "окончание: RFC 3339 или местное время YYYY-MM-DDTHH:MM")
+	paymentMethod := fs.String("payment-method", "card", "способ оплаты: card, cash, bank_transfer")
+	promoCode := fs.String("promo-code", "", "промокод")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	for _, name := range []string{"room", "from", "to"} {
+		if err := requireFlag(fs, name); err != nil {
+			return err
+		}
+	}
+	from, err := parseCommandTime("from", *fromStr)
+	if err != nil {
+		return err
+	}
+	to, err := parseCommandTime("to", *toStr)
+	if err != nil {
+		return err
+	}
+
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	zone, err := db.GetRoomTimeZone(ctx, *roomID)
+	if err != nil {
+		return err
+	}
+	startsAt, err := from.In(zone)
+	if err != nil {
+		return err
+	}
+	endsAt, err := to.In(zone)
+	if err != nil {
+		return err
+	}
+	if !startsAt.Before(endsAt) {
+		return usagef("--from должно быть раньше --to")
+	}
+
+	booking, payment, err := db.CreateBookingWithPayment(ctx, *roomID, user.UserID, startsAt, endsAt, *paymentMethod, *promoCode)
+	if err != nil {
+		return err
+	}
+	return printResult(*output, models.BookingWithPayment{Booking: booking, Payment: payment}, bookingWithPaymentTable(booking, payment))
+}
+
+func cmdBookingsCancel(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("bookings cancel")
+	bookingID := fs.Int("id", 0, "ID бронирования")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	if err := requireFlag(fs, "id"); err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	if err := authorizer.CanCancelBooking(ctx, user, *bookingID); err != nil {
+		return err
+	}
+
+	result, err := db.CancelBookingWithRefund(ctx, *bookingID, user.UserID)
+	if err != nil {
+		return err
+	}
+	t := table{header: []string{"booking_id", "refund_percent", "paid_amount", "refund_amount", "refunds"}}
+	t.add(cellInt(result.Quote.BookingID), cellInt(result.Quote.RefundPercent), result.Quote.PaidAmount.String(),
+		result.Quote.RefundAmount.String(), cellInt(len(result.Refunds)))
+	return printResult(*output, result, t)
+}
+
+func cmdPaymentsList(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("payments list")
+	bookingID := fs.Int("booking", 0, "ID бронирования")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	if err := requireFlag(fs, "booking"); err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	if err := authorizer.CanViewBooking(ctx, user, *bookingID); err != nil {
+		return err
+	}
+
+	ledger, err := db.GetBookingLedger(ctx, *bookingID)
+	if err != nil {
+		return err
+	}
+	t := table{header: []string{"kind", "id", "amount", "status", "payment_method", "created_at", "paid_at"}}
+	for _, p := range ledger.Payments {
+		t.add("payment", cellInt(p.PaymentID), p.Amount.String(), p.Status, cellString(p.PaymentMethod),
+			cellTime(p.CreatedAt), cellTimePtr(p.PaidAt))
+	}
+	for _, r := range ledger.Refunds {
+		t.add("refund", cellInt(r.RefundID), r.Amount.String(), r.Reason, "", cellTime(r.CreatedAt), "")
+	}
+	return printResult(*output, ledger, t)
+}
+
+func cmdPaymentsConfirm(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("payments confirm")
+	paymentID := fs.Int("id", 0, "ID платежа")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	if err := requireFlag(fs, "id"); err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	if err := authorizer.CanConfirmPayment(ctx, user, *paymentID); err != nil {
+		return err
+	}
+
+	payment, booking, err := db.ConfirmPaymentAndBooking(ctx, *paymentID)
+	if err != nil {
+		return err
+	}
+	return printResult(*output, models.BookingWithPayment{Booking: booking, Payment: payment}, bookingWithPaymentTable(booking, payment))
+}
+
+func cmdReportsOccupancy(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("reports occupancy")
+	fromStr := fs.String("from", "", "начало периода: YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS")
+	toStr := fs.String("to", "", "конец периода: YYYY-MM-DD (включительно) или YYYY-MM-DDTHH:MM:SS")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	from, to, err := parsePeriodFlags(*fromStr, *toStr)
+	if err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	scope, err := authorizer.ReportScope(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	report, err := db.GetRoomOccupancy(ctx, from, to, scope)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		report = []models.RoomOccupancy{}
+	}
+	t := table{header: []string{"room_id", "room", "coworking", "bookings", "booked_hours", "total_hours", "blackout_hours", "occupancy_percentage"}}
+	for _, r := range report {
+		t.add(cellInt(r.RoomID), r.RoomName, r.CoworkingName, cellInt(r.TotalBookings), cellFloat(r.BookedHours),
+			cellFloat(r.TotalHours), cellFloat(r.BlackoutHours), cellFloat(r.OccupancyPercentage))
+	}
+	return printResult(*output, report, t)
+}
+
+func cmdReportsRevenue(ctx context.Context, args []string) error {
+	fs, output := newFlagSet("reports revenue")
+	fromStr := fs.String("from", "", "начало периода: YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS")
+	toStr := fs.String("to", "", "конец периода: YYYY-MM-DD (включительно) или YYYY-MM-DDTHH:MM:SS")
+	if err := parseFlags(fs, args, output); err != nil {
+		return err
+	}
+	from, to, err := parsePeriodFlags(*fromStr, *toStr)
+	if err != nil {
+		return err
+	}
+	ctx, user, err := commandUser(ctx)
+	if err != nil {
+		return err
+	}
+	scope, err := authorizer.ReportScope(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	report, err := db.GetRevenueReport(ctx, from, to, scope)
+	if err != nil {
+		return err
+	}
+	if report == nil {
+		report = []models.RevenueReport{}
+	}
+	t := table{header: []string{"coworking_id", "coworking", "bookings", "total_revenue", "confirmed_revenue", "pending_revenue", "refunded_amount", "promo_discount"}}
+	for _, r := range report {
+		t.add(cellInt(r.CoworkingID), r.CoworkingName, cellInt(r.TotalBookings), r.TotalRevenue.String(),
+			r.ConfirmedRevenue.String(), r.PendingRevenue.String(), r.RefundedAmount.String(), r.PromoDiscount.String())
+	}
+	return printResult(*output, report, t)
+}
+
+func bookingWithPaymentTable(b *models.Booking, p *models.Payment) table {
+	t := table{header: []string{"booking_id", "room_id", "starts_at", "ends_at", "total_amount", "status", "payment_id", "payment_amount", "payment_status", "provider_ref"}}
+	// бесплатное бронирование подтверждается без платежа — колонки платежа пустые
+	payment := make([]string, 4)
+	if p != nil {
+		payment = []string{cellInt(p.PaymentID), p.Amount.String(), p.Status, cellString(p.ProviderRef)}
+	}
+	t.add(append([]string{cellInt(b.BookingID), cellInt(b.RoomID), cellTime(b.StartsAt), cellTime(b.EndsAt),
+		b.TotalAmount.String(), b.Status}, payment...)...)
+	return t
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"coworking-booking/internal/database"
+	"coworking-booking/migrations"
+	"fmt"
+	"strconv"
+)
+
+// runMigrate выполняет подкоманды migrate up | down [N] | status | baseline <версия>
+func runMigrate(ctx context.Context, args []string) error {
+	all, err := database.LoadMigrations(migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	cmd := "status"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		applied, err := db.MigrateUp(ctx, all)
+		for _, m := range applied {
+			fmt.Printf("applied  %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		reverted, err := db.MigrateDown(ctx, all, steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %04d_%s\n", m.Version, m.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := db.MigrationStatus(ctx, all)
+		if err != nil {
+			return err
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", st.Version, st.Name, applied)
+		}
+		return nil
+
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: migrate baseline <version>")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version <= 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := db.MigrateBaseline(ctx, all, version); err != nil {
+			return err
+		}
+		fmt.Printf("marked migrations up to %04d as applied\n", version)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down, status, baseline)", cmd)
+	}
+}
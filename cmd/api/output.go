@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"coworking-booking/internal/money"
+)
+
+// Форматы вывода неинтерактивных команд (--output)
+const (
+	formatTable = "table" // выровненные колонки для человека
+	formatJSON  = "json"  // структуры models, как в HTTP API
+	formatCSV   = "csv"   // те же колонки, что в table, с заголовком
+)
+
+// table — строки результата для форматов table и csv
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// printResult печатает результат в stdout: data — в json, t — в table и csv
+func printResult(format string, data any, t table) error {
+	return writeResult(os.Stdout, format, data, t)
+}
+
+func writeResult(w io.Writer, format string, data any, t table) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// Форматирование ячеек: пустые значения — пустая строка, время — по часам коворкинга
+
+func cellTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+func cellTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return cellTime(*t)
+}
+
+func cellMoneyPtr(m *money.Money) string {
+	if m == nil {
+		return ""
+	}
+	return m.String()
+}
+
+func cellString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func cellInt(n int) string {
+	return strconv.Itoa(n)
+}
+
+func cellFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
@@ -1,8 +1,9 @@
 module coworking-booking
 
-go 1.21
+go 1.22
 
 require (
-	github.com/lib/pq v1.10.9
 	github.com/joho/godotenv v1.5.1
+	github.com/lib/pq v1.10.9
+	golang.org/x/crypto v0.21.0
 )
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+// maxAuditLimit ограничивает число записей журнала в одном ответе
+const maxAuditLimit = 1000
+
+// handleAuditLog: GET /api/v1/audit — журнал изменений (только администратор).
+// Параметры: entity, entity_id, booking_id (история бронирования с платежами, возвратами
+// и документами), payment_id, actor_id, from/to (RFC 3339), limit — последние записи
+func (s *Server) handleAuditLog(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanViewAuditLog(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := s.db.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{Entity: q.Get("entity")}
+
+	ids := []struct {
+		name string
+		dst  *int
+	}{
+		{"entity_id", &filter.EntityID},
+		{"booking_id", &filter.BookingID},
+		{"payment_id", &filter.PaymentID},
+		{"actor_id", &filter.ActorUserID},
+		{"limit", &filter.Limit},
+	}
+	for _, p := range ids {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, fmt.Errorf("invalid %s", p.name)
+		}
+		*p.dst = n
+	}
+	if filter.EntityID != 0 && filter.Entity == "" {
+		return filter, fmt.Errorf("entity_id requires entity")
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s: expected RFC 3339 time", p.name)
+		}
+		*p.dst = &t
+	}
+	return filter, nil
+}
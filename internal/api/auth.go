@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"coworking-booking/internal/auth"
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+)
+
+type ctxKey int
+
+const userKey ctxKey = iota
+
+// requireAuth пропускает запрос только с действующим bearer-токеном
+// и кладёт пользователя в контекст запроса; его изменения записываются в audit_log от его имени
+func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.auth.Authenticate(r.Context(), bearerToken(r))
+		if err != nil {
+			writeAuthError(w, err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), userKey, user)
+		ctx = database.WithActor(ctx, database.UserActor(user))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// currentUser возвращает пользователя, установленного requireAuth
+func currentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userKey).(*models.User)
+	return user
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(header) > len(prefix) && strings.EqualFold(header[:len(prefix)], prefix) {
+		return strings.TrimSpace(header[len(prefix):])
+	}
+	return ""
+}
+
+// writeAuthError переводит ошибку аутентификации в HTTP-статус
+func writeAuthError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated), errors.Is(err, auth.ErrInvalidCredentials):
+		w.Header().Set("WWW-Authenticate", `Bearer realm="coworking-booking"`)
+		writeError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, auth.ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, database.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		log.Printf("Error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := s.auth.Register(r.Context(), req.Email, req.Password, req.FullName)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, user)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := s.auth.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if err := s.auth.Logout(r.Context(), bearerToken(r)); err != nil {
+		writeAuthError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentUser(r))
+}
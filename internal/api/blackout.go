@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+// handleListBlackouts: GET /api/v1/coworkings/{id}/blackouts?from=RFC3339 (по умолчанию — сейчас);
+// блокировки коворкинга и всех его комнат
+func (s *Server) handleListBlackouts(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	from := time.Now()
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid from: expected RFC3339")
+			return
+		}
+	}
+
+	blackouts, err := s.db.GetBlackouts(r.Context(), coworkingID, from)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(blackouts))
+}
+
+func (s *Server) handleCreateCoworkingBlackout(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	s.createBlackout(w, r, nil, &coworkingID)
+}
+
+func (s *Server) handleCreateRoomBlackout(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageRoomSchedule(r.Context(), currentUser(r), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	s.createBlackout(w, r, &roomID, nil)
+}
+
+// createBlackout создаёт блокировку; в ответе — пересекающиеся бронирования, которые нужно разобрать
+func (s *Server) createBlackout(w http.ResponseWriter, r *http.Request, roomID, coworkingID *int) {
+	var req models.CreateBlackoutRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		writeError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		writeError(w, http.StatusBadRequest, "starts_at must be before ends_at")
+		return
+	}
+
+	result, err := s.db.CreateBlackout(r.Context(), currentUser(r).UserID, roomID, coworkingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func (s *Server) handleDeleteBlackout(w http.ResponseWriter, r *http.Request) {
+	blackoutID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	coworkingID, err := s.db.GetBlackoutCoworkingID(r.Context(), blackoutID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err := s.db.DeleteBlackout(r.Context(), blackoutID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,398 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+)
+
+func (s *Server) handleGetCoworking(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, err := s.db.GetCoworkingByID(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// handleUpdateCoworking: PATCH /api/v1/coworkings/{id} — изменяются только переданные поля;
+// часовой пояс нельзя сменить при предстоящих бронированиях (409)
+func (s *Server) handleUpdateCoworking(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.UpdateCoworkingRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (req.Name != nil && strings.TrimSpace(*req.Name) == "") || (req.Address != nil && strings.TrimSpace(*req.Address) == "") {
+		writeError(w, http.StatusBadRequest, "name and address cannot be empty")
+		return
+	}
+	if req.TimeZone != nil {
+		if err := localtime.Validate(*req.TimeZone); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid time_zone: expected IANA name like Europe/Moscow")
+			return
+		}
+	}
+
+	c, err := s.db.UpdateCoworking(r.Context(), coworkingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// handleDeleteCoworking: DELETE /api/v1/coworkings/{id} — 409, если есть предстоящие
+// бронирования, история бронирований или выданные счета
+func (s *Server) handleDeleteCoworking(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.DeleteCoworking(r.Context(), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetRoom(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	room, err := s.db.GetRoomByID(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// handleUpdateRoom: PATCH /api/v1/rooms/{id} — изменяются только переданные поля
+func (s *Server) handleUpdateRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.UpdateRoomRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (req.Name != nil && strings.TrimSpace(*req.Name) == "") || (req.Capacity != nil && *req.Capacity <= 0) ||
+		(req.AreaSqm != nil && *req.AreaSqm <= 0) || (req.HourlyRate != nil && req.HourlyRate.Sign() < 0) {
+		writeError(w, http.StatusBadRequest, "name cannot be empty, capacity and area_sqm must be positive, hourly_rate non-negative")
+		return
+	}
+
+	room, err := s.db.UpdateRoom(r.Context(), roomID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// handleDeleteRoom: DELETE /api/v1/rooms/{id} — 409, если есть предстоящие бронирования
+// или история бронирований
+func (s *Server) handleDeleteRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.DeleteRoom(r.Context(), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAddRoomEquipment: PUT /api/v1/rooms/{id}/equipment/{equipment_id} — идемпотентно
+func (s *Server) handleAddRoomEquipment(w http.ResponseWriter, r *http.Request) {
+	s.changeRoomEquipment(w, r, s.db.AddEquipmentToRoom)
+}
+
+// handleRemoveRoomEquipment: DELETE /api/v1/rooms/{id}/equipment/{equipment_id}
+func (s *Server) handleRemoveRoomEquipment(w http.ResponseWriter, r *http.Request) {
+	s.changeRoomEquipment(w, r, s.db.RemoveEquipmentFromRoom)
+}
+
+func (s *Server) changeRoomEquipment(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, roomID, equipmentID int) error) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	equipmentID, err := pathID(r, "equipment_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := change(r.Context(), roomID, equipmentID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	room, err := s.db.GetRoomByID(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+func (s *Server) handleListEquipment(w http.ResponseWriter, r *http.Request) {
+	equipment, err := s.db.GetAllEquipment(r.Context())
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(equipment))
+}
+
+func (s *Server) handleGetEquipment(w http.ResponseWriter, r *http.Request) {
+	equipmentID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	e, err := s.db.GetEquipmentByID(r.Context(), equipmentID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// handleCreateEquipment: POST /api/v1/equipment — существующее имя обновляет описание
+func (s *Server) handleCreateEquipment(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var req models.EquipmentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	e, err := s.db.CreateEquipment(r.Context(), req.Name, req.Description)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, e)
+}
+
+// handleUpdateEquipment: PUT /api/v1/equipment/{id} — новое имя и описание
+func (s *Server) handleUpdateEquipment(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	equipmentID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.EquipmentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	e, err := s.db.UpdateEquipment(r.Context(), equipmentID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, e)
+}
+
+// handleDeleteEquipment: DELETE /api/v1/equipment/{id} — снимает оборудование со всех комнат;
+// 409, если его требуют активные заявки листа ожидания
+func (s *Server) handleDeleteEquipment(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	equipmentID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.DeleteEquipment(r.Context(), equipmentID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleArchiveCoworking: POST /api/v1/coworkings/{id}/archive — скрывает коворкинг и его
+// комнаты из поиска; возвращает предстоящие бронирования, отмеченные для переноса
+func (s *Server) handleArchiveCoworking(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.db.ArchiveCoworking(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRestoreCoworking(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c, err := s.db.RestoreCoworking(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+// handleArchiveRoom: POST /api/v1/rooms/{id}/archive — как handleArchiveCoworking для одной комнаты
+func (s *Server) handleArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.db.ArchiveRoom(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleRestoreRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	room, err := s.db.RestoreRoom(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, room)
+}
+
+// handleRelocations: GET /api/v1/bookings/relocations — предстоящие бронирования архивных
+// комнат и коворкингов (менеджер — своих коворкингов, администратор — все)
+func (s *Server) handleRelocations(w http.ResponseWriter, r *http.Request) {
+	scope, err := s.authz.RelocationScope(r.Context(), currentUser(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	bookings, err := s.db.GetBookingsNeedingRelocation(r.Context(), scope)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bookings)
+}
+
+func includeArchived(r *http.Request) bool {
+	v, _ := strconv.ParseBool(r.URL.Query().Get("include_archived"))
+	return v
+}
@@ -0,0 +1,432 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+)
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := s.db.PingContext(r.Context()); err != nil {
+		writeError(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleListCoworkings: GET /api/v1/coworkings?include_archived=true — архивные только по запросу
+func (s *Server) handleListCoworkings(w http.ResponseWriter, r *http.Request) {
+	coworkings, err := s.db.GetAllCoworkings(r.Context(), includeArchived(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(coworkings))
+}
+
+func (s *Server) handleCreateCoworking(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var req models.CreateCoworkingRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Address) == "" {
+		writeError(w, http.StatusBadRequest, "name and address are required")
+		return
+	}
+
+	if req.TimeZone == "" {
+		req.TimeZone = localtime.DefaultZone
+	}
+	if err := localtime.Validate(req.TimeZone); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid time_zone: expected IANA name like Europe/Moscow")
+		return
+	}
+
+	c, err := s.db.CreateCoworking(r.Context(), req.Name, req.Address, req.Description, req.TimeZone)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// handleListRooms: GET /api/v1/coworkings/{id}/rooms?include_archived=true
+func (s *Server) handleListRooms(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rooms, err := s.db.GetRoomsByCoworking(r.Context(), coworkingID, includeArchived(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rooms))
+}
+
+func (s *Server) handleCreateRoom(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageRooms(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.CreateRoomRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" || req.Capacity <= 0 || req.HourlyRate.Sign() < 0 {
+		writeError(w, http.StatusBadRequest, "name, positive capacity and non-negative hourly_rate are required")
+		return
+	}
+
+	room, err := s.db.CreateRoom(r.Context(), coworkingID, req.Name, req.Capacity, req.AreaSqm, req.HourlyRate)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, room)
+}
+
+// handleSearchRooms: GET /api/v1/rooms/available?starts_at=&ends_at=&coworking_id=&city=&min_capacity=&max_rate=
+// &equipment_ids=1,2&equipment=Проектор,Флипчарт&sort=-capacity
+// starts_at/ends_at — моменты времени в RFC 3339 со смещением
+func (s *Server) handleSearchRooms(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var params models.SearchRoomParams
+	var err error
+	if params.StartsAt, err = time.Parse(time.RFC3339, q.Get("starts_at")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid starts_at: expected RFC 3339")
+		return
+	}
+	if params.EndsAt, err = time.Parse(time.RFC3339, q.Get("ends_at")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid ends_at: expected RFC 3339")
+		return
+	}
+	if !params.StartsAt.Before(params.EndsAt) {
+		writeError(w, http.StatusBadRequest, "starts_at must be before ends_at")
+		return
+	}
+	if v := q.Get("coworking_id"); v != "" {
+		coworkingID, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid coworking_id")
+			return
+		}
+		params.CoworkingID = &coworkingID
+	}
+	if v := q.Get("min_capacity"); v != "" {
+		capacity, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid min_capacity")
+			return
+		}
+		params.MinCapacity = &capacity
+	}
+	if v := q.Get("max_rate"); v != "" {
+		rate, err := money.Parse(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid max_rate")
+			return
+		}
+		params.MaxRate = &rate
+	}
+	if v := q.Get("city"); v != "" {
+		params.City = &v
+	}
+	if params.EquipmentIDs, err = parseIntList(q.Get("equipment_ids")); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid equipment_ids: "+err.Error())
+		return
+	}
+	params.EquipmentNames = parseNameList(q.Get("equipment"))
+	params.Sort = q.Get("sort")
+	if err := database.ValidateRoomSort(params.Sort); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rooms, err := s.db.SearchAvailableRooms(r.Context(), params)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rooms))
+}
+
+func (s *Server) handleCreateBooking(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateBookingRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RoomID <= 0 {
+		writeError(w, http.StatusBadRequest, "room_id is required")
+		return
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		writeError(w, http.StatusBadRequest, "starts_at must be before ends_at")
+		return
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = "card"
+	}
+
+	booking, payment, err := s.db.CreateBookingWithPayment(r.Context(), req.RoomID, currentUser(r).UserID, req.StartsAt, req.EndsAt, req.PaymentMethod, req.PromoCode)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, models.BookingWithPayment{Booking: booking, Payment: payment})
+}
+
+func (s *Server) handleCancelBooking(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user := currentUser(r)
+	if err := s.authz.CanCancelBooking(r.Context(), user, bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result, err := s.db.CancelBookingWithRefund(r.Context(), bookingID, user.UserID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleCancellationQuote показывает возврат при отмене сейчас, до подтверждения отмены
+func (s *Server) handleCancellationQuote(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanCancelBooking(r.Context(), currentUser(r), bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	quote, err := s.db.QuoteCancellation(r.Context(), bookingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// handleRescheduleBooking переносит или продлевает бронирование: PATCH с room_id/starts_at/ends_at
+func (s *Server) handleRescheduleBooking(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.RescheduleBookingRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RoomID == nil && req.StartsAt == nil && req.EndsAt == nil {
+		writeError(w, http.StatusBadRequest, "at least one of room_id, starts_at, ends_at is required")
+		return
+	}
+
+	user := currentUser(r)
+	if err := s.authz.CanRescheduleBooking(r.Context(), user, bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result, err := s.db.RescheduleBooking(r.Context(), bookingID, user.UserID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleConfirmPayment(w http.ResponseWriter, r *http.Request) {
+	paymentID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.authz.CanConfirmPayment(r.Context(), currentUser(r), paymentID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	payment, booking, err := s.db.ConfirmPaymentAndBooking(r.Context(), paymentID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, models.BookingWithPayment{Booking: booking, Payment: payment})
+}
+
+func (s *Server) handleMyBookings(w http.ResponseWriter, r *http.Request) {
+	bookings, err := s.db.GetUserBookings(r.Context(), currentUser(r).UserID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(bookings))
+}
+
+func (s *Server) handleMyStatistics(w http.ResponseWriter, r *http.Request) {
+	stats, err := s.db.GetUserStatistics(r.Context(), currentUser(r).UserID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleUserBookings — история бронирований любого пользователя (FR12, администратор)
+func (s *Server) handleUserBookings(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanViewUserBookings(r.Context(), currentUser(r), userID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	bookings, err := s.db.GetUserBookings(r.Context(), userID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(bookings))
+}
+
+func (s *Server) handleSetUserRole(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageUsers(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	userID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.SetRoleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.SetUserRole(r.Context(), userID, req.Role); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAssignManager(w http.ResponseWriter, r *http.Request) {
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.AssignManagerRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.AssignCoworkingManager(r.Context(), coworkingID, req.UserID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleOccupancyReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scope, err := s.authz.ReportScope(r.Context(), currentUser(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	report, err := s.db.GetRoomOccupancy(r.Context(), from, to, scope)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(report))
+}
+
+func (s *Server) handleRevenueReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	scope, err := s.authz.ReportScope(r.Context(), currentUser(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	report, err := s.db.GetRevenueReport(r.Context(), from, to, scope)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(report))
+}
+
+// nonNil гарантирует, что пустой список сериализуется как [], а не null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
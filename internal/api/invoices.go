@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	netmail "net/mail"
+	"strings"
+
+	"coworking-booking/internal/invoice"
+	"coworking-booking/internal/mail"
+	"coworking-booking/internal/models"
+)
+
+// handleSetLegalEntity задаёт реквизиты юридического лица коворкинга для новых счетов
+func (s *Server) handleSetLegalEntity(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageCoworkings(r.Context(), currentUser(r)); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var req models.LegalEntityRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.VATPercent < 0 || req.VATPercent > 100 {
+		writeError(w, http.StatusBadRequest, "vat_percent must be between 0 and 100")
+		return
+	}
+	if req.TaxID != nil && len(*req.TaxID) != 0 && len(*req.TaxID) != 10 && len(*req.TaxID) != 12 {
+		writeError(w, http.StatusBadRequest, "tax_id must have 10 or 12 digits")
+		return
+	}
+
+	c, err := s.db.SetCoworkingLegalEntity(r.Context(), coworkingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (s *Server) handleGetBookingInvoices(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanViewBooking(r.Context(), currentUser(r), bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	invoices, err := s.db.GetBookingInvoices(r.Context(), bookingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, invoices)
+}
+
+// handleGetInvoice: GET /api/v1/invoices/{id}; ?format=html — печатная форма
+func (s *Server) handleGetInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, ok := s.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		writeJSON(w, http.StatusOK, inv)
+	case "html":
+		var page bytes.Buffer
+		if err := invoice.RenderHTML(&page, inv); err != nil {
+			writeDBError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(page.Bytes())
+	default:
+		writeError(w, http.StatusBadRequest, "format must be json or html")
+	}
+}
+
+// handleEmailInvoice отправляет печатную форму документа покупателю или на адрес to
+func (s *Server) handleEmailInvoice(w http.ResponseWriter, r *http.Request) {
+	var req models.InvoiceEmailRequest
+	if r.ContentLength != 0 {
+		if err := decodeJSON(w, r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	inv, ok := s.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		to = inv.BuyerEmail
+	}
+	if _, err := netmail.ParseAddress(to); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid email address")
+		return
+	}
+
+	var page bytes.Buffer
+	if err := invoice.RenderHTML(&page, inv); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	msg := mail.Message{To: to, Subject: invoice.Title(inv) + " — " + inv.SellerName, HTML: page.String()}
+	if err := s.mailer.Send(r.Context(), msg); err != nil {
+		log.Printf("Error: invoice %d: %v", inv.InvoiceID, err)
+		writeError(w, http.StatusBadGateway, "failed to send email")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.InvoiceEmailResult{InvoiceID: inv.InvoiceID, To: to})
+}
+
+// loadInvoice проверяет доступ к документу из пути и загружает его; false — ответ уже записан
+func (s *Server) loadInvoice(w http.ResponseWriter, r *http.Request) (*models.Invoice, bool) {
+	invoiceID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if err := s.authz.CanViewInvoice(r.Context(), currentUser(r), invoiceID); err != nil {
+		writeDBError(w, err)
+		return nil, false
+	}
+
+	inv, err := s.db.GetInvoice(r.Context(), invoiceID)
+	if err != nil {
+		writeDBError(w, err)
+		return nil, false
+	}
+	return inv, true
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/payments"
+)
+
+func (s *Server) handleAddPayment(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.AddPaymentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Amount != nil && req.Amount.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, "amount must be positive")
+		return
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = "card"
+	}
+
+	if err := s.authz.CanPayBooking(r.Context(), currentUser(r), bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	payment, err := s.db.AddPayment(r.Context(), bookingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, payment)
+}
+
+func (s *Server) handleGetBookingLedger(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanViewBooking(r.Context(), currentUser(r), bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	ledger, err := s.db.GetBookingLedger(r.Context(), bookingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+// handlePaymentWebhook принимает уведомление платёжного шлюза. Аутентификация — подпись
+// уведомления, а не сессия; ответ не 2xx означает, что шлюз должен повторить доставку
+func (s *Server) handlePaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	if s.provider == nil {
+		writeError(w, http.StatusNotFound, "payment provider is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+	ev, err := s.provider.ParseWebhook(body, r.Header)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := database.WithActor(r.Context(), database.SystemActor("payment_gateway:"+s.provider.Name()))
+	result, err := s.db.ApplyPaymentEvent(ctx, s.provider.Name(), *ev)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/schedule"
+)
+
+func (s *Server) handleGetCoworkingPricingRules(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rules, err := s.db.GetCoworkingPricingRules(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rules))
+}
+
+func (s *Server) handleGetRoomPricingRules(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rules, err := s.db.GetRoomPricingRules(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(rules))
+}
+
+func (s *Server) handleCreateCoworkingPricingRule(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManagePricing(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	s.createPricingRule(w, r, nil, &coworkingID)
+}
+
+func (s *Server) handleCreateRoomPricingRule(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageRoomPricing(r.Context(), currentUser(r), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	s.createPricingRule(w, r, &roomID, nil)
+}
+
+func (s *Server) createPricingRule(w http.ResponseWriter, r *http.Request, roomID, coworkingID *int) {
+	var req models.CreatePricingRuleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validatePricingRule(req); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	rule, err := s.db.CreatePricingRule(r.Context(), roomID, coworkingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// validatePricingRule проверяет поля, обязательные для вида правила (pricing_rule_fields_check)
+func validatePricingRule(req models.CreatePricingRuleRequest) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "name is required"
+	}
+	validRate := req.RatePercent != nil && *req.RatePercent >= 1 && *req.RatePercent <= 1000
+	validMinutes := req.MinMinutes != nil && *req.MinMinutes > 0
+
+	switch req.Kind {
+	case models.PricingTimeWindow:
+		if req.OpensAt == nil || req.ClosesAt == nil {
+			return "opens_at and closes_at are required"
+		}
+		if _, err := schedule.ParseWindow(*req.OpensAt, *req.ClosesAt); err != nil {
+			return err.Error()
+		}
+		for _, d := range req.Weekdays {
+			if d < 1 || d > 7 {
+				return "weekdays must be between 1 (Monday) and 7 (Sunday)"
+			}
+		}
+		if !validRate {
+			return "rate_percent must be between 1 and 1000"
+		}
+	case models.PricingWeekend:
+		if !validRate {
+			return "rate_percent must be between 1 and 1000"
+		}
+	case models.PricingMinDuration:
+		if !validMinutes {
+			return "min_minutes must be positive"
+		}
+	case models.PricingDurationDiscount:
+		if !validMinutes {
+			return "min_minutes must be positive"
+		}
+		if req.DiscountPercent == nil || *req.DiscountPercent < 1 || *req.DiscountPercent > 100 {
+			return "discount_percent must be between 1 and 100"
+		}
+	default:
+		return "kind must be one of time_window, weekend, min_duration, duration_discount"
+	}
+	return ""
+}
+
+func (s *Server) handleDeletePricingRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	coworkingID, err := s.db.GetPricingRuleCoworkingID(r.Context(), ruleID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if err := s.authz.CanManagePricing(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err := s.db.DeletePricingRule(r.Context(), ruleID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetBookingPriceLines возвращает расчёт стоимости бронирования
+func (s *Server) handleGetBookingPriceLines(w http.ResponseWriter, r *http.Request) {
+	bookingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanViewBooking(r.Context(), currentUser(r), bookingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	lines, err := s.db.GetBookingPriceLines(r.Context(), bookingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(lines))
+}
+
+func (s *Server) handleGetCoworkingCancellationPolicy(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tiers, err := s.db.GetCoworkingCancellationPolicy(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tiers)
+}
+
+func (s *Server) handleSetCoworkingCancellationPolicy(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManagePricing(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	req, ok := decodeCancellationPolicy(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.SetCoworkingCancellationPolicy(r.Context(), coworkingID, req.Tiers); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetRoomCancellationPolicy(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tiers, err := s.db.GetRoomCancellationPolicy(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tiers)
+}
+
+// handleSetRoomCancellationPolicy задаёт собственную политику отмены комнаты; пустой tiers — политика коворкинга
+func (s *Server) handleSetRoomCancellationPolicy(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageRoomPricing(r.Context(), currentUser(r), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	req, ok := decodeCancellationPolicy(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.SetRoomCancellationPolicy(r.Context(), roomID, req.Tiers); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeCancellationPolicy(w http.ResponseWriter, r *http.Request) (models.SetCancellationPolicyRequest, bool) {
+	var req models.SetCancellationPolicyRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	seen := make(map[int]bool)
+	for _, t := range req.Tiers {
+		if t.MinHoursBefore < 0 || t.RefundPercent < 0 || t.RefundPercent > 100 {
+			writeError(w, http.StatusBadRequest, "min_hours_before must be non-negative and refund_percent between 0 and 100")
+			return req, false
+		}
+		if seen[t.MinHoursBefore] {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("duplicate tier for %d hours", t.MinHoursBefore))
+			return req, false
+		}
+		seen[t.MinHoursBefore] = true
+	}
+	return req, true
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+)
+
+// promoCodePattern совпадает с promo_code_code_check после NormalizePromoCode
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{1,50}$`)
+
+// handleListPromoCodes: администратор видит все промокоды, менеджер — промокоды своих коворкингов
+func (s *Server) handleListPromoCodes(w http.ResponseWriter, r *http.Request) {
+	scope, err := s.authz.PromoCodeScope(r.Context(), currentUser(r))
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	promos, err := s.db.GetPromoCodes(r.Context(), scope)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(promos))
+}
+
+func (s *Server) handleCreatePromoCode(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePromoCodeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !promoCodePattern.MatchString(database.NormalizePromoCode(req.Code)) {
+		writeError(w, http.StatusBadRequest, "code must be 1-50 letters, digits, '-' or '_'")
+		return
+	}
+	if (req.DiscountPercent == nil) == (req.DiscountAmount == nil) {
+		writeError(w, http.StatusBadRequest, "exactly one of discount_percent and discount_amount is required")
+		return
+	}
+	if req.DiscountPercent != nil && (*req.DiscountPercent < 1 || *req.DiscountPercent > 100) {
+		writeError(w, http.StatusBadRequest, "discount_percent must be between 1 and 100")
+		return
+	}
+	if req.DiscountAmount != nil && req.DiscountAmount.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, "discount_amount must be positive")
+		return
+	}
+	if req.CoworkingID != nil && req.RoomID != nil {
+		writeError(w, http.StatusBadRequest, "set coworking_id or room_id, not both")
+		return
+	}
+	if (req.MaxRedemptions != nil && *req.MaxRedemptions <= 0) || (req.MaxPerUser != nil && *req.MaxPerUser <= 0) {
+		writeError(w, http.StatusBadRequest, "max_redemptions and max_per_user must be positive")
+		return
+	}
+	if req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidFrom.Before(*req.ValidUntil) {
+		writeError(w, http.StatusBadRequest, "valid_from must be before valid_until")
+		return
+	}
+
+	// Промокод комнаты проверяется по её коворкингу
+	coworkingID := req.CoworkingID
+	if req.RoomID != nil {
+		id, err := s.db.GetRoomCoworkingID(r.Context(), *req.RoomID)
+		if err != nil {
+			writeDBError(w, err)
+			return
+		}
+		coworkingID = &id
+	}
+	user := currentUser(r)
+	if err := s.authz.CanManagePromoCodes(r.Context(), user, coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	promo, err := s.db.CreatePromoCode(r.Context(), user.UserID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, promo)
+}
+
+func (s *Server) handleDeactivatePromoCode(w http.ResponseWriter, r *http.Request) {
+	promoCodeID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	coworkingID, err := s.db.GetPromoCodeCoworkingID(r.Context(), promoCodeID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if err := s.authz.CanManagePromoCodes(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err := s.db.DeactivatePromoCode(r.Context(), promoCodeID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/schedule"
+)
+
+func (s *Server) handleGetCoworkingHours(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hours, err := s.db.GetCoworkingHours(r.Context(), coworkingID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(hours))
+}
+
+func (s *Server) handleSetCoworkingHours(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	req, ok := decodeHours(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.SetCoworkingHours(r.Context(), coworkingID, req.Hours); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetRoomHours(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hours, err := s.db.GetRoomHours(r.Context(), roomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(hours))
+}
+
+// handleSetRoomHours задаёт собственное расписание комнаты; пустой hours — расписание коворкинга
+func (s *Server) handleSetRoomHours(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageRoomSchedule(r.Context(), currentUser(r), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	req, ok := decodeHours(w, r)
+	if !ok {
+		return
+	}
+	if err := s.db.SetRoomHours(r.Context(), roomID, req.Hours); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSetRoomBuffer задаёт буферы подготовки и уборки вокруг бронирований комнаты
+func (s *Server) handleSetRoomBuffer(w http.ResponseWriter, r *http.Request) {
+	roomID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageRoomSchedule(r.Context(), currentUser(r), roomID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var req models.SetRoomBufferRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.SetupMinutes < 0 || req.SetupMinutes > 240 || req.TeardownMinutes < 0 || req.TeardownMinutes > 240 {
+		writeError(w, http.StatusBadRequest, "setup_minutes and teardown_minutes must be between 0 and 240")
+		return
+	}
+	if err := s.db.SetRoomBuffer(r.Context(), roomID, req.SetupMinutes, req.TeardownMinutes); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListHolidays: GET /api/v1/coworkings/{id}/holidays?from=YYYY-MM-DD (по умолчанию — сегодня)
+func (s *Server) handleListHolidays(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = time.Now().Format(schedule.DayLayout)
+	} else if _, err := time.Parse(schedule.DayLayout, from); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from: expected YYYY-MM-DD")
+		return
+	}
+
+	holidays, err := s.db.GetHolidays(r.Context(), coworkingID, from)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(holidays))
+}
+
+func (s *Server) handleCreateHoliday(w http.ResponseWriter, r *http.Request) {
+	coworkingID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	var req models.CreateHolidayRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if _, err := time.Parse(schedule.DayLayout, req.Day); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid day: expected YYYY-MM-DD")
+		return
+	}
+	if (req.OpensAt == nil) != (req.ClosesAt == nil) {
+		writeError(w, http.StatusBadRequest, "opens_at and closes_at must be set together")
+		return
+	}
+	if req.OpensAt != nil {
+		if _, err := schedule.ParseWindow(*req.OpensAt, *req.ClosesAt); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	holiday, err := s.db.CreateHoliday(r.Context(), coworkingID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, holiday)
+}
+
+func (s *Server) handleDeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	holidayID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	coworkingID, err := s.db.GetHolidayCoworkingID(r.Context(), holidayID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	if err := s.authz.CanManageSchedule(r.Context(), currentUser(r), coworkingID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	if err := s.db.DeleteHoliday(r.Context(), holidayID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeHours читает и проверяет недельное расписание; при ошибке ответ уже записан
+func decodeHours(w http.ResponseWriter, r *http.Request) (models.SetOpeningHoursRequest, bool) {
+	var req models.SetOpeningHoursRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return req, false
+	}
+	for i, h := range req.Hours {
+		if h.Weekday < 1 || h.Weekday > 7 {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("hours[%d]: weekday must be between 1 and 7", i))
+			return req, false
+		}
+		if _, err := schedule.ParseWindow(h.OpensAt, h.ClosesAt); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("hours[%d]: %v", i, err))
+			return req, false
+		}
+	}
+	return req, true
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/recurrence"
+)
+
+func (s *Server) handleCreateSeries(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSeriesRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.RoomID <= 0 {
+		writeError(w, http.StatusBadRequest, "room_id is required")
+		return
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		writeError(w, http.StatusBadRequest, "starts_at must be before ends_at")
+		return
+	}
+	// UNTIL без Z задаётся по местным часам коворкинга комнаты
+	zone, err := s.db.GetRoomTimeZone(r.Context(), req.RoomID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	loc, err := localtime.Location(zone)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	rule, err := recurrence.Parse(req.RRule, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = "card"
+	}
+
+	result, err := s.db.CreateBookingSeries(r.Context(), currentUser(r).UserID, req, rule)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+func (s *Server) handleGetSeries(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.authz.CanManageSeries(r.Context(), currentUser(r), seriesID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	result, err := s.db.GetBookingSeries(r.Context(), seriesID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleUpdateSeries(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var req models.UpdateSeriesRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.BookingID <= 0 {
+		writeError(w, http.StatusBadRequest, "booking_id is required")
+		return
+	}
+	switch req.Scope {
+	case models.SeriesScopeThis, models.SeriesScopeFollowing, models.SeriesScopeAll:
+	default:
+		writeError(w, http.StatusBadRequest, "scope must be one of: this, following, all")
+		return
+	}
+	if req.RoomID == nil && req.StartsAt == nil && req.EndsAt == nil {
+		writeError(w, http.StatusBadRequest, "at least one of room_id, starts_at, ends_at is required")
+		return
+	}
+
+	user := currentUser(r)
+	if err := s.authz.CanManageSeries(r.Context(), user, seriesID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	results, err := s.db.UpdateBookingSeries(r.Context(), seriesID, user.UserID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func (s *Server) handleCancelSeries(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Тело необязательно: без него отменяются все будущие вхождения
+	var req models.CancelSeriesRequest
+	if err := decodeJSON(w, r, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user := currentUser(r)
+	if err := s.authz.CanManageSeries(r.Context(), user, seriesID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+
+	cancelled, err := s.db.CancelBookingSeries(r.Context(), seriesID, user.UserID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"series_id": seriesID, "cancelled_booking_ids": cancelled})
+}
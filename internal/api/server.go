@@ -0,0 +1,288 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/auth"
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/mail"
+	"coworking-booking/internal/payments"
+	"coworking-booking/internal/recurrence"
+)
+
+// requestTimeout — предельное время обработки запроса, включая запросы к БД
+const requestTimeout = 30 * time.Second
+
+// Server представляет HTTP/JSON API поверх database.DB
+type Server struct {
+	db       *database.DB
+	auth     *auth.Service
+	authz    *auth.Authorizer
+	provider payments.Provider // nil — уведомления шлюза не принимаются
+	mailer   mail.Sender
+	mux      *http.ServeMux
+}
+
+// NewServer создаёт API-сервер и регистрирует маршруты версии v1
+func NewServer(db *database.DB, authService *auth.Service, authorizer *auth.Authorizer, provider payments.Provider, mailer mail.Sender) *Server {
+	s := &Server{db: db, auth: authService, authz: authorizer, provider: provider, mailer: mailer, mux: http.NewServeMux()}
+	s.routes()
+	return s
+}
+
+// Handler возвращает корневой http.Handler сервера
+func (s *Server) Handler() http.Handler {
+	return s.logRequests(withTimeout(s.mux, requestTimeout))
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+
+	s.mux.HandleFunc("POST /api/v1/auth/register", s.handleRegister)
+	s.mux.HandleFunc("POST /api/v1/auth/login", s.handleLogin)
+	s.mux.HandleFunc("POST /api/v1/auth/logout", s.requireAuth(s.handleLogout))
+	s.mux.HandleFunc("GET /api/v1/auth/me", s.requireAuth(s.handleMe))
+
+	s.mux.HandleFunc("GET /api/v1/coworkings", s.handleListCoworkings)
+	s.mux.HandleFunc("POST /api/v1/coworkings", s.requireAuth(s.handleCreateCoworking))
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}", s.handleGetCoworking)
+	s.mux.HandleFunc("PATCH /api/v1/coworkings/{id}", s.requireAuth(s.handleUpdateCoworking))
+	s.mux.HandleFunc("DELETE /api/v1/coworkings/{id}", s.requireAuth(s.handleDeleteCoworking))
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/archive", s.requireAuth(s.handleArchiveCoworking))
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/restore", s.requireAuth(s.handleRestoreCoworking))
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/rooms", s.handleListRooms)
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/rooms", s.requireAuth(s.handleCreateRoom))
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/managers", s.requireAuth(s.handleAssignManager))
+	s.mux.HandleFunc("PUT /api/v1/coworkings/{id}/legal-entity", s.requireAuth(s.handleSetLegalEntity))
+	s.mux.HandleFunc("GET /api/v1/rooms/{id}", s.handleGetRoom)
+	s.mux.HandleFunc("PATCH /api/v1/rooms/{id}", s.requireAuth(s.handleUpdateRoom))
+	s.mux.HandleFunc("DELETE /api/v1/rooms/{id}", s.requireAuth(s.handleDeleteRoom))
+	s.mux.HandleFunc("POST /api/v1/rooms/{id}/archive", s.requireAuth(s.handleArchiveRoom))
+	s.mux.HandleFunc("POST /api/v1/rooms/{id}/restore", s.requireAuth(s.handleRestoreRoom))
+	s.mux.HandleFunc("PUT /api/v1/rooms/{id}/equipment/{equipment_id}", s.requireAuth(s.handleAddRoomEquipment))
+	s.mux.HandleFunc("DELETE /api/v1/rooms/{id}/equipment/{equipment_id}", s.requireAuth(s.handleRemoveRoomEquipment))
+	s.mux.HandleFunc("GET /api/v1/equipment", s.handleListEquipment)
+	s.mux.HandleFunc("POST /api/v1/equipment", s.requireAuth(s.handleCreateEquipment))
+	s.mux.HandleFunc("GET /api/v1/equipment/{id}", s.handleGetEquipment)
+	s.mux.HandleFunc("PUT /api/v1/equipment/{id}", s.requireAuth(s.handleUpdateEquipment))
+	s.mux.HandleFunc("DELETE /api/v1/equipment/{id}", s.requireAuth(s.handleDeleteEquipment))
+
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/hours", s.handleGetCoworkingHours)
+	s.mux.HandleFunc("PUT /api/v1/coworkings/{id}/hours", s.requireAuth(s.handleSetCoworkingHours))
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/holidays", s.handleListHolidays)
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/holidays", s.requireAuth(s.handleCreateHoliday))
+	s.mux.HandleFunc("DELETE /api/v1/holidays/{id}", s.requireAuth(s.handleDeleteHoliday))
+	s.mux.HandleFunc("GET /api/v1/rooms/{id}/hours", s.handleGetRoomHours)
+	s.mux.HandleFunc("PUT /api/v1/rooms/{id}/hours", s.requireAuth(s.handleSetRoomHours))
+	s.mux.HandleFunc("PUT /api/v1/rooms/{id}/buffer", s.requireAuth(s.handleSetRoomBuffer))
+
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/blackouts", s.requireAuth(s.handleListBlackouts))
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/blackouts", s.requireAuth(s.handleCreateCoworkingBlackout))
+	s.mux.HandleFunc("POST /api/v1/rooms/{id}/blackouts", s.requireAuth(s.handleCreateRoomBlackout))
+	s.mux.HandleFunc("DELETE /api/v1/blackouts/{id}", s.requireAuth(s.handleDeleteBlackout))
+
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/pricing-rules", s.handleGetCoworkingPricingRules)
+	s.mux.HandleFunc("POST /api/v1/coworkings/{id}/pricing-rules", s.requireAuth(s.handleCreateCoworkingPricingRule))
+	s.mux.HandleFunc("GET /api/v1/rooms/{id}/pricing-rules", s.handleGetRoomPricingRules)
+	s.mux.HandleFunc("POST /api/v1/rooms/{id}/pricing-rules", s.requireAuth(s.handleCreateRoomPricingRule))
+	s.mux.HandleFunc("DELETE /api/v1/pricing-rules/{id}", s.requireAuth(s.handleDeletePricingRule))
+
+	s.mux.HandleFunc("GET /api/v1/coworkings/{id}/cancellation-policy", s.handleGetCoworkingCancellationPolicy)
+	s.mux.HandleFunc("PUT /api/v1/coworkings/{id}/cancellation-policy", s.requireAuth(s.handleSetCoworkingCancellationPolicy))
+	s.mux.HandleFunc("GET /api/v1/rooms/{id}/cancellation-policy", s.handleGetRoomCancellationPolicy)
+	s.mux.HandleFunc("PUT /api/v1/rooms/{id}/cancellation-policy", s.requireAuth(s.handleSetRoomCancellationPolicy))
+
+	s.mux.HandleFunc("GET /api/v1/promo-codes", s.requireAuth(s.handleListPromoCodes))
+	s.mux.HandleFunc("POST /api/v1/promo-codes", s.requireAuth(s.handleCreatePromoCode))
+	s.mux.HandleFunc("POST /api/v1/promo-codes/{id}/deactivate", s.requireAuth(s.handleDeactivatePromoCode))
+
+	s.mux.HandleFunc("GET /api/v1/rooms/available", s.handleSearchRooms)
+
+	s.mux.HandleFunc("POST /api/v1/bookings", s.requireAuth(s.handleCreateBooking))
+	s.mux.HandleFunc("GET /api/v1/bookings/relocations", s.requireAuth(s.handleRelocations))
+	s.mux.HandleFunc("PATCH /api/v1/bookings/{id}", s.requireAuth(s.handleRescheduleBooking))
+	s.mux.HandleFunc("GET /api/v1/bookings/{id}/price-lines", s.requireAuth(s.handleGetBookingPriceLines))
+	s.mux.HandleFunc("GET /api/v1/bookings/{id}/cancellation-quote", s.requireAuth(s.handleCancellationQuote))
+	s.mux.HandleFunc("POST /api/v1/bookings/{id}/cancel", s.requireAuth(s.handleCancelBooking))
+	s.mux.HandleFunc("GET /api/v1/bookings/{id}/payments", s.requireAuth(s.handleGetBookingLedger))
+	s.mux.HandleFunc("POST /api/v1/bookings/{id}/payments", s.requireAuth(s.handleAddPayment))
+	s.mux.HandleFunc("GET /api/v1/bookings/{id}/invoices", s.requireAuth(s.handleGetBookingInvoices))
+	s.mux.HandleFunc("GET /api/v1/invoices/{id}", s.requireAuth(s.handleGetInvoice))
+	s.mux.HandleFunc("POST /api/v1/invoices/{id}/email", s.requireAuth(s.handleEmailInvoice))
+	s.mux.HandleFunc("POST /api/v1/payments/{id}/confirm", s.requireAuth(s.handleConfirmPayment))
+	s.mux.HandleFunc("POST /api/v1/payments/webhook", s.handlePaymentWebhook)
+
+	s.mux.HandleFunc("POST /api/v1/waitlist", s.requireAuth(s.handleJoinWaitlist))
+	s.mux.HandleFunc("DELETE /api/v1/waitlist/{id}", s.requireAuth(s.handleLeaveWaitlist))
+
+	s.mux.HandleFunc("POST /api/v1/booking-series", s.requireAuth(s.handleCreateSeries))
+	s.mux.HandleFunc("GET /api/v1/booking-series/{id}", s.requireAuth(s.handleGetSeries))
+	s.mux.HandleFunc("PATCH /api/v1/booking-series/{id}", s.requireAuth(s.handleUpdateSeries))
+	s.mux.HandleFunc("POST /api/v1/booking-series/{id}/cancel", s.requireAuth(s.handleCancelSeries))
+
+	s.mux.HandleFunc("GET /api/v1/me/bookings", s.requireAuth(s.handleMyBookings))
+	s.mux.HandleFunc("GET /api/v1/me/statistics", s.requireAuth(s.handleMyStatistics))
+	s.mux.HandleFunc("GET /api/v1/me/waitlist", s.requireAuth(s.handleMyWaitlist))
+
+	s.mux.HandleFunc("GET /api/v1/users/{id}/bookings", s.requireAuth(s.handleUserBookings))
+	s.mux.HandleFunc("PUT /api/v1/users/{id}/role", s.requireAuth(s.handleSetUserRole))
+
+	s.mux.HandleFunc("GET /api/v1/reports/occupancy", s.requireAuth(s.handleOccupancyReport))
+	s.mux.HandleFunc("GET /api/v1/reports/revenue", s.requireAuth(s.handleRevenueReport))
+
+	s.mux.HandleFunc("GET /api/v1/audit", s.requireAuth(s.handleAuditLog))
+}
+
+// logRequests логирует метод, путь, код ответа и длительность запроса
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
+
+// withTimeout ограничивает контекст запроса; при отключении клиента или истечении
+// времени контекст отменяется, и database.DB прерывает запрос к PostgreSQL
+func withTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// errorResponse — тело ответа при ошибке
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error: failed to encode response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
+
+// writeDBError переводит ошибку слоя данных или авторизации в HTTP-статус
+func writeDBError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrForbidden):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, database.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, database.ErrRoomUnavailable), errors.Is(err, database.ErrOutsideOpeningHours),
+		errors.Is(err, database.ErrConflict):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, database.ErrPromoCodeInvalid):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, recurrence.ErrInvalidRule):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		writeError(w, http.StatusGatewayTimeout, "request timed out")
+	case errors.Is(err, context.Canceled):
+		// Клиент отключился, ответ уже никто не прочитает
+		writeError(w, http.StatusServiceUnavailable, "request cancelled")
+	default:
+		log.Printf("Error: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// decodeJSON читает тело запроса в v, отклоняя неизвестные поля
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
+
+func pathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid %s", name)
+	}
+	return id, nil
+}
+
+// parseLocalTime принимает местное время коворкинга: YYYY-MM-DD или YYYY-MM-DDTHH:MM:SS без смещения
+func parseLocalTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
+
+// parsePeriod читает параметры from/to отчётов. Период задаётся по местным часам
+// каждого коворкинга и полуоткрыт [from, to); дата без времени в to включает весь день
+func parsePeriod(r *http.Request) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+	from, _, err := parseLocalTime(q.Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS")
+	}
+	to, dateOnly, err := parseLocalTime(q.Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: expected YYYY-MM-DD or YYYY-MM-DDTHH:MM:SS")
+	}
+	if dateOnly {
+		to = to.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseIntList(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var ids []int
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseNameList разбирает список названий через запятую; пустые элементы пропускаются
+func parseNameList(value string) []string {
+	var names []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			names = append(names, part)
+		}
+	}
+	return names
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"coworking-booking/internal/models"
+)
+
+func (s *Server) handleJoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	var req models.JoinWaitlistRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		writeError(w, http.StatusBadRequest, "starts_at must be before ends_at")
+		return
+	}
+
+	entry, err := s.db.JoinWaitlist(r.Context(), currentUser(r).UserID, req)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) handleMyWaitlist(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.db.GetUserWaitlist(r.Context(), currentUser(r).UserID)
+	if err != nil {
+		writeDBError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nonNil(entries))
+}
+
+func (s *Server) handleLeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	entryID, err := pathID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := s.db.LeaveWaitlist(r.Context(), entryID, currentUser(r).UserID); err != nil {
+		writeDBError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
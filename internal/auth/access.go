@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+)
+
+// Роли пользователей (user_role_check)
+const (
+	RoleUser    = "user"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+)
+
+// ErrForbidden — у пользователя нет прав на операцию; проверяется через errors.Is
+var ErrForbidden = errors.New("доступ запрещён")
+
+// AccessError описывает отказ в доступе к конкретной операции
+type AccessError struct {
+	Op     string // операция, например "confirm_payment"
+	Role   string // роль пользователя, которому отказано
+	Reason string // причина отказа для показа пользователю
+}
+
+func (e *AccessError) Error() string {
+	return fmt.Sprintf("доступ запрещён (%s, роль %s): %s", e.Op, e.Role, e.Reason)
+}
+
+// Is позволяет сравнивать AccessError с ErrForbidden
+func (e *AccessError) Is(target error) bool {
+	return target == ErrForbidden
+}
+
+func deny(user *models.User, op, reason string) error {
+	return &AccessError{Op: op, Role: user.Role, Reason: reason}
+}
+
+// Authorizer решает, может ли пользователь выполнить операцию database.DB:
+//   - admin управляет коворкингами, комнатами и ролями, видит всё;
+//   - manager подтверждает платежи и смотрит отчёты только своих коворкингов;
+//   - user видит и отменяет только свои бронирования.
+type Authorizer struct {
+	db *database.DB
+}
+
+// NewAuthorizer создаёт слой авторизации
+func NewAuthorizer(db *database.DB) *Authorizer {
+	return &Authorizer{db: db}
+}
+
+// CanManageCoworkings — создание, изменение, архивирование и восстановление коворкингов,
+// назначение менеджеров и ролей
+func (a *Authorizer) CanManageCoworkings(ctx context.Context, user *models.User) error {
+	if user.Role != RoleAdmin {
+		return deny(user, "manage_coworkings", "только администратор управляет коворкингами")
+	}
+	return nil
+}
+
+// CanManageUsers — изменение ролей пользователей
+func (a *Authorizer) CanManageUsers(ctx context.Context, user *models.User) error {
+	if user.Role != RoleAdmin {
+		return deny(user, "manage_users", "только администратор управляет ролями")
+	}
+	return nil
+}
+
+// CanManageRooms — создание, изменение, архивирование и восстановление комнат, оборудование
+func (a *Authorizer) CanManageRooms(ctx context.Context, user *models.User) error {
+	if user.Role != RoleAdmin {
+		return deny(user, "manage_rooms", "только администратор управляет комнатами")
+	}
+	return nil
+}
+
+// CanViewAuditLog — журнал изменений: история бронирований, платежей и настроек
+func (a *Authorizer) CanViewAuditLog(ctx context.Context, user *models.User) error {
+	if user.Role != RoleAdmin {
+		return deny(user, "view_audit_log", "журнал изменений доступен только администратору")
+	}
+	return nil
+}
+
+// CanManageSchedule — часы работы, праздничные дни и блокировки: администратор или менеджер коворкинга
+func (a *Authorizer) CanManageSchedule(ctx context.Context, user *models.User, coworkingID int) error {
+	switch user.Role {
+	case RoleAdmin:
+		return nil
+	case RoleManager:
+		return a.requireManagerOf(ctx, user, coworkingID, "manage_schedule")
+	default:
+		return deny(user, "manage_schedule", "расписанием управляет только менеджер или администратор")
+	}
+}
+
+// CanManageRoomSchedule — часы работы, буферы и блокировки комнаты, как CanManageSchedule для её коворкинга
+func (a *Authorizer) CanManageRoomSchedule(ctx context.Context, user *models.User, roomID int) error {
+	coworkingID, err := a.db.GetRoomCoworkingID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	return a.CanManageSchedule(ctx, user, coworkingID)
+}
+
+// CanManagePricing — правила цены коворкинга: администратор или менеджер коворкинга
+func (a *Authorizer) CanManagePricing(ctx context.Context, user *models.User, coworkingID int) error {
+	switch user.Role {
+	case RoleAdmin:
+		return nil
+	case RoleManager:
+		return a.requireManagerOf(ctx, user, coworkingID, "manage_pricing")
+	default:
+		return deny(user, "manage_pricing", "тарифами управляет только менеджер или администратор")
+	}
+}
+
+// CanManageRoomPricing — правила цены комнаты, как CanManagePricing для её коворкинга
+func (a *Authorizer) CanManageRoomPricing(ctx context.Context, user *models.User, roomID int) error {
+	coworkingID, err := a.db.GetRoomCoworkingID(ctx, roomID)
+	if err != nil {
+		return err
+	}
+	return a.CanManagePricing(ctx, user, coworkingID)
+}
+
+// CanManagePromoCodes — создание и отключение промокода коворкинга coworkingID:
+// администратор или менеджер коворкинга; общие промокоды (nil) — только администратор
+func (a *Authorizer) CanManagePromoCodes(ctx context.Context, user *models.User, coworkingID *int) error {
+	switch {
+	case user.Role == RoleAdmin:
+		return nil
+	case user.Role == RoleManager && coworkingID != nil:
+		return a.requireManagerOf(ctx, user, *coworkingID, "manage_promo_codes")
+	case user.Role == RoleManager:
+		return deny(user, "manage_promo_codes", "общие промокоды создаёт только администратор")
+	default:
+		return deny(user, "manage_promo_codes", "промокодами управляет только менеджер или администратор")
+	}
+}
+
+// PromoCodeScope возвращает коворкинги, промокоды которых пользователь может просматривать:
+// nil — все (администратор), список — коворкинги менеджера
+func (a *Authorizer) PromoCodeScope(ctx context.Context, user *models.User) ([]int, error) {
+	switch user.Role {
+	case RoleAdmin:
+		return nil, nil
+	case RoleManager:
+		return a.db.GetManagedCoworkingIDs(ctx, user.UserID)
+	default:
+		return nil, deny(user, "manage_promo_codes", "промокоды доступны только менеджерам и администраторам")
+	}
+}
+
+// CanViewBooking — детали бронирования: владелец, администратор или менеджер коворкинга
+func (a *Authorizer) CanViewBooking(ctx context.Context, user *models.User, bookingID int) error {
+	return a.requireBookingParty(ctx, user, bookingID, "view_booking", "можно просматривать только свои бронирования")
+}
+
+// CanPayBooking — новый платёж по бронированию (депозит, оплата частями):
+// владелец, администратор или менеджер коворкинга
+func (a *Authorizer) CanPayBooking(ctx context.Context, user *models.User, bookingID int) error {
+	return a.requireBookingParty(ctx, user, bookingID, "pay_booking", "можно оплачивать только свои бронирования")
+}
+
+// CanViewInvoice — просмотр и отправка счёта или квитанции: владелец бронирования,
+// администратор или менеджер коворкинга
+func (a *Authorizer) CanViewInvoice(ctx context.Context, user *models.User, invoiceID int) error {
+	bookingID, err := a.db.GetInvoiceBookingID(ctx, invoiceID)
+	if err != nil {
+		return err
+	}
+	return a.requireBookingParty(ctx, user, bookingID, "view_invoice", "можно просматривать только счета своих бронирований")
+}
+
+// CanConfirmPayment — подтверждение оплаты: администратор или менеджер коворкинга платежа
+func (a *Authorizer) CanConfirmPayment(ctx context.Context, user *models.User, paymentID int) error {
+	switch user.Role {
+	case RoleAdmin:
+		return nil
+	case RoleManager:
+		coworkingID, err := a.db.GetPaymentCoworkingID(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		return a.requireManagerOf(ctx, user, coworkingID, "confirm_payment")
+	default:
+		return deny(user, "confirm_payment", "подтверждать оплату может только менеджер или администратор")
+	}
+}
+
+// CanCancelBooking — отменить бронирование может только его владелец
+func (a *Authorizer) CanCancelBooking(ctx context.Context, user *models.User, bookingID int) error {
+	return a.requireOwner(ctx, user, bookingID, "cancel_booking", "можно отменять только свои бронирования")
+}
+
+// CanRescheduleBooking — перенести или продлить бронирование может только его владелец
+func (a *Authorizer) CanRescheduleBooking(ctx context.Context, user *models.User, bookingID int) error {
+	return a.requireOwner(ctx, user, bookingID, "reschedule_booking", "можно переносить только свои бронирования")
+}
+
+// CanManageSeries — просмотр, изменение и отмена серии бронирований: только владелец
+func (a *Authorizer) CanManageSeries(ctx context.Context, user *models.User, seriesID int) error {
+	ownerID, err := a.db.GetSeriesOwner(ctx, seriesID)
+	if err != nil {
+		return err
+	}
+	if ownerID != user.UserID {
+		return deny(user, "manage_series", "можно управлять только своими сериями бронирований")
+	}
+	return nil
+}
+
+// CanViewUserBookings — история бронирований: свои или любого пользователя для администратора
+func (a *Authorizer) CanViewUserBookings(ctx context.Context, user *models.User, userID int) error {
+	if user.UserID != userID && user.Role != RoleAdmin {
+		return deny(user, "view_user_bookings", "можно просматривать только свои бронирования")
+	}
+	return nil
+}
+
+// ReportScope возвращает коворкинги, по которым пользователь может смотреть отчёты:
+// nil — все (администратор), список — коворкинги менеджера
+func (a *Authorizer) ReportScope(ctx context.Context, user *models.User) ([]int, error) {
+	switch user.Role {
+	case RoleAdmin:
+		return nil, nil
+	case RoleManager:
+		return a.db.GetManagedCoworkingIDs(ctx, user.UserID)
+	default:
+		return nil, deny(user, "view_reports", "отчёты доступны только менеджерам и администраторам")
+	}
+}
+
+// RelocationScope возвращает коворкинги, бронирования на перенос из которых пользователь
+// может просматривать: nil — все (администратор), список — коворкинги менеджера
+func (a *Authorizer) RelocationScope(ctx context.Context, user *models.User) ([]int, error) {
+	switch user.Role {
+	case RoleAdmin:
+		return nil, nil
+	case RoleManager:
+		return a.db.GetManagedCoworkingIDs(ctx, user.UserID)
+	default:
+		return nil, deny(user, "view_relocations", "бронирования на перенос доступны только менеджерам и администраторам")
+	}
+}
+
+func (a *Authorizer) requireManagerOf(ctx context.Context, user *models.User, coworkingID int, op string) error {
+	ids, err := a.db.GetManagedCoworkingIDs(ctx, user.UserID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == coworkingID {
+			return nil
+		}
+	}
+	return deny(user, op, fmt.Sprintf("менеджер не отвечает за коворкинг %d", coworkingID))
+}
+
+// requireBookingParty пропускает владельца бронирования, администратора и менеджера его коворкинга
+func (a *Authorizer) requireBookingParty(ctx context.Context, user *models.User, bookingID int, op, reason string) error {
+	ownerID, coworkingID, err := a.db.GetBookingOwnership(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	switch {
+	case ownerID == user.UserID, user.Role == RoleAdmin:
+		return nil
+	case user.Role == RoleManager:
+		return a.requireManagerOf(ctx, user, coworkingID, op)
+	default:
+		return deny(user, op, reason)
+	}
+}
+
+func (a *Authorizer) requireOwner(ctx context.Context, user *models.User, bookingID int, op, reason string) error {
+	ownerID, _, err := a.db.GetBookingOwnership(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	if ownerID != user.UserID {
+		return deny(user, op, reason)
+	}
+	return nil
+}
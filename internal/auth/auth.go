@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/database"
+	"coworking-booking/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Ошибки аутентификации
+var (
+	// ErrInvalidCredentials — неверный email или пароль
+	ErrInvalidCredentials = errors.New("неверный email или пароль")
+	// ErrUnauthenticated — токен отсутствует, истёк или отозван
+	ErrUnauthenticated = errors.New("требуется вход в систему")
+	// ErrInvalidInput — данные регистрации не прошли проверку
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+const (
+	// MinPasswordLength — минимальная длина пароля
+	MinPasswordLength = 8
+	// DefaultSessionTTL — время жизни сессии по умолчанию
+	DefaultSessionTTL = 24 * time.Hour
+
+	bcryptCost = 10
+	tokenBytes = 32
+)
+
+// Service реализует регистрацию, вход и выход пользователей
+type Service struct {
+	db  *database.DB
+	ttl time.Duration
+}
+
+// NewService создаёт сервис аутентификации с заданным временем жизни сессии
+func NewService(db *database.DB, ttl time.Duration) *Service {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &Service{db: db, ttl: ttl}
+}
+
+// Register создаёт пользователя с ролью user и bcrypt-хешем пароля
+func (s *Service) Register(ctx context.Context, email, password, fullName string) (*models.User, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	fullName = strings.TrimSpace(fullName)
+
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, fmt.Errorf("%w: некорректный email", ErrInvalidInput)
+	}
+	if fullName == "" {
+		return nil, fmt.Errorf("%w: имя обязательно", ErrInvalidInput)
+	}
+	if len(password) < MinPasswordLength {
+		return nil, fmt.Errorf("%w: пароль должен содержать не менее %d символов", ErrInvalidInput, MinPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+
+	return s.db.CreateUser(ctx, email, string(hash), fullName, "user")
+}
+
+// Login проверяет пароль и выдаёт новый токен сессии
+func (s *Service) Login(ctx context.Context, email, password string) (*models.LoginResponse, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	user, err := s.db.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			// Тратим время на сравнение, чтобы не раскрывать существование email
+			bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.db.CreateSession(ctx, user.UserID, hashToken(token), time.Now().Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	return &models.LoginResponse{Token: token, ExpiresAt: session.ExpiresAt, User: user}, nil
+}
+
+// Authenticate возвращает пользователя по действующему токену
+func (s *Service) Authenticate(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, ErrUnauthenticated
+	}
+	user, err := s.db.GetUserBySessionToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, ErrUnauthenticated
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// Logout отзывает сессию; повторный выход возвращает ErrUnauthenticated
+func (s *Service) Logout(ctx context.Context, token string) error {
+	if err := s.db.RevokeSession(ctx, hashToken(token)); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return ErrUnauthenticated
+		}
+		return err
+	}
+	return nil
+}
+
+// dummyHash используется при входе с несуществующим email
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcryptCost)
+
+func newToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
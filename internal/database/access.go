@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// SetUserRole меняет роль пользователя (user, manager, admin)
+func (db *DB) SetUserRole(ctx context.Context, userID int, role string) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE "user" SET role = $2 WHERE user_id = $1`
+	res, err := tx.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return fmt.Errorf("unknown role %q: %w", role, ErrConflict)
+		}
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("user with id %d %w", userID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// AssignCoworkingManager назначает менеджера коворкинга
+func (db *DB) AssignCoworkingManager(ctx context.Context, coworkingID, userID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO coworking_manager (coworking_id, user_id)
+		SELECT $1, u.user_id
+		FROM "user" u
+		WHERE u.user_id = $2 AND u.role = 'manager'
+		ON CONFLICT DO NOTHING
+	`
+	res, err := tx.ExecContext(ctx, query, coworkingID, userID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to assign manager: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM coworking_manager WHERE coworking_id = $1 AND user_id = $2)`,
+			coworkingID, userID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to assign manager: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("user %d is not a manager: %w", userID, ErrConflict)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetManagedCoworkingIDs возвращает коворкинги, за которые отвечает менеджер
+func (db *DB) GetManagedCoworkingIDs(ctx context.Context, userID int) ([]int, error) {
+	query := `
+		SELECT coworking_id
+		FROM coworking_manager
+		WHERE user_id = $1
+		ORDER BY coworking_id
+	`
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get managed coworkings: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan coworking id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read managed coworkings: %w", err)
+	}
+	return ids, nil
+}
+
+// GetBookingOwnership возвращает владельца бронирования и коворкинг, к которому оно относится
+func (db *DB) GetBookingOwnership(ctx context.Context, bookingID int) (userID, coworkingID int, err error) {
+	query := `
+		SELECT b.user_id, r.coworking_id
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		WHERE b.booking_id = $1
+	`
+	err = db.QueryRowContext(ctx, query, bookingID).Scan(&userID, &coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, 0, fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return 0, 0, fmt.Errorf("failed to get booking: %w", err)
+	}
+	return userID, coworkingID, nil
+}
+
+// GetRoomCoworkingID возвращает коворкинг, в котором находится комната
+func (db *DB) GetRoomCoworkingID(ctx context.Context, roomID int) (int, error) {
+	var coworkingID int
+	err := db.QueryRowContext(ctx, `SELECT coworking_id FROM room WHERE room_id = $1`, roomID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get room: %w", err)
+	}
+	return coworkingID, nil
+}
+
+// GetPaymentCoworkingID возвращает коворкинг, к которому относится платёж
+func (db *DB) GetPaymentCoworkingID(ctx context.Context, paymentID int) (int, error) {
+	query := `
+		SELECT r.coworking_id
+		FROM payment p
+		JOIN booking b ON p.booking_id = b.booking_id
+		JOIN room r ON b.room_id = r.room_id
+		WHERE p.payment_id = $1
+	`
+	var coworkingID int
+	err := db.QueryRowContext(ctx, query, paymentID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("payment with id %d %w", paymentID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return coworkingID, nil
+}
@@ -0,0 +1,267 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"coworking-booking/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Архивирование закрывает коворкинг или комнату, не удаляя строк: история бронирований,
+// платежи, счета и отчёты продолжают ссылаться на них. Архивная комната скрыта из поиска,
+// а триггер booking_check_archived не пускает на неё новые бронирования и переносы.
+// Предстоящие бронирования отмечаются needs_relocation — их переносит или отменяет менеджер
+
+// ArchiveCoworking архивирует коворкинг со всеми его комнатами
+func (db *DB) ArchiveCoworking(ctx context.Context, coworkingID int) (*models.ArchiveResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var c models.Coworking
+	err = scanCoworking(tx.QueryRowContext(ctx, `
+		SELECT `+coworkingColumns+` FROM coworking WHERE coworking_id = $1 FOR UPDATE
+	`, coworkingID), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock coworking: %w", err)
+	}
+	if c.ArchivedAt != nil {
+		return nil, fmt.Errorf("coworking %d is already archived: %w", coworkingID, ErrConflict)
+	}
+
+	err = scanCoworking(tx.QueryRowContext(ctx, `
+		UPDATE coworking SET archived_at = NOW() WHERE coworking_id = $1
+		RETURNING `+coworkingColumns+`
+	`, coworkingID), &c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive coworking: %w", err)
+	}
+
+	bookings, err := flagRelocation(ctx, tx, `r.coworking_id = $1`, coworkingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &models.ArchiveResult{Coworking: &c, RelocationBookings: bookings}, nil
+}
+
+// RestoreCoworking возвращает архивный коворкинг в работу. Комнаты, архивированные
+// по отдельности, остаются в архиве
+func (db *DB) RestoreCoworking(ctx context.Context, coworkingID int) (*models.Coworking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var c models.Coworking
+	err = scanCoworking(tx.QueryRowContext(ctx, `
+		UPDATE coworking SET archived_at = NULL
+		WHERE coworking_id = $1 AND archived_at IS NOT NULL
+		RETURNING `+coworkingColumns+`
+	`, coworkingID), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := coworkingArchivedAt(ctx, tx, coworkingID); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("coworking %d is not archived: %w", coworkingID, ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to restore coworking: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE booking b
+		SET needs_relocation = FALSE
+		FROM room r
+		WHERE b.room_id = r.room_id
+		  AND r.coworking_id = $1
+		  AND r.archived_at IS NULL
+		  AND b.needs_relocation
+	`, coworkingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear relocation flags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &c, nil
+}
+
+// ArchiveRoom архивирует комнату; её оборудование, расписание и тарифы сохраняются до восстановления
+func (db *DB) ArchiveRoom(ctx context.Context, roomID int) (*models.ArchiveResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var r models.Room
+	err = tx.QueryRowContext(ctx, `
+		UPDATE room SET archived_at = NOW()
+		WHERE room_id = $1 AND archived_at IS NULL
+		RETURNING room_id, coworking_id, name, capacity, area_sqm, hourly_rate, created_at,
+		          setup_minutes, teardown_minutes, archived_at
+	`, roomID).Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+		&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if _, err := roomTimeZone(ctx, tx, roomID); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("room %d is already archived: %w", roomID, ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to archive room: %w", err)
+	}
+
+	bookings, err := flagRelocation(ctx, tx, `r.room_id = $1`, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &models.ArchiveResult{Room: &r, RelocationBookings: bookings}, nil
+}
+
+// RestoreRoom возвращает архивную комнату в работу; комнату архивного коворкинга
+// можно восстановить только после него
+func (db *DB) RestoreRoom(ctx context.Context, roomID int) (*models.Room, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var coworkingID int
+	var roomArchived bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT coworking_id, archived_at IS NOT NULL FROM room WHERE room_id = $1 FOR UPDATE
+	`, roomID).Scan(&coworkingID, &roomArchived)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock room: %w", err)
+	}
+	if !roomArchived {
+		return nil, fmt.Errorf("room %d is not archived: %w", roomID, ErrConflict)
+	}
+	coworkingArchived, err := coworkingArchivedAt(ctx, tx, coworkingID)
+	if err != nil {
+		return nil, err
+	}
+	if coworkingArchived.Valid {
+		return nil, fmt.Errorf("coworking %d of room %d is archived; restore it first: %w", coworkingID, roomID, ErrConflict)
+	}
+
+	var r models.Room
+	err = tx.QueryRowContext(ctx, `
+		UPDATE room SET archived_at = NULL
+		WHERE room_id = $1
+		RETURNING room_id, coworking_id, name, capacity, area_sqm, hourly_rate, created_at,
+		          setup_minutes, teardown_minutes, archived_at
+	`, roomID).Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+		&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore room: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE booking SET needs_relocation = FALSE WHERE room_id = $1 AND needs_relocation
+	`, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear relocation flags: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &r, nil
+}
+
+// GetBookingsNeedingRelocation возвращает предстоящие бронирования архивных комнат и коворкингов.
+// coworkingIDs ограничивает список этими коворкингами; nil — все
+func (db *DB) GetBookingsNeedingRelocation(ctx context.Context, coworkingIDs []int) ([]models.Booking, error) {
+	return relocationBookings(ctx, db, `($1::int[] IS NULL OR r.coworking_id = ANY($1))`, pq.Array(coworkingIDs))
+}
+
+// flagRelocation отмечает needs_relocation незавершённые активные бронирования комнат,
+// отобранных условием where по room r (параметр $1), и возвращает их
+func flagRelocation(ctx context.Context, tx *sql.Tx, where string, id int) ([]models.Booking, error) {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE booking b
+		SET needs_relocation = TRUE
+		FROM room r
+		WHERE b.room_id = r.room_id
+		  AND `+where+`
+		  AND b.status IN ('pending', 'confirmed')
+		  AND b.ends_at > NOW()
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to flag bookings for relocation: %w", err)
+	}
+	return relocationBookings(ctx, tx, where, id)
+}
+
+func relocationBookings(ctx context.Context, q querier, where string, arg any) ([]models.Booking, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT b.booking_id, b.room_id, b.user_id, b.starts_at, b.ends_at, b.total_amount, b.status,
+		       b.created_at, b.updated_at, b.series_id, b.needs_relocation,
+		       r.name, c.name, c.address, c.time_zone, u.full_name, u.email
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		JOIN "user" u ON b.user_id = u.user_id
+		WHERE `+where+`
+		  AND b.needs_relocation
+		  AND b.status IN ('pending', 'confirmed')
+		  AND b.ends_at > NOW()
+		ORDER BY b.starts_at, b.room_id
+	`, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookings for relocation: %w", err)
+	}
+	defer rows.Close()
+
+	bookings := []models.Booking{}
+	for rows.Next() {
+		var b models.Booking
+		if err := rows.Scan(&b.BookingID, &b.RoomID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.TotalAmount, &b.Status,
+			&b.CreatedAt, &b.UpdatedAt, &b.SeriesID, &b.NeedsRelocation,
+			&b.RoomName, &b.CoworkingName, &b.CoworkingAddress, &b.TimeZone, &b.UserName, &b.UserEmail); err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		localizeBooking(&b, b.TimeZone)
+		bookings = append(bookings, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read bookings for relocation: %w", err)
+	}
+	return bookings, nil
+}
+
+func coworkingArchivedAt(ctx context.Context, q queryRower, coworkingID int) (sql.NullTime, error) {
+	var archivedAt sql.NullTime
+	err := q.QueryRowContext(ctx, `SELECT archived_at FROM coworking WHERE coworking_id = $1`, coworkingID).Scan(&archivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return archivedAt, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return archivedAt, fmt.Errorf("failed to get coworking: %w", err)
+	}
+	return archivedAt, nil
+}
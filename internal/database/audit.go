@@ -0,0 +1,138 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"coworking-booking/internal/models"
+)
+
+// anonymousActor — исполнитель изменений, когда в контексте нет ни пользователя, ни системного
+// процесса (регистрация, вход)
+const anonymousActor = "anonymous"
+
+// auditLimit ограничивает выборку журнала, если AuditFilter.Limit не задан
+const auditLimit = 200
+
+type actorKey struct{}
+
+// Actor — исполнитель изменений, записываемый в audit_log
+type Actor struct {
+	UserID int    // пользователь; 0 — системный процесс
+	Name   string // email пользователя или имя процесса
+}
+
+// UserActor — изменения от имени пользователя
+func UserActor(user *models.User) Actor {
+	return Actor{UserID: user.UserID, Name: user.Email}
+}
+
+// SystemActor — изменения от имени системного процесса (scheduler, payment_gateway:<шлюз>)
+func SystemActor(name string) Actor {
+	return Actor{Name: name}
+}
+
+// WithActor возвращает контекст, изменения в транзакциях которого записываются в audit_log
+// от имени actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// setActorTx передаёт исполнителя из ctx триггеру audit_row_change до конца транзакции
+func setActorTx(ctx context.Context, tx *sql.Tx) error {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	if !ok || actor.Name == "" {
+		actor.Name = anonymousActor
+	}
+	userID := ""
+	if actor.UserID != 0 {
+		userID = strconv.Itoa(actor.UserID)
+	}
+	_, err := tx.ExecContext(ctx, `
+		SELECT set_config('app.actor_user_id', $1, true), set_config('app.actor', $2, true)
+	`, userID, actor.Name)
+	if err != nil {
+		return fmt.Errorf("failed to set audit actor: %w", err)
+	}
+	return nil
+}
+
+// GetAuditLog возвращает записи журнала изменений по фильтру в порядке их появления.
+// С BookingID — история бронирования вместе с его платежами, возвратами и документами,
+// с PaymentID — история платежа вместе с возвратами и документами по нему.
+// При более чем Limit записях возвращаются последние
+func (db *DB) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = auditLimit
+	}
+
+	query := `
+		SELECT audit_id, occurred_at, tx_id, actor_user_id, actor, action, entity, entity_id, booking_id,
+		       old_values, new_values
+		FROM (
+			SELECT *
+			FROM audit_log
+			WHERE ($1 = '' OR entity = $1)
+			  AND ($2 = 0 OR entity_id = $2)
+			  AND ($3 = 0 OR booking_id = $3)
+			  AND ($4 = 0
+			       OR (entity = 'payment' AND entity_id = $4)
+			       OR (entity IN ('refund', 'invoice')
+			           AND (COALESCE(new_values, old_values) ->> 'payment_id')::INTEGER = $4))
+			  AND ($5 = 0 OR actor_user_id = $5)
+			  AND ($6::TIMESTAMPTZ IS NULL OR occurred_at >= $6)
+			  AND ($7::TIMESTAMPTZ IS NULL OR occurred_at < $7)
+			ORDER BY audit_id DESC
+			LIMIT $8
+		) a
+		ORDER BY audit_id
+	`
+	rows, err := db.QueryContext(ctx, query, filter.Entity, filter.EntityID, filter.BookingID, filter.PaymentID,
+		filter.ActorUserID, filter.From, filter.To, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var oldValues, newValues []byte
+		err := rows.Scan(&e.AuditID, &e.OccurredAt, &e.TxID, &e.ActorUserID, &e.Actor, &e.Action, &e.Entity,
+			&e.EntityID, &e.BookingID, &oldValues, &newValues)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if e.OldValues, err = decodeAuditValues(oldValues); err != nil {
+			return nil, err
+		}
+		if e.NewValues, err = decodeAuditValues(newValues); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// decodeAuditValues разбирает JSONB со значениями колонок; числа остаются в записи БД
+// (json.Number), чтобы суммы не теряли копейки
+func decodeAuditValues(data []byte) (map[string]any, error) {
+	if data == nil {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var values map[string]any
+	if err := dec.Decode(&values); err != nil {
+		return nil, fmt.Errorf("failed to decode audit values: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/schedule"
+
+	"github.com/lib/pq"
+)
+
+// blackoutColumns — колонки блокировки и часовой пояс её коворкинга
+const blackoutColumns = `
+	bl.blackout_id, bl.room_id, bl.coworking_id, bl.starts_at, bl.ends_at,
+	bl.reason, bl.created_by, bl.created_at, c.time_zone
+	FROM blackout bl
+	LEFT JOIN room r ON bl.room_id = r.room_id
+	JOIN coworking c ON c.coworking_id = COALESCE(bl.coworking_id, r.coworking_id)
+`
+
+// CreateBlackout создаёт блокировку комнаты (roomID) или всех комнат коворкинга (coworkingID);
+// задан ровно один из них. Возвращает активные бронирования, пересекающиеся с блокировкой:
+// они не отменяются автоматически, их разбирает менеджер.
+// Строки затронутых комнат берутся FOR UPDATE: параллельное бронирование (триггер
+// booking_check_blackout берёт их FOR SHARE) либо попадёт в список пересечений,
+// либо будет отклонено
+func (db *DB) CreateBlackout(ctx context.Context, userID int, roomID, coworkingID *int, req models.CreateBlackoutRequest) (*models.BlackoutResult, error) {
+	if (roomID == nil) == (coworkingID == nil) {
+		return nil, fmt.Errorf("exactly one of room and coworking must be set: %w", ErrConflict)
+	}
+	if !req.StartsAt.Before(req.EndsAt) {
+		return nil, fmt.Errorf("blackout must end after it starts: %w", ErrConflict)
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("blackout reason is required: %w", ErrConflict)
+	}
+
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var zone string
+	err = tx.QueryRowContext(ctx, `
+		SELECT c.time_zone
+		FROM coworking c
+		WHERE c.coworking_id = COALESCE($2, (SELECT coworking_id FROM room WHERE room_id = $1))
+	`, roomID, coworkingID).Scan(&zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			if roomID != nil {
+				return nil, fmt.Errorf("room with id %d %w", *roomID, ErrNotFound)
+			}
+			return nil, fmt.Errorf("coworking with id %d %w", *coworkingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get blackout time zone: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		SELECT 1 FROM room WHERE room_id = $1 OR coworking_id = $2 ORDER BY room_id FOR UPDATE
+	`, roomID, coworkingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock rooms: %w", err)
+	}
+
+	var b models.Blackout
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO blackout (room_id, coworking_id, starts_at, ends_at, reason, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING blackout_id, room_id, coworking_id, starts_at, ends_at, reason, created_by, created_at
+	`, roomID, coworkingID, req.StartsAt, req.EndsAt, strings.TrimSpace(req.Reason), userID).Scan(
+		&b.BlackoutID, &b.RoomID, &b.CoworkingID, &b.StartsAt, &b.EndsAt, &b.Reason, &b.CreatedBy, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blackout: %w", err)
+	}
+	localizeBlackout(&b, zone)
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT b.booking_id, b.room_id, b.user_id, b.starts_at, b.ends_at, b.total_amount, b.status,
+		       b.created_at, b.updated_at, b.series_id, r.name, u.full_name, u.email
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN "user" u ON b.user_id = u.user_id
+		WHERE (b.room_id = $1 OR r.coworking_id = $2)
+		  AND b.status IN ('pending', 'confirmed')
+		  AND tstzrange(b.starts_at, b.ends_at) && tstzrange($3, $4)
+		ORDER BY b.starts_at, b.room_id
+	`, roomID, coworkingID, req.StartsAt, req.EndsAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conflicting bookings: %w", err)
+	}
+	defer rows.Close()
+
+	result := &models.BlackoutResult{Blackout: &b, ConflictingBookings: []models.Booking{}}
+	for rows.Next() {
+		var bk models.Booking
+		if err := rows.Scan(&bk.BookingID, &bk.RoomID, &bk.UserID, &bk.StartsAt, &bk.EndsAt, &bk.TotalAmount,
+			&bk.Status, &bk.CreatedAt, &bk.UpdatedAt, &bk.SeriesID, &bk.RoomName, &bk.UserName, &bk.UserEmail); err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		localizeBooking(&bk, zone)
+		result.ConflictingBookings = append(result.ConflictingBookings, bk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conflicting bookings: %w", err)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// GetBlackouts возвращает блокировки коворкинга и его комнат, не закончившиеся к моменту from
+func (db *DB) GetBlackouts(ctx context.Context, coworkingID int, from time.Time) ([]models.Blackout, error) {
+	query := `SELECT ` + blackoutColumns + `
+		WHERE c.coworking_id = $1 AND bl.ends_at > $2
+		ORDER BY bl.starts_at, bl.blackout_id
+	`
+	rows, err := db.QueryContext(ctx, query, coworkingID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	var blackouts []models.Blackout
+	for rows.Next() {
+		var b models.Blackout
+		var zone string
+		if err := rows.Scan(&b.BlackoutID, &b.RoomID, &b.CoworkingID, &b.StartsAt, &b.EndsAt,
+			&b.Reason, &b.CreatedBy, &b.CreatedAt, &zone); err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		localizeBlackout(&b, zone)
+		blackouts = append(blackouts, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blackouts: %w", err)
+	}
+	return blackouts, nil
+}
+
+// DeleteBlackout удаляет блокировку
+func (db *DB) DeleteBlackout(ctx context.Context, blackoutID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM blackout WHERE blackout_id = $1`, blackoutID)
+	if err != nil {
+		return fmt.Errorf("failed to delete blackout: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete blackout: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("blackout with id %d %w", blackoutID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetBlackoutCoworkingID возвращает коворкинг блокировки (для блокировки комнаты — коворкинг комнаты)
+func (db *DB) GetBlackoutCoworkingID(ctx context.Context, blackoutID int) (int, error) {
+	var coworkingID int
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(bl.coworking_id, r.coworking_id)
+		FROM blackout bl
+		LEFT JOIN room r ON bl.room_id = r.room_id
+		WHERE bl.blackout_id = $1
+	`, blackoutID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("blackout with id %d %w", blackoutID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get blackout: %w", err)
+	}
+	return coworkingID, nil
+}
+
+// loadBlackouts возвращает интервалы блокировок комнат roomIDs, пересекающиеся с [from, to),
+// склеенные по каждой комнате: блокировка комнаты и коворкинга не считаются дважды
+func loadBlackouts(ctx context.Context, q querier, roomIDs []int, from, to time.Time) (map[int][]schedule.Interval, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.room_id, bl.starts_at, bl.ends_at
+		FROM room r
+		JOIN blackout bl ON bl.room_id = r.room_id OR bl.coworking_id = r.coworking_id
+		WHERE r.room_id = ANY($1)
+		  AND tstzrange(bl.starts_at, bl.ends_at) && tstzrange($2, $3)
+	`, pq.Array(roomIDs), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	blackouts := make(map[int][]schedule.Interval)
+	for rows.Next() {
+		var roomID int
+		var iv schedule.Interval
+		if err := rows.Scan(&roomID, &iv.Start, &iv.End); err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts[roomID] = append(blackouts[roomID], iv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blackouts: %w", err)
+	}
+	for roomID, ivs := range blackouts {
+		blackouts[roomID] = schedule.Merge(ivs)
+	}
+	return blackouts, nil
+}
+
+// localizeBlackout переводит время блокировки в часовой пояс её коворкинга
+func localizeBlackout(b *models.Blackout, zone string) {
+	b.TimeZone = zone
+	b.StartsAt = localtime.In(b.StartsAt, zone)
+	b.EndsAt = localtime.In(b.EndsAt, zone)
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/pricing"
+
+	"github.com/lib/pq"
+)
+
+// GetCoworkingCancellationPolicy возвращает ступени политики отмены коворкинга
+func (db *DB) GetCoworkingCancellationPolicy(ctx context.Context, coworkingID int) ([]models.CancellationTier, error) {
+	return getCancellationTiers(ctx, db, "coworking_id", coworkingID)
+}
+
+// GetRoomCancellationPolicy возвращает собственные ступени политики отмены комнаты
+func (db *DB) GetRoomCancellationPolicy(ctx context.Context, roomID int) ([]models.CancellationTier, error) {
+	return getCancellationTiers(ctx, db, "room_id", roomID)
+}
+
+// SetCoworkingCancellationPolicy заменяет политику отмены коворкинга; пустой список — полный возврат
+func (db *DB) SetCoworkingCancellationPolicy(ctx context.Context, coworkingID int, tiers []models.CancellationTier) error {
+	return db.setCancellationPolicy(ctx, "coworking_id", coworkingID, tiers)
+}
+
+// SetRoomCancellationPolicy заменяет политику отмены комнаты; пустой список возвращает комнату
+// к политике коворкинга
+func (db *DB) SetRoomCancellationPolicy(ctx context.Context, roomID int, tiers []models.CancellationTier) error {
+	return db.setCancellationPolicy(ctx, "room_id", roomID, tiers)
+}
+
+func (db *DB) setCancellationPolicy(ctx context.Context, ownerColumn string, ownerID int, tiers []models.CancellationTier) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM cancellation_tier WHERE `+ownerColumn+` = $1`, ownerID); err != nil {
+		return fmt.Errorf("failed to clear cancellation policy: %w", err)
+	}
+	for _, t := range tiers {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO cancellation_tier (`+ownerColumn+`, min_hours_before, refund_percent)
+			VALUES ($1, $2, $3)
+		`, ownerID, t.MinHoursBefore, t.RefundPercent)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				switch pqErr.Code {
+				case "23503": // foreign_key_violation
+					return fmt.Errorf("owner with id %d %w", ownerID, ErrNotFound)
+				case "23505", "23514": // unique_violation, check_violation
+					return fmt.Errorf("invalid cancellation tier (%s): %w", pqErr.Constraint, ErrConflict)
+				}
+			}
+			return fmt.Errorf("failed to set cancellation policy: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// QuoteCancellation рассчитывает возврат, который пользователь получит, отменив бронирование сейчас
+func (db *DB) QuoteCancellation(ctx context.Context, bookingID int) (*models.CancellationQuote, error) {
+	return cancellationQuote(ctx, db, bookingID, time.Now())
+}
+
+// cancellationQuote рассчитывает возврат при отмене бронирования в момент now.
+// Оплаченная сумма — баланс бронирования: все оплаченные платежи за вычетом уже сделанных возвратов
+func cancellationQuote(ctx context.Context, q querier, bookingID int, now time.Time) (*models.CancellationQuote, error) {
+	quote := models.CancellationQuote{BookingID: bookingID, PaidAmount: money.Zero(), RefundAmount: money.Zero()}
+	var roomID int
+	var zone string
+	err := q.QueryRowContext(ctx, `
+		SELECT b.starts_at, b.room_id, c.time_zone
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE b.booking_id = $1
+	`, bookingID).Scan(&quote.StartsAt, &roomID, &zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	quote.StartsAt = localtime.In(quote.StartsAt, zone)
+
+	if quote.Policy, err = loadCancellationPolicy(ctx, q, roomID); err != nil {
+		return nil, err
+	}
+	tiers := make([]pricing.RefundTier, len(quote.Policy))
+	for i, t := range quote.Policy {
+		tiers[i] = pricing.RefundTier{Notice: time.Duration(t.MinHoursBefore) * time.Hour, Percent: int64(t.RefundPercent)}
+	}
+	notice := quote.StartsAt.Sub(now)
+	quote.HoursBefore = notice.Hours()
+	quote.RefundPercent = int(pricing.RefundPercent(tiers, notice))
+
+	balance, err := bookingBalance(ctx, q, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Balance.Sign() > 0 {
+		quote.PaidAmount = balance.Balance
+	}
+	quote.RefundAmount = quote.PaidAmount.Percent(int64(quote.RefundPercent))
+	return &quote, nil
+}
+
+// loadCancellationPolicy возвращает действующую политику отмены комнаты: собственные ступени
+// или, если их нет, ступени коворкинга
+func loadCancellationPolicy(ctx context.Context, q querier, roomID int) ([]models.CancellationTier, error) {
+	tiers, err := getCancellationTiers(ctx, q, "room_id", roomID)
+	if err != nil || len(tiers) > 0 {
+		return tiers, err
+	}
+	var coworkingID int
+	if err := q.QueryRowContext(ctx, `SELECT coworking_id FROM room WHERE room_id = $1`, roomID).Scan(&coworkingID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get room: %w", err)
+	}
+	return getCancellationTiers(ctx, q, "coworking_id", coworkingID)
+}
+
+func getCancellationTiers(ctx context.Context, q querier, ownerColumn string, ownerID int) ([]models.CancellationTier, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT min_hours_before, refund_percent
+		FROM cancellation_tier
+		WHERE `+ownerColumn+` = $1
+		ORDER BY min_hours_before DESC
+	`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cancellation policy: %w", err)
+	}
+	defer rows.Close()
+
+	tiers := []models.CancellationTier{}
+	for rows.Next() {
+		var t models.CancellationTier
+		if err := rows.Scan(&t.MinHoursBefore, &t.RefundPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan cancellation tier: %w", err)
+		}
+		tiers = append(tiers, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cancellation policy: %w", err)
+	}
+	return tiers, nil
+}
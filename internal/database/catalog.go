@@ -0,0 +1,405 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"coworking-booking/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// coworkingColumns — колонки коворкинга в порядке scanCoworking
+const coworkingColumns = `coworking_id, name, address, city, description, time_zone, legal_name, tax_id, vat_percent, created_at, archived_at`
+
+func scanCoworking(row interface{ Scan(...any) error }, c *models.Coworking) error {
+	return row.Scan(&c.CoworkingID, &c.Name, &c.Address, &c.City, &c.Description, &c.TimeZone,
+		&c.LegalName, &c.TaxID, &c.VATPercent, &c.CreatedAt, &c.ArchivedAt)
+}
+
+// GetCoworkingByID возвращает коворкинг по идентификатору
+func (db *DB) GetCoworkingByID(ctx context.Context, coworkingID int) (*models.Coworking, error) {
+	var c models.Coworking
+	err := scanCoworking(db.QueryRowContext(ctx, `
+		SELECT `+coworkingColumns+`
+		FROM coworking
+		WHERE coworking_id = $1
+	`, coworkingID), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get coworking: %w", err)
+	}
+	return &c, nil
+}
+
+// UpdateCoworking изменяет название, адрес, описание и часовой пояс коворкинга.
+// Часовой пояс нельзя сменить, пока есть предстоящие бронирования: их местное время
+// и часы работы, по которым они проверены, сдвинулись бы
+func (db *DB) UpdateCoworking(ctx context.Context, coworkingID int, req models.UpdateCoworkingRequest) (*models.Coworking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var zone string
+	err = tx.QueryRowContext(ctx, `SELECT time_zone FROM coworking WHERE coworking_id = $1 FOR UPDATE`, coworkingID).Scan(&zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock coworking: %w", err)
+	}
+	if req.TimeZone != nil && *req.TimeZone != zone {
+		upcoming, err := upcomingBookings(ctx, tx, `r.coworking_id = $1`, coworkingID)
+		if err != nil {
+			return nil, err
+		}
+		if upcoming.count > 0 {
+			return nil, fmt.Errorf("coworking %d has %s; time zone cannot be changed: %w", coworkingID, upcoming, ErrConflict)
+		}
+	}
+
+	var c models.Coworking
+	err = scanCoworking(tx.QueryRowContext(ctx, `
+		UPDATE coworking
+		SET name        = COALESCE($2, name),
+		    address     = COALESCE($3, address),
+		    description = CASE WHEN $4::text IS NULL THEN description ELSE NULLIF($4, '') END,
+		    time_zone   = COALESCE($5, time_zone)
+		WHERE coworking_id = $1
+		RETURNING `+coworkingColumns+`
+	`, coworkingID, req.Name, req.Address, req.Description, req.TimeZone), &c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update coworking: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &c, nil
+}
+
+// DeleteCoworking удаляет коворкинг вместе с его комнатами, расписанием, тарифами и промокодами.
+// Удаление невозможно, пока в коворкинге есть предстоящие бронирования, а также если
+// по нему есть история бронирований или выданные счета (booking и invoice ON DELETE RESTRICT)
+func (db *DB) DeleteCoworking(ctx context.Context, coworkingID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `SELECT coworking_id FROM coworking WHERE coworking_id = $1 FOR UPDATE`, coworkingID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to lock coworking: %w", err)
+	}
+	upcoming, err := upcomingBookings(ctx, tx, `r.coworking_id = $1`, coworkingID)
+	if err != nil {
+		return err
+	}
+	if upcoming.count > 0 {
+		return fmt.Errorf("coworking %d has %s; cancel or move them before deleting: %w", coworkingID, upcoming, ErrConflict)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM coworking WHERE coworking_id = $1`, coworkingID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("coworking %d has past bookings or issued invoices and cannot be deleted; archive it instead: %w", coworkingID, ErrConflict)
+		}
+		return fmt.Errorf("failed to delete coworking: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetRoomByID возвращает комнату с коворкингом и списком оборудования
+func (db *DB) GetRoomByID(ctx context.Context, roomID int) (*models.Room, error) {
+	var r models.Room
+	var equipmentList pq.StringArray
+	var equipmentIDs pq.Int64Array
+	err := db.QueryRowContext(ctx, `
+		SELECT r.room_id, r.coworking_id, r.name, r.capacity, r.area_sqm, r.hourly_rate, r.created_at,
+		       r.setup_minutes, r.teardown_minutes, r.archived_at, c.name, c.address, c.time_zone,
+		       COALESCE(ARRAY_AGG(e.name ORDER BY e.name) FILTER (WHERE e.name IS NOT NULL), ARRAY[]::VARCHAR[]),
+		       COALESCE(ARRAY_AGG(e.equipment_id ORDER BY e.name) FILTER (WHERE e.name IS NOT NULL), ARRAY[]::INT[])
+		FROM room r
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		LEFT JOIN room_equipment re ON r.room_id = re.room_id
+		LEFT JOIN equipment e ON re.equipment_id = e.equipment_id
+		WHERE r.room_id = $1
+		GROUP BY r.room_id, c.coworking_id
+	`, roomID).Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+		&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt, &r.CoworkingName, &r.CoworkingAddress, &r.TimeZone,
+		&equipmentList, &equipmentIDs)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get room: %w", err)
+	}
+	r.EquipmentList = equipmentList
+	r.EquipmentIDs = ints(equipmentIDs)
+	return &r, nil
+}
+
+// UpdateRoom изменяет название, вместимость, площадь и ставку комнаты.
+// Новая ставка действует на бронирования, созданные или перенесённые после изменения
+func (db *DB) UpdateRoom(ctx context.Context, roomID int, req models.UpdateRoomRequest) (*models.Room, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var r models.Room
+	err = tx.QueryRowContext(ctx, `
+		UPDATE room
+		SET name        = COALESCE($2, name),
+		    capacity    = COALESCE($3, capacity),
+		    area_sqm    = COALESCE($4, area_sqm),
+		    hourly_rate = COALESCE($5, hourly_rate)
+		WHERE room_id = $1
+		RETURNING room_id, coworking_id, name, capacity, area_sqm, hourly_rate, created_at,
+		          setup_minutes, teardown_minutes, archived_at
+	`, roomID, req.Name, req.Capacity, req.AreaSqm, req.HourlyRate).Scan(
+		&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+		&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return nil, fmt.Errorf("capacity and area must be positive, hourly rate non-negative: %w", ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to update room: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &r, nil
+}
+
+// DeleteRoom удаляет комнату вместе с её оборудованием, расписанием и листом ожидания.
+// Удаление невозможно, пока у комнаты есть предстоящие бронирования, а также если
+// по ней есть история бронирований или серий (booking и booking_series ON DELETE RESTRICT)
+func (db *DB) DeleteRoom(ctx context.Context, roomID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRowContext(ctx, `SELECT room_id FROM room WHERE room_id = $1 FOR UPDATE`, roomID).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to lock room: %w", err)
+	}
+	upcoming, err := upcomingBookings(ctx, tx, `r.room_id = $1`, roomID)
+	if err != nil {
+		return err
+	}
+	if upcoming.count > 0 {
+		return fmt.Errorf("room %d has %s; cancel or move them before deleting: %w", roomID, upcoming, ErrConflict)
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM room WHERE room_id = $1`, roomID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("room %d has past bookings or booking series and cannot be deleted; archive it instead: %w", roomID, ErrConflict)
+		}
+		return fmt.Errorf("failed to delete room: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// upcomingSummary — активные бронирования, которые ещё не закончились
+type upcomingSummary struct {
+	count int
+	first time.Time
+}
+
+func (u upcomingSummary) String() string {
+	return fmt.Sprintf("%d upcoming booking(s), the first at %s", u.count, u.first.UTC().Format(time.RFC3339))
+}
+
+// upcomingBookings считает незавершённые бронирования pending/confirmed комнат, отобранных
+// условием where по room r (параметр $1)
+func upcomingBookings(ctx context.Context, q queryRower, where string, id int) (upcomingSummary, error) {
+	var u upcomingSummary
+	var first sql.NullTime
+	err := q.QueryRowContext(ctx, `
+		SELECT COUNT(*), MIN(b.starts_at)
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		WHERE `+where+`
+		  AND b.status IN ('pending', 'confirmed')
+		  AND b.ends_at > NOW()
+	`, id).Scan(&u.count, &first)
+	if err != nil {
+		return u, fmt.Errorf("failed to count upcoming bookings: %w", err)
+	}
+	u.first = first.Time
+	return u, nil
+}
+
+// GetAllEquipment возвращает справочник оборудования
+func (db *DB) GetAllEquipment(ctx context.Context) ([]models.Equipment, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT equipment_id, name, description
+		FROM equipment
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipment: %w", err)
+	}
+	defer rows.Close()
+
+	var equipment []models.Equipment
+	for rows.Next() {
+		var e models.Equipment
+		if err := rows.Scan(&e.EquipmentID, &e.Name, &e.Description); err != nil {
+			return nil, fmt.Errorf("failed to scan equipment: %w", err)
+		}
+		equipment = append(equipment, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read equipment: %w", err)
+	}
+	return equipment, nil
+}
+
+// GetEquipmentByID возвращает тип оборудования по идентификатору
+func (db *DB) GetEquipmentByID(ctx context.Context, equipmentID int) (*models.Equipment, error) {
+	var e models.Equipment
+	err := db.QueryRowContext(ctx, `
+		SELECT equipment_id, name, description FROM equipment WHERE equipment_id = $1
+	`, equipmentID).Scan(&e.EquipmentID, &e.Name, &e.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("equipment with id %d %w", equipmentID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get equipment: %w", err)
+	}
+	return &e, nil
+}
+
+// UpdateEquipment переименовывает тип оборудования и заменяет описание
+func (db *DB) UpdateEquipment(ctx context.Context, equipmentID int, req models.EquipmentRequest) (*models.Equipment, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var e models.Equipment
+	err = tx.QueryRowContext(ctx, `
+		UPDATE equipment
+		SET name = $2, description = NULLIF($3, '')
+		WHERE equipment_id = $1
+		RETURNING equipment_id, name, description
+	`, equipmentID, req.Name, req.Description).Scan(&e.EquipmentID, &e.Name, &e.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("equipment with id %d %w", equipmentID, ErrNotFound)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("equipment %q already exists: %w", req.Name, ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to update equipment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &e, nil
+}
+
+// DeleteEquipment удаляет тип оборудования и снимает его со всех комнат. Удаление невозможно,
+// пока оборудование требуется в активных заявках листа ожидания: они перестали бы совпадать
+// с любой комнатой
+func (db *DB) DeleteEquipment(ctx context.Context, equipmentID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var waiting int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM waitlist_entry
+		WHERE $1 = ANY(equipment_ids) AND status IN ('waiting', 'offered')
+	`, equipmentID).Scan(&waiting)
+	if err != nil {
+		return fmt.Errorf("failed to check waitlist: %w", err)
+	}
+	if waiting > 0 {
+		return fmt.Errorf("equipment %d is required by %d active waitlist entries: %w", equipmentID, waiting, ErrConflict)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM equipment WHERE equipment_id = $1`, equipmentID)
+	if err != nil {
+		return fmt.Errorf("failed to delete equipment: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete equipment: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("equipment with id %d %w", equipmentID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RemoveEquipmentFromRoom снимает оборудование с комнаты
+func (db *DB) RemoveEquipmentFromRoom(ctx context.Context, roomID, equipmentID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		DELETE FROM room_equipment
+		WHERE room_id = $1 AND equipment_id = $2
+	`, roomID, equipmentID)
+	if err != nil {
+		return fmt.Errorf("failed to remove equipment from room: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove equipment from room: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("equipment %d in room %d %w", equipmentID, roomID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -1,16 +1,20 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
+	"coworking-booking/internal/payments"
+
 	_ "github.com/lib/pq"
 )
 
 // DB представляет подключение к базе данных
 type DB struct {
 	*sql.DB
+	provider payments.Provider // платёжный шлюз; nil — платежи подтверждаются только вручную
 }
 
 // Config содержит параметры подключения к БД
@@ -23,8 +27,27 @@ type Config struct {
 	SSLMode  string
 }
 
+// queryRower — общий интерфейс *sql.DB и *sql.Tx для запросов одной строки
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// querier — общий интерфейс *sql.DB и *sql.Tx для запросов нескольких строк
+type querier interface {
+	queryRower
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Параметры транзакций
+var (
+	// txWrite — изменение данных; от пересечений защищает EXCLUDE constraint
+	txWrite = &sql.TxOptions{Isolation: sql.LevelReadCommitted}
+	// txReport — отчёты читают согласованный снимок и ничего не изменяют
+	txReport = &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+)
+
 // New создаёт новое подключение к PostgreSQL
-func New(cfg Config) (*DB, error) {
+func New(ctx context.Context, cfg Config) (*DB, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
@@ -41,11 +64,12 @@ func New(cfg Config) (*DB, error) {
 	db.SetConnMaxLifetime(5 * time.Minute)
 
 	// Проверка подключения
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db}, nil
 }
 
 // Close закрывает подключение к БД
@@ -53,7 +77,22 @@ func (db *DB) Close() error {
 	return db.DB.Close()
 }
 
-// BeginTx начинает транзакцию
-func (db *DB) BeginTx() (*sql.Tx, error) {
-	return db.DB.Begin()
+// BeginTx начинает транзакцию с явными параметрами (уровень изоляции, read-only).
+// Изменения в транзакции записываются в audit_log от имени исполнителя из ctx (WithActor).
+// Транзакция откатывается, если ctx отменяется до Commit
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if opts == nil {
+		opts = txWrite
+	}
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.ReadOnly {
+		if err := setActorTx(ctx, tx); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
 }
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/money"
+	"coworking-booking/migrations"
+)
+
+// Интеграционные тесты работают с отдельной базой PostgreSQL из TEST_DATABASE_URL
+// (например postgres://postgres@localhost/coworking_test?sslmode=disable).
+// Схема public этой базы пересоздаётся при запуске. Без переменной тесты пропускаются
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+var (
+	testDBOnce sync.Once
+	testDB     *DB
+	testDBErr  error
+)
+
+// openTestDB возвращает общую для пакета базу с применёнными миграциями
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+	testDBOnce.Do(func() { testDB, testDBErr = setupTestDB(dsn) })
+	if testDBErr != nil {
+		t.Fatal(testDBErr)
+	}
+	return testDB
+}
+
+func setupTestDB(dsn string) (*DB, error) {
+	ctx := context.Background()
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open test database: %w", err)
+	}
+	db := &DB{DB: conn}
+	if _, err := db.ExecContext(ctx, `DROP SCHEMA public CASCADE; CREATE SCHEMA public`); err != nil {
+		return nil, fmt.Errorf("failed to reset test database: %w", err)
+	}
+	all, err := LoadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.MigrateUp(ctx, all); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// fixture — пользователь и коворкинг, созданные для одного теста
+type fixture struct {
+	db          *DB
+	userID      int
+	coworkingID int
+	start       time.Time // начало свободного дня в будущем
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	return newFixtureIn(t, "Europe/Moscow", "Москва")
+}
+
+// newFixtureIn создаёт коворкинг в часовом поясе zone и городе city
+func newFixtureIn(t *testing.T, zone, city string) *fixture {
+	t.Helper()
+	db := openTestDB(t)
+	ctx := context.Background()
+	name := uniqueName(t)
+
+	user, err := db.CreateUser(ctx, name+"@example.test", "x", name, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Город — первая часть адреса (coworking.city)
+	cw, err := db.CreateCoworking(ctx, name, city+", ул. Тестовая, 1", nil, zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(72 * time.Hour).Truncate(24 * time.Hour).Add(10 * time.Hour)
+	return &fixture{db: db, userID: user.UserID, coworkingID: cw.CoworkingID, start: start}
+}
+
+// user создаёт ещё одного пользователя
+func (f *fixture) user(t *testing.T) int {
+	t.Helper()
+	name := uniqueName(t)
+	u, err := f.db.CreateUser(context.Background(), name+"@example.test", "x", name, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.UserID
+}
+
+// room создаёт комнату с часовой ставкой rate
+func (f *fixture) room(t *testing.T, rate string) int {
+	t.Helper()
+	r, err := f.db.CreateRoom(context.Background(), f.coworkingID, uniqueName(t), 4, nil, money.MustParse(rate))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.RoomID
+}
+
+// at — начало бронирования через offset после f.start
+func (f *fixture) at(offset time.Duration) time.Time {
+	return f.start.Add(offset)
+}
+
+// statusReasons возвращает причины переходов статуса бронирования в порядке записи
+func (f *fixture) statusReasons(t *testing.T, bookingID int) []string {
+	t.Helper()
+	rows, err := f.db.QueryContext(context.Background(), `
+		SELECT reason FROM booking_status_history WHERE booking_id = $1 ORDER BY history_id
+	`, bookingID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var reasons []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			t.Fatal(err)
+		}
+		reasons = append(reasons, r)
+	}
+	return reasons
+}
+
+var uniqueSeq struct {
+	sync.Mutex
+	n int
+}
+
+// uniqueName — имя, не повторяющееся между тестами и запусками
+func uniqueName(t *testing.T) string {
+	uniqueSeq.Lock()
+	defer uniqueSeq.Unlock()
+	uniqueSeq.n++
+	name := strings.NewReplacer("/", "-", " ", "-").Replace(strings.ToLower(t.Name()))
+	return fmt.Sprintf("%s-%d-%d", name, time.Now().UnixNano(), uniqueSeq.n)
+}
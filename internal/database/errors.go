@@ -0,0 +1,17 @@
+package database
+
+import "errors"
+
+// Ошибки уровня данных, которые вызывающий код может проверять через errors.Is
+var (
+	// ErrNotFound — запись не найдена
+	ErrNotFound = errors.New("not found")
+	// ErrConflict — операция невозможна в текущем состоянии записи
+	ErrConflict = errors.New("conflict")
+	// ErrRoomUnavailable — комната занята в выбранное время (booking_no_overlap)
+	ErrRoomUnavailable = errors.New("комната занята в выбранное время")
+	// ErrOutsideOpeningHours — выбранное время выходит за часы работы комнаты или приходится на праздник
+	ErrOutsideOpeningHours = errors.New("комната закрыта в выбранное время")
+	// ErrPromoCodeInvalid — промокод не найден, неактивен, истёк, исчерпан или не подходит к комнате
+	ErrPromoCodeInvalid = errors.New("промокод недействителен")
+)
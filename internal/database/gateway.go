@@ -0,0 +1,301 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/payments"
+)
+
+// Результаты обработки уведомлений шлюза (payment_event.outcome)
+const (
+	OutcomeConfirmed = "confirmed" // платёж оплачен, бронирование подтверждено
+	OutcomePaid      = "paid"      // платёж оплачен, бронирование ещё ждёт оплаты или уже подтверждено
+	OutcomeFailed    = "failed"    // платёж отклонён
+	OutcomeRefunded  = "refunded"  // деньги возвращены
+	OutcomeIgnored   = "ignored"   // событие не меняет платёж (например, отказ по уже оплаченному)
+)
+
+// Причины возвратов по уведомлениям шлюза (refund.reason)
+const (
+	ReasonProviderRefund = "provider_refund" // возврат, проведённый в шлюзе
+	ReasonOverpayment    = "overpayment"     // оплата сверх стоимости или после отмены бронирования
+)
+
+// intentBatchSize ограничивает число намерений оплаты, создаваемых за один проход
+const intentBatchSize = 100
+
+// SetPaymentProvider подключает платёжный шлюз; nil — платежи подтверждаются только вручную
+func (db *DB) SetPaymentProvider(p payments.Provider) {
+	db.provider = p
+}
+
+// CreatePaymentIntents создаёт намерения оплаты для ожидающих платежей, выставленных без него:
+// вхождений серий, предложений листа ожидания, доплат за перенос, а также платежей, для которых
+// шлюз не ответил при создании. Возвращает ID платежей.
+// Запросы к шлюзу идут вне транзакции; намерение идемпотентно по payment_id, поэтому несколько
+// экземпляров могут работать одновременно — платёж получит одно намерение (см. attachIntent)
+func (db *DB) CreatePaymentIntents(ctx context.Context) ([]int, error) {
+	if db.provider == nil {
+		return nil, nil
+	}
+
+	// Наличные через шлюз не проводятся (см. payments.RequiresIntent)
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM payment
+		WHERE status = 'pending' AND provider_ref IS NULL
+		  AND payment_method IS DISTINCT FROM 'cash'
+		ORDER BY payment_id
+		LIMIT $1
+	`, intentBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments without intent: %w", err)
+	}
+	var pending []models.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		pending = append(pending, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read payments: %w", err)
+	}
+	rows.Close()
+
+	var ids []int
+	for i := range pending {
+		if err := db.attachIntent(ctx, &pending[i]); err != nil {
+			return nil, err
+		}
+		if pending[i].ProviderRef != nil {
+			ids = append(ids, pending[i].PaymentID)
+		}
+	}
+	return ids, nil
+}
+
+// ApplyPaymentEvent применяет проверенное уведомление шлюза provider в одной транзакции:
+//   - payment.succeeded — платёж оплачен, бронирование подтверждается, если баланс покрывает
+//     стоимость (как ConfirmPaymentAndBooking); оплата отменённого бронирования и переплата
+//     (платёж успели заменить при переносе) сразу возвращаются;
+//   - payment.failed — ожидающий платёж переходит в failed с причиной отказа;
+//   - payment.refunded — возврат по оплаченному платежу (без суммы — весь невозвращённый остаток).
+//
+// Событие с уже обработанным ID ничего не меняет и возвращает сохранённый результат (Duplicate).
+// Неизвестное намерение оплаты — ErrNotFound; событие не сохраняется, и шлюз может повторить его
+func (db *DB) ApplyPaymentEvent(ctx context.Context, provider string, ev payments.Event) (*models.PaymentEventResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.PaymentEventResult{EventID: ev.ID, EventType: ev.Type}
+
+	// Регистрация события; одновременная доставка того же события ждёт здесь первую
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO payment_event (provider, event_id, event_type)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`, provider, ev.ID, ev.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payment event: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to record payment event: %w", err)
+	} else if n == 0 {
+		return duplicatePaymentEvent(ctx, tx, provider, result)
+	}
+
+	// Блокировка бронирования сериализует изменения его платежей
+	var bookingID, paymentID int
+	var bookingStatus string
+	err = tx.QueryRowContext(ctx, `
+		SELECT b.booking_id, b.status, p.payment_id
+		FROM payment p
+		JOIN booking b ON b.booking_id = p.booking_id
+		WHERE p.provider = $1 AND p.provider_ref = $2
+		FOR UPDATE OF b
+	`, provider, ev.Ref).Scan(&bookingID, &bookingStatus, &paymentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment intent %s %w", ev.Ref, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to lock booking: %w", err)
+	}
+	payment, err := scanPayment(tx.QueryRowContext(ctx, `
+		SELECT `+paymentColumns+` FROM payment WHERE payment_id = $1
+	`, paymentID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	result.Outcome = OutcomeIgnored
+	switch ev.Type {
+	case payments.EventSucceeded:
+		if payment.Status == "paid" {
+			break
+		}
+		// Деньги получены, даже если платёж успели отменить или он истёк
+		confirmed := false
+		if payment, confirmed, err = confirmPaymentTx(ctx, tx, bookingID, paymentID); err != nil {
+			return nil, err
+		}
+		result.Outcome = OutcomePaid
+		if confirmed {
+			result.Outcome = OutcomeConfirmed
+		}
+
+		// Оплата отменённого бронирования возвращается целиком, переплата — в размере излишка
+		excess := payment.Amount
+		if bookingStatus != "cancelled" {
+			balance, err := bookingBalance(ctx, tx, bookingID)
+			if err != nil {
+				return nil, err
+			}
+			excess = balance.AmountDue.Neg()
+		}
+		if excess.Sign() > 0 {
+			r, err := refundPaymentTx(ctx, tx, *payment, excess, nil, ReasonOverpayment)
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				result.Refunds = append(result.Refunds, *r)
+			}
+		}
+
+	case payments.EventFailed:
+		if payment.Status != "pending" {
+			break
+		}
+		payment, err = scanPayment(tx.QueryRowContext(ctx, `
+			UPDATE payment
+			SET status = 'failed', failure_reason = NULLIF($2, '')
+			WHERE payment_id = $1
+			RETURNING `+paymentColumns,
+			paymentID, ev.Reason))
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payment: %w", err)
+		}
+		result.Outcome = OutcomeFailed
+
+	case payments.EventRefunded:
+		if payment.Status != "paid" {
+			break
+		}
+		amount := payment.Amount
+		if ev.Amount != nil {
+			amount = *ev.Amount
+		}
+		r, err := refundPaymentTx(ctx, tx, *payment, amount, nil, ReasonProviderRefund)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			result.Refunds = append(result.Refunds, *r)
+			result.Outcome = OutcomeRefunded
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payment_event SET payment_id = $3, outcome = $4
+		WHERE provider = $1 AND event_id = $2
+	`, provider, ev.ID, paymentID, result.Outcome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record payment event outcome: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM booking WHERE booking_id = $1`, bookingID).Scan(&result.BookingStatus); err != nil {
+		return nil, fmt.Errorf("failed to get booking status: %w", err)
+	}
+	result.Payment = payment
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// duplicatePaymentEvent возвращает сохранённый результат уже обработанного события
+func duplicatePaymentEvent(ctx context.Context, tx *sql.Tx, provider string, result *models.PaymentEventResult) (*models.PaymentEventResult, error) {
+	result.Duplicate = true
+
+	var paymentID sql.NullInt64
+	var outcome sql.NullString
+	err := tx.QueryRowContext(ctx, `
+		SELECT payment_id, outcome FROM payment_event WHERE provider = $1 AND event_id = $2
+	`, provider, result.EventID).Scan(&paymentID, &outcome)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment event: %w", err)
+	}
+	result.Outcome = outcome.String
+	if paymentID.Valid {
+		result.Payment, err = scanPayment(tx.QueryRowContext(ctx, `
+			SELECT `+paymentColumns+` FROM payment WHERE payment_id = $1
+		`, paymentID.Int64))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment: %w", err)
+		}
+		err = tx.QueryRowContext(ctx, `SELECT status FROM booking WHERE booking_id = $1`, result.Payment.BookingID).Scan(&result.BookingStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get booking status: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// attachIntent создаёт в шлюзе намерение оплаты для ожидающего платежа и сохраняет его в p.
+// Вызывается после фиксации транзакции платежа, чтобы запрос к шлюзу не держал блокировки.
+// Шлюз возвращает для того же payment_id то же намерение, а сохраняется оно только у ожидающего
+// платежа без намерения; если его уже сохранил другой вызов или платёж успел измениться,
+// в p перечитывается текущее состояние.
+// Ничего не делает без шлюза, для платежей наличными и для платежей, у которых намерение уже есть
+func (db *DB) attachIntent(ctx context.Context, p *models.Payment) error {
+	if db.provider == nil || p == nil || p.Status != "pending" || p.ProviderRef != nil {
+		return nil
+	}
+	var method string
+	if p.PaymentMethod != nil {
+		method = *p.PaymentMethod
+	}
+	if !payments.RequiresIntent(method) {
+		return nil
+	}
+
+	ref, err := db.provider.CreateIntent(ctx, payments.Intent{
+		PaymentID: p.PaymentID,
+		BookingID: p.BookingID,
+		Amount:    p.Amount,
+		Method:    method,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payment intent: %w", err)
+	}
+
+	updated, err := scanPayment(db.QueryRowContext(ctx, `
+		UPDATE payment SET provider = $2, provider_ref = $3
+		WHERE payment_id = $1 AND status = 'pending' AND provider_ref IS NULL
+		RETURNING `+paymentColumns,
+		p.PaymentID, db.provider.Name(), ref))
+	if err == sql.ErrNoRows {
+		updated, err = scanPayment(db.QueryRowContext(ctx, `
+			SELECT `+paymentColumns+` FROM payment WHERE payment_id = $1
+		`, p.PaymentID))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save payment intent: %w", err)
+	}
+	*p = *updated
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/payments"
+)
+
+// downProvider — шлюз, который не отвечает при создании намерений
+type downProvider struct {
+	*payments.Fake
+}
+
+func (downProvider) CreateIntent(context.Context, payments.Intent) (string, error) {
+	return "", errors.New("gateway is down")
+}
+
+// withProvider подключает шлюз к общей базе на время теста
+func (f *fixture) withProvider(t *testing.T, p payments.Provider) {
+	t.Helper()
+	f.db.SetPaymentProvider(p)
+	t.Cleanup(func() { f.db.SetPaymentProvider(nil) })
+}
+
+func TestPaymentIntentAfterCommit(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	fake := payments.NewFake("secret")
+	f.withProvider(t, fake)
+
+	_, payment, err := f.db.CreateBookingWithPayment(ctx, f.room(t, "1000.00"), f.userID, f.at(0), f.at(time.Hour), "card", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.ProviderRef == nil || payment.Provider == nil || *payment.Provider != payments.FakeName {
+		t.Fatalf("payment = %+v, want a fake intent", payment)
+	}
+
+	// Повторная попытка с устаревшей копией получает сохранённое намерение
+	stale := *payment
+	stale.Provider, stale.ProviderRef = nil, nil
+	if err := f.db.attachIntent(ctx, &stale); err != nil {
+		t.Fatal(err)
+	}
+	if stale.ProviderRef == nil || *stale.ProviderRef != *payment.ProviderRef {
+		t.Errorf("provider_ref = %v, want %s", stale.ProviderRef, *payment.ProviderRef)
+	}
+}
+
+func TestPaymentIntentWhenGatewayIsDown(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	fake := payments.NewFake("secret")
+	f.withProvider(t, downProvider{fake})
+
+	booking, payment, err := f.db.CreateBookingWithPayment(ctx, f.room(t, "1000.00"), f.userID, f.at(0), f.at(time.Hour), "card", "")
+	if err != nil {
+		t.Fatalf("booking must be created while the gateway is down: %v", err)
+	}
+	if booking.Status != "pending" || payment.ProviderRef != nil {
+		t.Fatalf("booking %+v, payment %+v", booking, payment)
+	}
+	if _, err := f.db.CreatePaymentIntents(ctx); err == nil {
+		t.Error("CreatePaymentIntents succeeded while the gateway is down")
+	}
+
+	// Планировщик создаёт намерение, когда шлюз снова отвечает
+	f.db.SetPaymentProvider(fake)
+	for {
+		ids, err := f.db.CreatePaymentIntents(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+	}
+	var ref *string
+	err = f.db.QueryRowContext(ctx, `SELECT provider_ref FROM payment WHERE payment_id = $1`, payment.PaymentID).Scan(&ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := fake.CreateIntent(ctx, payments.Intent{PaymentID: payment.PaymentID})
+	if ref == nil || *ref != want {
+		t.Errorf("provider_ref = %v, want %s", ref, want)
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Виды документов (invoice.kind)
+const (
+	DocumentInvoice = "invoice" // счёт на ожидающий платёж
+	DocumentReceipt = "receipt" // квитанция об оплаченном платеже
+)
+
+// invoiceColumns — колонки документа в порядке scanInvoice
+const invoiceColumns = `i.invoice_id, i.kind, i.coworking_id, i.number, i.booking_id, i.payment_id, p.status,
+	i.issued_at, i.seller_name, i.seller_tax_id, i.seller_address, i.buyer_name, i.buyer_email,
+	i.room_name, i.starts_at, i.ends_at, i.time_zone, i.booking_total,
+	i.amount, i.vat_percent, i.vat_amount, i.payment_method, i.paid_at`
+
+// SetCoworkingLegalEntity задаёт реквизиты юридического лица коворкинга. Изменения действуют
+// на документы, выданные после них; выданные счета и квитанции не меняются
+func (db *DB) SetCoworkingLegalEntity(ctx context.Context, coworkingID int, req models.LegalEntityRequest) (*models.Coworking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var c models.Coworking
+	err = scanCoworking(tx.QueryRowContext(ctx, `
+		UPDATE coworking
+		SET legal_name = NULLIF($2, ''), tax_id = NULLIF($3, ''), vat_percent = $4
+		WHERE coworking_id = $1
+		RETURNING `+coworkingColumns+`
+	`, coworkingID, req.LegalName, req.TaxID, req.VATPercent), &c)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return nil, fmt.Errorf("vat_percent must be between 0 and 100: %w", ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to set legal entity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &c, nil
+}
+
+// GetBookingInvoices возвращает счета и квитанции бронирования в порядке выдачи (без строк расчёта)
+func (db *DB) GetBookingInvoices(ctx context.Context, bookingID int) ([]models.Invoice, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM invoice i
+		JOIN payment p ON i.payment_id = p.payment_id
+		WHERE i.booking_id = $1
+		ORDER BY i.issued_at, i.invoice_id
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+	defer rows.Close()
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, *inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read invoices: %w", err)
+	}
+	return invoices, nil
+}
+
+// GetInvoice возвращает счёт или квитанцию со строками расчёта
+func (db *DB) GetInvoice(ctx context.Context, invoiceID int) (*models.Invoice, error) {
+	tx, err := db.BeginTx(ctx, txReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inv, err := scanInvoice(tx.QueryRowContext(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM invoice i
+		JOIN payment p ON i.payment_id = p.payment_id
+		WHERE i.invoice_id = $1
+	`, invoiceID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("invoice with id %d %w", invoiceID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT description, minutes, hourly_rate, amount
+		FROM invoice_line
+		WHERE invoice_id = $1
+		ORDER BY position
+	`, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice lines: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l models.InvoiceLine
+		if err := rows.Scan(&l.Description, &l.Minutes, &l.HourlyRate, &l.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan invoice line: %w", err)
+		}
+		inv.Lines = append(inv.Lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read invoice lines: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return inv, nil
+}
+
+// GetInvoiceBookingID возвращает бронирование, к которому относится документ
+func (db *DB) GetInvoiceBookingID(ctx context.Context, invoiceID int) (int, error) {
+	var bookingID int
+	err := db.QueryRowContext(ctx, `SELECT booking_id FROM invoice WHERE invoice_id = $1`, invoiceID).Scan(&bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("invoice with id %d %w", invoiceID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return bookingID, nil
+}
+
+// issueDocumentTx выдаёт документ kind по платежу: следующий номер коворкинга и снимок
+// реквизитов, бронирования и расчёта цены (SQL-функция issue_document)
+func issueDocumentTx(ctx context.Context, tx *sql.Tx, paymentID int, kind string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT issue_document($1, $2, NOW())`, paymentID, kind); err != nil {
+		return fmt.Errorf("failed to issue %s: %w", kind, err)
+	}
+	return nil
+}
+
+// scanInvoice читает колонки invoiceColumns
+func scanInvoice(row rowScanner) (*models.Invoice, error) {
+	var inv models.Invoice
+	var number int
+	err := row.Scan(&inv.InvoiceID, &inv.Kind, &inv.CoworkingID, &number, &inv.BookingID, &inv.PaymentID, &inv.PaymentStatus,
+		&inv.IssuedAt, &inv.SellerName, &inv.SellerTaxID, &inv.SellerAddress, &inv.BuyerName, &inv.BuyerEmail,
+		&inv.RoomName, &inv.StartsAt, &inv.EndsAt, &inv.TimeZone, &inv.BookingTotal,
+		&inv.Amount, &inv.VATPercent, &inv.VATAmount, &inv.PaymentMethod, &inv.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+	inv.Number = fmt.Sprintf("%d-%06d", inv.CoworkingID, number)
+	inv.IssuedAt = localtime.In(inv.IssuedAt, inv.TimeZone)
+	inv.StartsAt = localtime.In(inv.StartsAt, inv.TimeZone)
+	inv.EndsAt = localtime.In(inv.EndsAt, inv.TimeZone)
+	if inv.PaidAt != nil {
+		paidAt := localtime.In(*inv.PaidAt, inv.TimeZone)
+		inv.PaidAt = &paidAt
+	}
+	return &inv, nil
+}
@@ -0,0 +1,392 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+)
+
+// paymentColumns — колонки платежа в порядке scanPayment
+const paymentColumns = `payment_id, booking_id, amount, status, payment_method, paid_at, created_at,
+	updated_at, provider, provider_ref, failure_reason`
+
+// refundColumns — колонки возврата в порядке scanRefund
+const refundColumns = `refund_id, booking_id, payment_id, amount, refund_percent, reason, created_at`
+
+// GetBookingBalance возвращает баланс бронирования по всем платежам и возвратам
+func (db *DB) GetBookingBalance(ctx context.Context, bookingID int) (*models.BookingBalance, error) {
+	return bookingBalance(ctx, db, bookingID)
+}
+
+// GetPayment возвращает платёж по ID
+func (db *DB) GetPayment(ctx context.Context, paymentID int) (*models.Payment, error) {
+	payment, err := scanPayment(db.QueryRowContext(ctx, `
+		SELECT `+paymentColumns+` FROM payment WHERE payment_id = $1
+	`, paymentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("payment with id %d %w", paymentID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+// GetBookingLedger возвращает платежи и возвраты бронирования в порядке создания вместе с балансом
+func (db *DB) GetBookingLedger(ctx context.Context, bookingID int) (*models.BookingLedger, error) {
+	tx, err := db.BeginTx(ctx, txReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	ledger := models.BookingLedger{Payments: []models.Payment{}, Refunds: []models.Refund{}}
+	if ledger.Balance, err = bookingBalance(ctx, tx, bookingID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM payment
+		WHERE booking_id = $1
+		ORDER BY created_at, payment_id
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		ledger.Payments = append(ledger.Payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read payments: %w", err)
+	}
+	rows.Close()
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT `+refundColumns+`
+		FROM refund
+		WHERE booking_id = $1
+		ORDER BY created_at, refund_id
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunds: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		r, err := scanRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan refund: %w", err)
+		}
+		ledger.Refunds = append(ledger.Refunds, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read refunds: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &ledger, nil
+}
+
+// AddPayment создаёт ожидающий платёж по бронированию: депозит, оплату частями или доплату.
+// Сумма по умолчанию — весь остаток, ещё не покрытый оплаченными и ожидающими платежами;
+// больше остатка выставить нельзя. Для платежа через шлюз после фиксации создаётся намерение оплаты
+func (db *DB) AddPayment(ctx context.Context, bookingID int, req models.AddPaymentRequest) (*models.Payment, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Блокировка бронирования сериализует платежи по нему
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM booking WHERE booking_id = $1 FOR UPDATE`, bookingID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if status != "pending" && status != "confirmed" {
+		return nil, fmt.Errorf("booking in status %s cannot be paid: %w", status, ErrConflict)
+	}
+
+	balance, err := bookingBalance(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	outstanding := balance.AmountDue.Sub(balance.PendingAmount)
+	if outstanding.Sign() <= 0 {
+		return nil, fmt.Errorf("booking %d is already fully paid or awaiting payment: %w", bookingID, ErrConflict)
+	}
+	amount := outstanding
+	if req.Amount != nil {
+		amount = *req.Amount
+	}
+	if amount.Sign() <= 0 || amount.Cmp(outstanding) > 0 {
+		return nil, fmt.Errorf("payment amount must be between 0.01 and %s: %w", outstanding, ErrConflict)
+	}
+
+	payment, err := insertPaymentTx(ctx, tx, bookingID, amount, req.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Платёж уже выставлен: если шлюз не ответил, намерение позже создаст CreatePaymentIntents
+	_ = db.attachIntent(ctx, payment)
+	return payment, nil
+}
+
+// bookingBalance читает баланс бронирования из представления booking_balance
+func bookingBalance(ctx context.Context, q queryRower, bookingID int) (*models.BookingBalance, error) {
+	var b models.BookingBalance
+	err := q.QueryRowContext(ctx, `
+		SELECT booking_id, total_amount, paid_amount, pending_amount, refunded_amount,
+		       balance, amount_due, payment_status
+		FROM booking_balance
+		WHERE booking_id = $1
+	`, bookingID).Scan(&b.BookingID, &b.TotalAmount, &b.PaidAmount, &b.PendingAmount, &b.RefundedAmount,
+		&b.Balance, &b.AmountDue, &b.PaymentStatus)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking balance: %w", err)
+	}
+	return &b, nil
+}
+
+// insertPaymentTx создаёт ожидающий платёж и выставляет на него счёт
+func insertPaymentTx(ctx context.Context, tx *sql.Tx, bookingID int, amount money.Money, method string) (*models.Payment, error) {
+	payment, err := scanPayment(tx.QueryRowContext(ctx, `
+		INSERT INTO payment (booking_id, amount, status, payment_method)
+		VALUES ($1, $2, 'pending', NULLIF($3, ''))
+		RETURNING `+paymentColumns,
+		bookingID, amount, method))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment: %w", err)
+	}
+	if payment.Amount.IsZero() {
+		return payment, nil // документ на ноль не выдаётся (invoice_amount_check)
+	}
+	if err := issueDocumentTx(ctx, tx, payment.PaymentID, DocumentInvoice); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// adjustPaymentsTx приводит платежи бронирования к его новой стоимости после переноса.
+// Ожидающие платежи не меняют сумму (под неё в шлюзе уже может быть создано намерение оплаты),
+// поэтому при изменении стоимости они отменяются, и весь неоплаченный остаток выставляется
+// одним новым платежом; переплата возвращается по оплаченным платежам.
+// Подтверждённое бронирование, за которое ничего не оплачено (бесплатное), при появлении
+// остатка к оплате возвращается в pending — как новое бронирование с ожидающим платежом
+func adjustPaymentsTx(ctx context.Context, tx *sql.Tx, bookingID int, reason string) ([]models.Payment, []models.Refund, *models.BookingBalance, error) {
+	pending, err := lockPayments(ctx, tx, bookingID, "pending")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	balance, err := bookingBalance(ctx, tx, bookingID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if balance.AmountDue.Cmp(balance.PendingAmount) == 0 {
+		return nil, nil, balance, nil
+	}
+
+	var changed []models.Payment
+	var refunds []models.Refund
+	for _, p := range pending {
+		cancelled, err := scanPayment(tx.QueryRowContext(ctx, `
+			UPDATE payment SET status = 'cancelled' WHERE payment_id = $1
+			RETURNING `+paymentColumns,
+			p.PaymentID))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to cancel payment: %w", err)
+		}
+		changed = append(changed, *cancelled)
+	}
+
+	switch due := balance.AmountDue; {
+	case due.Sign() > 0:
+		// Способ оплаты — как у последнего платежа; у бесплатного бронирования платежей нет
+		var method string
+		err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(payment_method, '')
+			FROM payment
+			WHERE booking_id = $1
+			ORDER BY created_at DESC, payment_id DESC
+			LIMIT 1
+		`, bookingID).Scan(&method)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, nil, fmt.Errorf("failed to get payment method: %w", err)
+		}
+		p, err := insertPaymentTx(ctx, tx, bookingID, due, method)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		changed = append(changed, *p)
+		if balance.Balance.IsZero() {
+			if err := unconfirmBookingTx(ctx, tx, bookingID); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+	case due.Sign() < 0:
+		if refunds, err = refundPaidTx(ctx, tx, bookingID, due.Neg(), nil, reason); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if balance, err = bookingBalance(ctx, tx, bookingID); err != nil {
+		return nil, nil, nil, err
+	}
+	return changed, refunds, balance, nil
+}
+
+// unconfirmBookingTx возвращает подтверждённое бронирование в pending до оплаты остатка
+func unconfirmBookingTx(ctx context.Context, tx *sql.Tx, bookingID int) error {
+	result, err := tx.ExecContext(ctx, `
+		UPDATE booking
+		SET status = 'pending', updated_at = NOW()
+		WHERE booking_id = $1 AND status = 'confirmed'
+	`, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to update booking status: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update booking status: %w", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	return recordStatusChange(ctx, tx, []int{bookingID}, "confirmed", "pending", ReasonPaymentRequired)
+}
+
+// refundPaidTx возвращает amount по оплаченным платежам бронирования, начиная с последнего:
+// с каждого платежа — не больше, чем по нему ещё не возвращено. percent — процент политики
+// отмены (nil для возврата разницы). Сумма сверх оплаченного не возвращается
+func refundPaidTx(ctx context.Context, tx *sql.Tx, bookingID int, amount money.Money, percent *int, reason string) ([]models.Refund, error) {
+	paid, err := lockPayments(ctx, tx, bookingID, "paid")
+	if err != nil {
+		return nil, err
+	}
+
+	var refunds []models.Refund
+	for _, p := range paid {
+		if amount.Sign() <= 0 {
+			break
+		}
+		r, err := refundPaymentTx(ctx, tx, p, amount, percent, reason)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			refunds = append(refunds, *r)
+			amount = amount.Sub(r.Amount)
+		}
+	}
+	return refunds, nil
+}
+
+// refundPaymentTx возвращает по оплаченному платежу amount, но не больше, чем по нему ещё
+// не возвращено; nil — возвращать уже нечего
+func refundPaymentTx(ctx context.Context, tx *sql.Tx, p models.Payment, amount money.Money, percent *int, reason string) (*models.Refund, error) {
+	var refunded money.Money
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM refund WHERE payment_id = $1
+	`, p.PaymentID).Scan(&refunded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	part := p.Amount.Sub(refunded)
+	if part.Cmp(amount) > 0 {
+		part = amount
+	}
+	if part.Sign() <= 0 {
+		return nil, nil
+	}
+
+	r, err := scanRefund(tx.QueryRowContext(ctx, `
+		INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+refundColumns,
+		p.BookingID, p.PaymentID, part, percent, reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+	return r, nil
+}
+
+// cancelPendingPaymentsTx отменяет ожидающие платежи бронирования, которые больше не нужны
+func cancelPendingPaymentsTx(ctx context.Context, tx *sql.Tx, bookingID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE payment SET status = 'cancelled' WHERE booking_id = $1 AND status = 'pending'
+	`, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel pending payments: %w", err)
+	}
+	return nil
+}
+
+// lockPayments блокирует платежи бронирования в статусе status, начиная с последнего
+func lockPayments(ctx context.Context, tx *sql.Tx, bookingID int, status string) ([]models.Payment, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+paymentColumns+`
+		FROM payment
+		WHERE booking_id = $1 AND status = $2
+		ORDER BY created_at DESC, payment_id DESC
+		FOR UPDATE
+	`, bookingID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []models.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read payments: %w", err)
+	}
+	return payments, nil
+}
+
+// scanPayment читает колонки paymentColumns
+func scanPayment(row rowScanner) (*models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(&p.PaymentID, &p.BookingID, &p.Amount, &p.Status, &p.PaymentMethod, &p.PaidAt, &p.CreatedAt,
+		&p.UpdatedAt, &p.Provider, &p.ProviderRef, &p.FailureReason)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// scanRefund читает колонки refundColumns
+func scanRefund(row rowScanner) (*models.Refund, error) {
+	var r models.Refund
+	if err := row.Scan(&r.RefundID, &r.BookingID, &r.PaymentID, &r.Amount, &r.RefundPercent, &r.Reason, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
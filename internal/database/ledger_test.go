@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+func TestRescheduleFreeBookingIntoPaidRoom(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	free, paid := f.room(t, "0"), f.room(t, "1000.00")
+
+	booking, payment, err := f.db.CreateBookingWithPayment(ctx, free, f.userID, f.at(0), f.at(2*time.Hour), "card", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.Status != "confirmed" || payment != nil {
+		t.Fatalf("free booking: status %s, payment %+v", booking.Status, payment)
+	}
+
+	result, err := f.db.RescheduleBooking(ctx, booking.BookingID, f.userID, models.RescheduleBookingRequest{RoomID: &paid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Booking.Status != "pending" {
+		t.Errorf("status after reschedule = %s, want pending", result.Booking.Status)
+	}
+	if len(result.Payments) != 1 || result.Payments[0].Status != "pending" || result.Payments[0].Amount.String() != "2000.00" {
+		t.Fatalf("payments = %+v, want one pending 2000.00", result.Payments)
+	}
+	if result.Payments[0].PaymentMethod != nil {
+		t.Errorf("payment method = %q, want none", *result.Payments[0].PaymentMethod)
+	}
+	if b := result.Balance; b.AmountDue.String() != "2000.00" || b.PaymentStatus != "pending" {
+		t.Errorf("balance = %+v", b)
+	}
+	if got := f.statusReasons(t, booking.BookingID); !slices.Equal(got, []string{ReasonPaymentRequired}) {
+		t.Errorf("status history = %v", got)
+	}
+	invoices, err := f.db.GetBookingInvoices(ctx, booking.BookingID)
+	if err != nil || len(invoices) != 1 {
+		t.Errorf("invoices = %+v, %v; want one", invoices, err)
+	}
+
+	// Окно удержания считается с возврата в pending, а не с создания бронирования
+	if _, err := f.db.ExecContext(ctx, `UPDATE booking SET created_at = NOW() - interval '2 hours' WHERE booking_id = $1`, booking.BookingID); err != nil {
+		t.Fatal(err)
+	}
+	expired, err := f.db.ExpireUnpaidBookings(ctx, 30*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(expired, booking.BookingID) {
+		t.Error("booking returned to pending expired at once")
+	}
+
+	if _, confirmed, err := f.db.ConfirmPaymentAndBooking(ctx, result.Payments[0].PaymentID); err != nil || confirmed.Status != "confirmed" {
+		t.Errorf("after payment: %+v, %v", confirmed, err)
+	}
+}
+
+func TestRescheduleFreeBookingStaysFree(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	free := f.room(t, "0")
+
+	booking, _, err := f.db.CreateBookingWithPayment(ctx, free, f.userID, f.at(0), f.at(time.Hour), "card", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := f.at(3 * time.Hour)
+	result, err := f.db.RescheduleBooking(ctx, booking.BookingID, f.userID, models.RescheduleBookingRequest{EndsAt: &end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Booking.Status != "confirmed" || len(result.Payments) != 0 || result.Balance.PaymentStatus != "no_payment" {
+		t.Errorf("result = %+v, payments %+v, balance %+v", result.Booking, result.Payments, result.Balance)
+	}
+}
+
+func TestReschedulePaidBooking(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	room := f.room(t, "1000.00")
+
+	booking, payment, err := f.db.CreateBookingWithPayment(ctx, room, f.userID, f.at(0), f.at(2*time.Hour), "card", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := f.db.ConfirmPaymentAndBooking(ctx, payment.PaymentID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Продление: доплата одним ожидающим платежом, бронирование остаётся подтверждённым
+	end := f.at(3 * time.Hour)
+	result, err := f.db.RescheduleBooking(ctx, booking.BookingID, f.userID, models.RescheduleBookingRequest{EndsAt: &end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Booking.Status != "confirmed" {
+		t.Errorf("status = %s, want confirmed", result.Booking.Status)
+	}
+	if len(result.Payments) != 1 || result.Payments[0].Amount.String() != "1000.00" ||
+		result.Payments[0].PaymentMethod == nil || *result.Payments[0].PaymentMethod != "card" {
+		t.Errorf("payments = %+v, want one card payment of 1000.00", result.Payments)
+	}
+
+	// Сокращение: неоплаченная доплата отменяется, переплата возвращается
+	end = f.at(time.Hour)
+	result, err = f.db.RescheduleBooking(ctx, booking.BookingID, f.userID, models.RescheduleBookingRequest{EndsAt: &end})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Payments) != 1 || result.Payments[0].Status != "cancelled" {
+		t.Errorf("payments = %+v, want the surcharge cancelled", result.Payments)
+	}
+	if len(result.Refunds) != 1 || result.Refunds[0].Amount.String() != "1000.00" {
+		t.Errorf("refunds = %+v, want 1000.00", result.Refunds)
+	}
+	if b := result.Balance; !b.AmountDue.IsZero() || b.Balance.String() != "1000.00" {
+		t.Errorf("balance = %+v", b)
+	}
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Причины смены статуса бронирования (booking_status_history.reason)
+const (
+	ReasonPaymentConfirmed = "payment_confirmed"
+	ReasonCancelledByUser  = "cancelled_by_user"
+	ReasonSeriesCancelled  = "series_cancelled"
+	ReasonHoldExpired      = "hold_expired"
+	ReasonCompleted        = "completed"
+	ReasonPaymentRequired  = "payment_required" // бесплатное бронирование подорожало при переносе
+)
+
+// SeriesPaymentLead — за сколько до начала должно быть оплачено вхождение серии. Вхождения
+// создаются pending на месяцы вперёд, и окно удержания от created_at отменило бы их все,
+// поэтому неоплаченное вхождение держит слот до starts_at − SeriesPaymentLead
+// (но не меньше окна удержания с перехода в pending)
+const SeriesPaymentLead = 24 * time.Hour
+
+// lifecycleBatchSize ограничивает число бронирований, обрабатываемых за одну транзакцию
+const lifecycleBatchSize = 500
+
+// ExpireUnpaidBookings отменяет pending-бронирования, оплата по которым не поступила
+// в течение holdWindow с перехода в pending — создания или возврата бесплатного бронирования
+// в pending при переносе (для вхождений серий — см. SeriesPaymentLead, для предложений
+// из листа ожидания — до offer_expires_at),
+// и переводит их ожидающие платежи в failed. Освободившиеся интервалы предлагаются
+// следующим заявкам листа ожидания.
+// Строки блокируются с SKIP LOCKED, поэтому несколько экземпляров могут работать одновременно
+func (db *DB) ExpireUnpaidBookings(ctx context.Context, holdWindow time.Duration) ([]int, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE booking b
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE b.booking_id IN (
+			SELECT pb.booking_id
+			FROM booking pb
+			WHERE pb.status = 'pending'
+			  AND (
+				(COALESCE((
+					SELECT MAX(h.changed_at) FROM booking_status_history h
+					WHERE h.booking_id = pb.booking_id AND h.new_status = 'pending'
+				 ), pb.created_at) < NOW() - make_interval(secs => $1)
+				 AND (pb.series_id IS NULL OR pb.starts_at - make_interval(secs => $3) < NOW()))
+				OR EXISTS (
+					SELECT 1 FROM waitlist_entry w
+					WHERE w.offered_booking_id = pb.booking_id
+					  AND w.status = 'offered'
+					  AND w.offer_expires_at < NOW()
+				)
+			  )
+			  AND NOT EXISTS (
+				SELECT 1 FROM payment p
+				WHERE p.booking_id = pb.booking_id AND p.status = 'paid'
+			  )
+			ORDER BY pb.booking_id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		AND b.status = 'pending'
+		RETURNING b.booking_id
+	`
+	ids, err := queryIDs(ctx, tx, query, holdWindow.Seconds(), lifecycleBatchSize, SeriesPaymentLead.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire bookings: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payment
+		SET status = 'failed'
+		WHERE booking_id = ANY($1) AND status = 'pending'
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail expired payments: %w", err)
+	}
+
+	if err := recordStatusChange(ctx, tx, ids, "pending", "cancelled", ReasonHoldExpired); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := releaseSlotTx(ctx, tx, id, "expired"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// CompletePastBookings переводит подтверждённые бронирования, время которых прошло, в completed
+func (db *DB) CompletePastBookings(ctx context.Context) ([]int, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE booking b
+		SET status = 'completed', updated_at = NOW()
+		WHERE b.booking_id IN (
+			SELECT cb.booking_id
+			FROM booking cb
+			WHERE cb.status = 'confirmed' AND cb.ends_at <= NOW()
+			ORDER BY cb.booking_id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		AND b.status = 'confirmed'
+		RETURNING b.booking_id
+	`
+	ids, err := queryIDs(ctx, tx, query, lifecycleBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete bookings: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	if err := recordStatusChange(ctx, tx, ids, "confirmed", "completed", ReasonCompleted); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return ids, nil
+}
+
+// recordStatusChange записывает переход статуса бронирований в booking_status_history
+func recordStatusChange(ctx context.Context, tx *sql.Tx, bookingIDs []int, oldStatus, newStatus, reason string) error {
+	query := `
+		INSERT INTO booking_status_history (booking_id, old_status, new_status, reason)
+		SELECT unnest($1::int[]), $2, $3, $4
+	`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(bookingIDs), oldStatus, newStatus, reason); err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	return nil
+}
+
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
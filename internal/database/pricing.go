@@ -0,0 +1,319 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/pricing"
+	"coworking-booking/internal/schedule"
+
+	"github.com/lib/pq"
+)
+
+// pricingRuleColumns — колонки правила цены в порядке scanPricingRule
+const pricingRuleColumns = `
+	pricing_rule_id, coworking_id, room_id, kind, name, weekdays,
+	left(opens_at::text, 5), left(closes_at::text, 5), rate_percent, min_minutes, discount_percent
+`
+
+// GetCoworkingPricingRules возвращает правила цены коворкинга
+func (db *DB) GetCoworkingPricingRules(ctx context.Context, coworkingID int) ([]models.PricingRule, error) {
+	return db.getPricingRules(ctx, "coworking_id", coworkingID)
+}
+
+// GetRoomPricingRules возвращает собственные правила цены комнаты
+func (db *DB) GetRoomPricingRules(ctx context.Context, roomID int) ([]models.PricingRule, error) {
+	return db.getPricingRules(ctx, "room_id", roomID)
+}
+
+func (db *DB) getPricingRules(ctx context.Context, ownerColumn string, ownerID int) ([]models.PricingRule, error) {
+	query := `
+		SELECT ` + pricingRuleColumns + `
+		FROM pricing_rule
+		WHERE ` + ownerColumn + ` = $1
+		ORDER BY kind, pricing_rule_id
+	`
+	rows, err := db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.PricingRule
+	for rows.Next() {
+		rule, err := scanPricingRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pricing rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pricing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// CreatePricingRule добавляет правило цены комнаты (roomID) или коворкинга (coworkingID);
+// задан ровно один из них. Правило действует на бронирования, созданные или перенесённые после него
+func (db *DB) CreatePricingRule(ctx context.Context, roomID, coworkingID *int, req models.CreatePricingRuleRequest) (*models.PricingRule, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	weekdays := req.Weekdays
+	if weekdays == nil {
+		weekdays = []int{}
+	}
+	query := `
+		INSERT INTO pricing_rule (room_id, coworking_id, kind, name, weekdays, opens_at, closes_at,
+		                          rate_percent, min_minutes, discount_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + pricingRuleColumns
+	rule, err := scanPricingRule(tx.QueryRowContext(ctx, query, roomID, coworkingID, req.Kind, req.Name,
+		pq.Array(weekdays), req.OpensAt, req.ClosesAt, req.RatePercent, req.MinMinutes, req.DiscountPercent))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23514": // check_violation
+				return nil, fmt.Errorf("invalid pricing rule (%s): %w", pqErr.Constraint, ErrConflict)
+			case "23503": // foreign_key_violation
+				if roomID != nil {
+					return nil, fmt.Errorf("room with id %d %w", *roomID, ErrNotFound)
+				}
+				return nil, fmt.Errorf("coworking with id %d %w", *coworkingID, ErrNotFound)
+			}
+		}
+		return nil, fmt.Errorf("failed to create pricing rule: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rule, nil
+}
+
+// DeletePricingRule удаляет правило цены
+func (db *DB) DeletePricingRule(ctx context.Context, ruleID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM pricing_rule WHERE pricing_rule_id = $1`, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete pricing rule: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete pricing rule: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("pricing rule with id %d %w", ruleID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetPricingRuleCoworkingID возвращает коворкинг правила цены (для правила комнаты — коворкинг комнаты)
+func (db *DB) GetPricingRuleCoworkingID(ctx context.Context, ruleID int) (int, error) {
+	var coworkingID int
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(pr.coworking_id, r.coworking_id)
+		FROM pricing_rule pr
+		LEFT JOIN room r ON pr.room_id = r.room_id
+		WHERE pr.pricing_rule_id = $1
+	`, ruleID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("pricing rule with id %d %w", ruleID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get pricing rule: %w", err)
+	}
+	return coworkingID, nil
+}
+
+// GetBookingPriceLines возвращает расчёт стоимости бронирования
+func (db *DB) GetBookingPriceLines(ctx context.Context, bookingID int) ([]models.PriceLine, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT kind, description, minutes, hourly_rate, amount
+		FROM booking_price_line
+		WHERE booking_id = $1
+		ORDER BY position
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []models.PriceLine
+	for rows.Next() {
+		var l models.PriceLine
+		if err := rows.Scan(&l.Kind, &l.Description, &l.Minutes, &l.HourlyRate, &l.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan price line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read price lines: %w", err)
+	}
+	return lines, nil
+}
+
+// bookingPrice рассчитывает стоимость бронирования комнаты по её тарифу
+// и возвращает часовой пояс коворкинга
+func bookingPrice(ctx context.Context, q querier, roomID int, startsAt, endsAt time.Time) (pricing.Quote, string, error) {
+	tariffs, err := loadTariffs(ctx, q, []int{roomID})
+	if err != nil {
+		return pricing.Quote{}, "", err
+	}
+	t, ok := tariffs[roomID]
+	if !ok {
+		return pricing.Quote{}, "", fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+	}
+	return t.Quote(startsAt, endsAt), t.Location.String(), nil
+}
+
+// savePriceLines заменяет расчёт стоимости бронирования
+func savePriceLines(ctx context.Context, tx *sql.Tx, bookingID int, lines []pricing.Line) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM booking_price_line WHERE booking_id = $1`, bookingID); err != nil {
+		return fmt.Errorf("failed to clear price lines: %w", err)
+	}
+	for i, l := range lines {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO booking_price_line (booking_id, position, kind, description, minutes, hourly_rate, amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, bookingID, i+1, l.Kind, l.Description, l.Minutes, l.HourlyRate, l.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to save price line: %w", err)
+		}
+	}
+	return nil
+}
+
+// priceLines переводит строки расчёта в модель ответа
+func priceLines(lines []pricing.Line) []models.PriceLine {
+	result := make([]models.PriceLine, len(lines))
+	for i, l := range lines {
+		result[i] = models.PriceLine{
+			Kind: l.Kind, Description: l.Description, Minutes: l.Minutes, HourlyRate: l.HourlyRate, Amount: l.Amount,
+		}
+	}
+	return result
+}
+
+// loadTariffs загружает тарифы комнат roomIDs: ставку, часовой пояс и правила цены —
+// правила комнаты, а для видов, которых у комнаты нет, правила её коворкинга
+func loadTariffs(ctx context.Context, q querier, roomIDs []int) (map[int]*pricing.Tariff, error) {
+	tariffs := make(map[int]*pricing.Tariff, len(roomIDs))
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.room_id, r.hourly_rate, c.time_zone
+		FROM room r
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE r.room_id = ANY($1)
+	`, pq.Array(roomIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room rates: %w", err)
+	}
+	for rows.Next() {
+		var roomID int
+		var rate money.Money
+		var zone string
+		if err := rows.Scan(&roomID, &rate, &zone); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan room rate: %w", err)
+		}
+		loc, err := localtime.Location(zone)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tariffs[roomID] = pricing.New(rate, loc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read room rates: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT r.room_id, pr.kind, pr.name, pr.weekdays, pr.opens_at::text, pr.closes_at::text,
+		       pr.rate_percent, pr.min_minutes, pr.discount_percent
+		FROM room r
+		JOIN pricing_rule pr ON pr.room_id = r.room_id
+			OR (pr.coworking_id = r.coworking_id
+				AND NOT EXISTS (SELECT 1 FROM pricing_rule own WHERE own.room_id = r.room_id AND own.kind = pr.kind))
+		WHERE r.room_id = ANY($1)
+		ORDER BY pr.pricing_rule_id
+	`, pq.Array(roomIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing rules: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roomID int
+		var kind, name string
+		var weekdays pq.Int64Array
+		var opens, closes *string
+		var ratePercent, minMinutes, discountPercent *int64
+		if err := rows.Scan(&roomID, &kind, &name, &weekdays, &opens, &closes,
+			&ratePercent, &minMinutes, &discountPercent); err != nil {
+			return nil, fmt.Errorf("failed to scan pricing rule: %w", err)
+		}
+		t := tariffs[roomID]
+		switch kind {
+		case models.PricingTimeWindow:
+			w, err := schedule.ParseWindow(*opens, *closes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pricing window of room %d: %w", roomID, err)
+			}
+			days := make([]int, len(weekdays))
+			for i, d := range weekdays {
+				days[i] = int(d)
+			}
+			t.Windows = append(t.Windows, pricing.Window{
+				Name: name, Weekdays: days, Opens: w.Opens, Closes: w.Closes, Percent: *ratePercent,
+			})
+		case models.PricingWeekend:
+			if t.WeekendPercent == 0 {
+				t.WeekendName, t.WeekendPercent = name, *ratePercent
+			}
+		case models.PricingMinDuration:
+			if d := time.Duration(*minMinutes) * time.Minute; d > t.MinDuration {
+				t.MinDuration = d
+			}
+		case models.PricingDurationDiscount:
+			t.Discounts = append(t.Discounts, pricing.Discount{
+				Name: name, MinDuration: time.Duration(*minMinutes) * time.Minute, Percent: *discountPercent,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pricing rules: %w", err)
+	}
+	return tariffs, nil
+}
+
+func scanPricingRule(row rowScanner) (*models.PricingRule, error) {
+	var r models.PricingRule
+	var weekdays pq.Int64Array
+	err := row.Scan(&r.PricingRuleID, &r.CoworkingID, &r.RoomID, &r.Kind, &r.Name, &weekdays,
+		&r.OpensAt, &r.ClosesAt, &r.RatePercent, &r.MinMinutes, &r.DiscountPercent)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range weekdays {
+		r.Weekdays = append(r.Weekdays, int(d))
+	}
+	return &r, nil
+}
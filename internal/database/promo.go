@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/pricing"
+
+	"github.com/lib/pq"
+)
+
+// promoCodeColumns — колонки промокода в порядке scanPromoCode (без числа погашений)
+const promoCodeColumns = `
+	promo_code_id, code, description, discount_percent, discount_amount, valid_from, valid_until,
+	max_redemptions, max_per_user, coworking_id, room_id, is_active, created_by, created_at
+`
+
+// NormalizePromoCode приводит введённый код к виду, в котором он хранится
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// CreatePromoCode создаёт промокод от имени пользователя userID
+func (db *DB) CreatePromoCode(ctx context.Context, userID int, req models.CreatePromoCodeRequest) (*models.PromoCode, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	query := `
+		INSERT INTO promo_code (code, description, discount_percent, discount_amount, valid_from, valid_until,
+		                        max_redemptions, max_per_user, coworking_id, room_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + promoCodeColumns
+	promo, err := scanPromoCode(tx.QueryRowContext(ctx, query, NormalizePromoCode(req.Code), req.Description,
+		req.DiscountPercent, req.DiscountAmount, validFrom, req.ValidUntil, req.MaxRedemptions, req.MaxPerUser,
+		req.CoworkingID, req.RoomID, userID))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505": // unique_violation
+				return nil, fmt.Errorf("promo code %s already exists: %w", NormalizePromoCode(req.Code), ErrConflict)
+			case "23514": // check_violation
+				return nil, fmt.Errorf("invalid promo code (%s): %w", pqErr.Constraint, ErrConflict)
+			case "23503": // foreign_key_violation
+				return nil, fmt.Errorf("coworking or room of promo code %w", ErrNotFound)
+			}
+		}
+		return nil, fmt.Errorf("failed to create promo code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return promo, nil
+}
+
+// GetPromoCodes возвращает промокоды с числом погашений.
+// coworkingIDs ограничивает список кодами этих коворкингов и их комнат; nil — все, включая общие
+func (db *DB) GetPromoCodes(ctx context.Context, coworkingIDs []int) ([]models.PromoCode, error) {
+	query := `
+		SELECT ` + promoCodeColumns + `,
+			(SELECT COUNT(*)
+			 FROM promo_redemption pr
+			 JOIN booking b ON pr.booking_id = b.booking_id
+			 WHERE pr.promo_code_id = pc.promo_code_id AND b.status <> 'cancelled')
+		FROM promo_code pc
+		WHERE $1::int[] IS NULL
+			OR pc.coworking_id = ANY($1)
+			OR pc.room_id IN (SELECT room_id FROM room WHERE coworking_id = ANY($1))
+		ORDER BY pc.created_at DESC, pc.promo_code_id DESC
+	`
+	rows, err := db.QueryContext(ctx, query, pq.Array(coworkingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	var promos []models.PromoCode
+	for rows.Next() {
+		var redemptions int
+		promo, err := scanPromoCode(rows, &redemptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan promo code: %w", err)
+		}
+		promo.Redemptions = redemptions
+		promos = append(promos, *promo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read promo codes: %w", err)
+	}
+	return promos, nil
+}
+
+// DeactivatePromoCode отключает промокод; выданные скидки сохраняются
+func (db *DB) DeactivatePromoCode(ctx context.Context, promoCodeID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE promo_code SET is_active = FALSE WHERE promo_code_id = $1`, promoCodeID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate promo code: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to deactivate promo code: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("promo code with id %d %w", promoCodeID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetPromoCodeCoworkingID возвращает коворкинг, которым ограничен промокод
+// (для кода комнаты — коворкинг комнаты); nil — общий промокод
+func (db *DB) GetPromoCodeCoworkingID(ctx context.Context, promoCodeID int) (*int, error) {
+	var coworkingID *int
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(pc.coworking_id, r.coworking_id)
+		FROM promo_code pc
+		LEFT JOIN room r ON pc.room_id = r.room_id
+		WHERE pc.promo_code_id = $1
+	`, promoCodeID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promo code with id %d %w", promoCodeID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	return coworkingID, nil
+}
+
+// promoDiscount — промокод, применяемый к расчёту цены бронирования
+type promoDiscount struct {
+	promoCodeID int
+	code        string
+	percent     *int64
+	amount      *money.Money
+	coworkingID *int
+	roomID      *int
+}
+
+// apply добавляет к расчёту строку скидки по промокоду
+func (p *promoDiscount) apply(quote pricing.Quote) (pricing.Quote, money.Money) {
+	var percent int64
+	fixed := money.Zero()
+	if p.percent != nil {
+		percent = *p.percent
+	}
+	if p.amount != nil {
+		fixed = *p.amount
+	}
+	return quote.WithPromo("Промокод "+p.code, percent, fixed)
+}
+
+// checkRoom проверяет, что промокод действует для комнаты roomID
+func (p *promoDiscount) checkRoom(ctx context.Context, q queryRower, roomID int) error {
+	if p.roomID != nil && *p.roomID != roomID {
+		return fmt.Errorf("promo code %s is valid only for room %d: %w", p.code, *p.roomID, ErrPromoCodeInvalid)
+	}
+	if p.coworkingID == nil {
+		return nil
+	}
+	var coworkingID int
+	if err := q.QueryRowContext(ctx, `SELECT coworking_id FROM room WHERE room_id = $1`, roomID).Scan(&coworkingID); err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return fmt.Errorf("failed to get room: %w", err)
+	}
+	if coworkingID != *p.coworkingID {
+		return fmt.Errorf("promo code %s is valid only in coworking %d: %w", p.code, *p.coworkingID, ErrPromoCodeInvalid)
+	}
+	return nil
+}
+
+// lockPromoCode находит промокод и проверяет, что пользователь userID может погасить его
+// для комнаты roomID. Строка промокода блокируется FOR UPDATE до конца транзакции, поэтому
+// одновременные погашения одного кода выполняются по очереди и лимиты не превышаются
+func lockPromoCode(ctx context.Context, tx *sql.Tx, code string, roomID, userID int) (*promoDiscount, error) {
+	code = NormalizePromoCode(code)
+	p := promoDiscount{code: code}
+	var validFrom time.Time
+	var validUntil *time.Time
+	var maxRedemptions, maxPerUser *int
+	var active bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT promo_code_id, discount_percent, discount_amount, valid_from, valid_until,
+		       max_redemptions, max_per_user, coworking_id, room_id, is_active
+		FROM promo_code
+		WHERE code = $1
+		FOR UPDATE
+	`, code).Scan(&p.promoCodeID, &p.percent, &p.amount, &validFrom, &validUntil,
+		&maxRedemptions, &maxPerUser, &p.coworkingID, &p.roomID, &active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("promo code %s not found: %w", code, ErrPromoCodeInvalid)
+		}
+		return nil, fmt.Errorf("failed to get promo code: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case !active:
+		return nil, fmt.Errorf("promo code %s is deactivated: %w", code, ErrPromoCodeInvalid)
+	case now.Before(validFrom):
+		return nil, fmt.Errorf("promo code %s is not valid yet: %w", code, ErrPromoCodeInvalid)
+	case validUntil != nil && !now.Before(*validUntil):
+		return nil, fmt.Errorf("promo code %s has expired: %w", code, ErrPromoCodeInvalid)
+	}
+	if err := p.checkRoom(ctx, tx, roomID); err != nil {
+		return nil, err
+	}
+
+	// Погашения отменённых бронирований возвращаются в лимит
+	var total, byUser int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE pr.user_id = $2)
+		FROM promo_redemption pr
+		JOIN booking b ON pr.booking_id = b.booking_id
+		WHERE pr.promo_code_id = $1 AND b.status <> 'cancelled'
+	`, p.promoCodeID, userID).Scan(&total, &byUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count promo code redemptions: %w", err)
+	}
+	if maxRedemptions != nil && total >= *maxRedemptions {
+		return nil, fmt.Errorf("promo code %s has been fully redeemed: %w", code, ErrPromoCodeInvalid)
+	}
+	if maxPerUser != nil && byUser >= *maxPerUser {
+		return nil, fmt.Errorf("promo code %s redemption limit per user reached: %w", code, ErrPromoCodeInvalid)
+	}
+	return &p, nil
+}
+
+// bookingPromo возвращает промокод, погашенный для бронирования, или nil
+func bookingPromo(ctx context.Context, tx *sql.Tx, bookingID int) (*promoDiscount, error) {
+	var p promoDiscount
+	err := tx.QueryRowContext(ctx, `
+		SELECT pc.promo_code_id, pc.code, pc.discount_percent, pc.discount_amount, pc.coworking_id, pc.room_id
+		FROM promo_redemption pr
+		JOIN promo_code pc ON pr.promo_code_id = pc.promo_code_id
+		WHERE pr.booking_id = $1
+		FOR UPDATE OF pr
+	`, bookingID).Scan(&p.promoCodeID, &p.code, &p.percent, &p.amount, &p.coworkingID, &p.roomID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo redemption: %w", err)
+	}
+	return &p, nil
+}
+
+// saveRedemption записывает погашение промокода или обновляет скидку при пересчёте
+func saveRedemption(ctx context.Context, tx *sql.Tx, p *promoDiscount, bookingID, userID int, discount money.Money) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO promo_redemption (promo_code_id, booking_id, user_id, amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (booking_id) DO UPDATE SET amount = EXCLUDED.amount
+	`, p.promoCodeID, bookingID, userID, discount)
+	if err != nil {
+		return fmt.Errorf("failed to record promo redemption: %w", err)
+	}
+	return nil
+}
+
+// scanPromoCode читает колонки promoCodeColumns; extra — приёмники дополнительных колонок после них
+func scanPromoCode(row rowScanner, extra ...any) (*models.PromoCode, error) {
+	var p models.PromoCode
+	dest := []any{&p.PromoCodeID, &p.Code, &p.Description, &p.DiscountPercent, &p.DiscountAmount,
+		&p.ValidFrom, &p.ValidUntil, &p.MaxRedemptions, &p.MaxPerUser, &p.CoworkingID, &p.RoomID,
+		&p.IsActive, &p.CreatedBy, &p.CreatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
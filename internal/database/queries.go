@@ -1,74 +1,125 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
+	"coworking-booking/internal/localtime"
 	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/schedule"
 
 	"github.com/lib/pq"
 )
 
 // CreateUser создаёт нового пользователя
-func (db *DB) CreateUser(email, passwordHash, fullName, role string) (*models.User, error) {
+func (db *DB) CreateUser(ctx context.Context, email, passwordHash, fullName, role string) (*models.User, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO "user" (email, password_hash, full_name, role)
 		VALUES ($1, $2, $3, $4)
 		RETURNING user_id, email, full_name, role, created_at
 	`
 	var user models.User
-	err := db.QueryRow(query, email, passwordHash, fullName, role).Scan(
+	err = tx.QueryRowContext(ctx, query, email, passwordHash, fullName, role).Scan(
 		&user.UserID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt,
 	)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" { // unique_violation
+			return nil, fmt.Errorf("email %s already registered: %w", email, ErrConflict)
+		}
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &user, nil
+}
+
+// GetUserByID получает пользователя по идентификатору
+func (db *DB) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
+	query := `
+		SELECT user_id, email, full_name, role, created_at
+		FROM "user"
+		WHERE user_id = $1
+	`
+	var user models.User
+	err := db.QueryRowContext(ctx, query, userID).Scan(
+		&user.UserID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user with id %d %w", userID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
 	return &user, nil
 }
 
 // GetUserByEmail получает пользователя по email
-func (db *DB) GetUserByEmail(email string) (*models.User, error) {
+func (db *DB) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
 		SELECT user_id, email, password_hash, full_name, role, created_at
 		FROM "user"
 		WHERE email = $1
 	`
 	var user models.User
-	err := db.QueryRow(query, email).Scan(
+	err := db.QueryRowContext(ctx, query, email).Scan(
 		&user.UserID, &user.Email, &user.PasswordHash, &user.FullName, &user.Role, &user.CreatedAt,
 	)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user %w", ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 	return &user, nil
 }
 
-// CreateCoworking создаёт новый коворкинг
-func (db *DB) CreateCoworking(name, address string, description *string) (*models.Coworking, error) {
+// CreateCoworking создаёт новый коворкинг в часовом поясе timeZone (IANA)
+func (db *DB) CreateCoworking(ctx context.Context, name, address string, description *string, timeZone string) (*models.Coworking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO coworking (name, address, description)
-		VALUES ($1, $2, $3)
-		RETURNING coworking_id, name, address, description, created_at
+		INSERT INTO coworking (name, address, description, time_zone)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + coworkingColumns + `
 	`
 	var c models.Coworking
-	err := db.QueryRow(query, name, address, description).Scan(
-		&c.CoworkingID, &c.Name, &c.Address, &c.Description, &c.CreatedAt,
-	)
+	err = scanCoworking(tx.QueryRowContext(ctx, query, name, address, description, timeZone), &c)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create coworking: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return &c, nil
 }
 
-// GetAllCoworkings возвращает список всех коворкингов
-func (db *DB) GetAllCoworkings() ([]models.Coworking, error) {
+// GetAllCoworkings возвращает список коворкингов; архивные — только при includeArchived
+func (db *DB) GetAllCoworkings(ctx context.Context, includeArchived bool) ([]models.Coworking, error) {
 	query := `
-		SELECT coworking_id, name, address, description, created_at
+		SELECT ` + coworkingColumns + `
 		FROM coworking
+		WHERE $1 OR archived_at IS NULL
 		ORDER BY name
 	`
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query, includeArchived)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get coworkings: %w", err)
 	}
@@ -77,42 +128,76 @@ func (db *DB) GetAllCoworkings() ([]models.Coworking, error) {
 	var coworkings []models.Coworking
 	for rows.Next() {
 		var c models.Coworking
-		if err := rows.Scan(&c.CoworkingID, &c.Name, &c.Address, &c.Description, &c.CreatedAt); err != nil {
+		if err := scanCoworking(rows, &c); err != nil {
 			return nil, fmt.Errorf("failed to scan coworking: %w", err)
 		}
 		coworkings = append(coworkings, c)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read coworkings: %w", err)
+	}
 	return coworkings, nil
 }
 
-// CreateRoom создаёт новую комнату
-func (db *DB) CreateRoom(coworkingID int, name string, capacity int, areaSqm *float64, hourlyRate float64) (*models.Room, error) {
+// CreateRoom создаёт новую комнату; в архивном коворкинге — ErrConflict
+func (db *DB) CreateRoom(ctx context.Context, coworkingID int, name string, capacity int, areaSqm *float64, hourlyRate money.Money) (*models.Room, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var archivedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT archived_at FROM coworking WHERE coworking_id = $1 FOR SHARE
+	`, coworkingID).Scan(&archivedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get coworking: %w", err)
+	}
+	if archivedAt.Valid {
+		return nil, fmt.Errorf("coworking %d is archived; restore it before adding rooms: %w", coworkingID, ErrConflict)
+	}
+
 	query := `
 		INSERT INTO room (coworking_id, name, capacity, area_sqm, hourly_rate)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING room_id, coworking_id, name, capacity, area_sqm, hourly_rate, created_at
+		RETURNING room_id, coworking_id, name, capacity, area_sqm, hourly_rate, created_at,
+		          setup_minutes, teardown_minutes, archived_at
 	`
 	var r models.Room
-	err := db.QueryRow(query, coworkingID, name, capacity, areaSqm, hourlyRate).Scan(
+	err = tx.QueryRowContext(ctx, query, coworkingID, name, capacity, areaSqm, hourlyRate).Scan(
 		&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+		&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt,
 	)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to create room: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return &r, nil
 }
 
-// GetRoomsByCoworking возвращает список комнат в коворкинге
-func (db *DB) GetRoomsByCoworking(coworkingID int) ([]models.Room, error) {
+// GetRoomsByCoworking возвращает список комнат в коворкинге; архивные комнаты и комнаты
+// архивного коворкинга — только при includeArchived
+func (db *DB) GetRoomsByCoworking(ctx context.Context, coworkingID int, includeArchived bool) ([]models.Room, error) {
 	query := `
 		SELECT r.room_id, r.coworking_id, r.name, r.capacity, r.area_sqm, r.hourly_rate, r.created_at,
-		       c.name AS coworking_name
+		       r.setup_minutes, r.teardown_minutes, r.archived_at, c.name AS coworking_name, c.time_zone
 		FROM room r
 		JOIN coworking c ON r.coworking_id = c.coworking_id
 		WHERE r.coworking_id = $1
+		  AND ($2 OR (r.archived_at IS NULL AND c.archived_at IS NULL))
 		ORDER BY r.name
 	`
-	rows, err := db.Query(query, coworkingID)
+	rows, err := db.QueryContext(ctx, query, coworkingID, includeArchived)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rooms: %w", err)
 	}
@@ -121,16 +206,26 @@ func (db *DB) GetRoomsByCoworking(coworkingID int) ([]models.Room, error) {
 	var rooms []models.Room
 	for rows.Next() {
 		var r models.Room
-		if err := rows.Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt, &r.CoworkingName); err != nil {
+		if err := rows.Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+			&r.SetupMinutes, &r.TeardownMinutes, &r.ArchivedAt, &r.CoworkingName, &r.TimeZone); err != nil {
 			return nil, fmt.Errorf("failed to scan room: %w", err)
 		}
 		rooms = append(rooms, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rooms: %w", err)
+	}
 	return rooms, nil
 }
 
 // CreateEquipment создаёт новый тип оборудования
-func (db *DB) CreateEquipment(name string, description *string) (*models.Equipment, error) {
+func (db *DB) CreateEquipment(ctx context.Context, name string, description *string) (*models.Equipment, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO equipment (name, description)
 		VALUES ($1, $2)
@@ -138,29 +233,60 @@ func (db *DB) CreateEquipment(name string, description *string) (*models.Equipme
 		RETURNING equipment_id, name, description
 	`
 	var e models.Equipment
-	err := db.QueryRow(query, name, description).Scan(&e.EquipmentID, &e.Name, &e.Description)
+	err = tx.QueryRowContext(ctx, query, name, description).Scan(&e.EquipmentID, &e.Name, &e.Description)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create equipment: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return &e, nil
 }
 
 // AddEquipmentToRoom добавляет оборудование к комнате
-func (db *DB) AddEquipmentToRoom(roomID, equipmentID int) error {
+func (db *DB) AddEquipmentToRoom(ctx context.Context, roomID, equipmentID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		INSERT INTO room_equipment (room_id, equipment_id)
 		VALUES ($1, $2)
 		ON CONFLICT DO NOTHING
 	`
-	_, err := db.Exec(query, roomID, equipmentID)
+	_, err = tx.ExecContext(ctx, query, roomID, equipmentID)
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" { // foreign_key_violation
+			return fmt.Errorf("room %d or equipment %d %w", roomID, equipmentID, ErrNotFound)
+		}
 		return fmt.Errorf("failed to add equipment to room: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
-// SearchAvailableRooms ищет свободные комнаты с учётом параметров
-func (db *DB) SearchAvailableRooms(params models.SearchRoomParams) ([]models.Room, error) {
+// SearchAvailableRooms ищет свободные комнаты с учётом параметров; архивные комнаты
+// и комнаты архивных коворкингов не возвращаются.
+// Комната занята, если интервал вместе с её буферами подготовки и уборки пересекается
+// с другим бронированием (как в booking_no_overlap).
+// Комнаты, закрытые в какой-либо части интервала (часы работы, праздники, блокировки), не возвращаются.
+// Оборудование можно задать идентификаторами и названиями; неизвестное название — ErrNotFound.
+// Результаты упорядочены по params.Sort (см. SortRooms)
+func (db *DB) SearchAvailableRooms(ctx context.Context, params models.SearchRoomParams) ([]models.Room, error) {
+	if err := ValidateRoomSort(params.Sort); err != nil {
+		return nil, err
+	}
+	equipment, err := resolveEquipment(ctx, db, params.EquipmentIDs, params.EquipmentNames)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		WITH required_equipment AS (
 			SELECT unnest($3::int[]) AS equipment_id
@@ -170,45 +296,61 @@ func (db *DB) SearchAvailableRooms(params models.SearchRoomParams) ([]models.Roo
 			FROM room_equipment re
 			WHERE re.equipment_id IN (SELECT equipment_id FROM required_equipment)
 			GROUP BY re.room_id
-			HAVING COUNT(DISTINCT re.equipment_id) = (SELECT COUNT(*) FROM required_equipment)
+			HAVING COUNT(DISTINCT re.equipment_id) = (SELECT COUNT(DISTINCT equipment_id) FROM required_equipment)
 		),
 		occupied_rooms AS (
-			SELECT DISTINCT room_id
-			FROM booking
-			WHERE status IN ('pending', 'confirmed')
-			  AND tsrange(starts_at, ends_at) && tsrange($1, $2)
+			SELECT DISTINCT b.room_id
+			FROM booking b
+			JOIN room r ON b.room_id = r.room_id
+			WHERE b.status IN ('pending', 'confirmed')
+			  AND b.blocked_range && tstzrange(
+				$1::timestamptz - make_interval(mins => r.setup_minutes),
+				$2::timestamptz + make_interval(mins => r.teardown_minutes))
+		),
+		blacked_out_rooms AS (
+			SELECT r.room_id
+			FROM room r
+			JOIN blackout bl ON bl.room_id = r.room_id OR bl.coworking_id = r.coworking_id
+			WHERE tstzrange(bl.starts_at, bl.ends_at) && tstzrange($1, $2)
 		)
 		SELECT
 			r.room_id,
+			r.coworking_id,
 			r.name,
 			r.capacity,
 			r.area_sqm,
 			r.hourly_rate,
 			r.created_at,
+			r.setup_minutes,
+			r.teardown_minutes,
 			c.name AS coworking_name,
 			c.address AS coworking_address,
-			COALESCE(ARRAY_AGG(e.name ORDER BY e.name) FILTER (WHERE e.name IS NOT NULL), ARRAY[]::VARCHAR[]) AS equipment_list
+			c.time_zone,
+			COALESCE(ARRAY_AGG(e.name ORDER BY e.name) FILTER (WHERE e.name IS NOT NULL), ARRAY[]::VARCHAR[]) AS equipment_list,
+			COALESCE(ARRAY_AGG(e.equipment_id ORDER BY e.name) FILTER (WHERE e.name IS NOT NULL), ARRAY[]::INT[]) AS equipment_ids
 		FROM room r
 		JOIN coworking c ON r.coworking_id = c.coworking_id
 		LEFT JOIN room_equipment re ON r.room_id = re.room_id
 		LEFT JOIN equipment e ON re.equipment_id = e.equipment_id
-		WHERE r.room_id NOT IN (SELECT room_id FROM occupied_rooms)
+		WHERE r.archived_at IS NULL
+		  AND c.archived_at IS NULL
+		  AND r.room_id NOT IN (SELECT room_id FROM occupied_rooms)
+		  AND r.room_id NOT IN (SELECT room_id FROM blacked_out_rooms)
 		  AND (
 			COALESCE(CARDINALITY($3::int[]), 0) = 0
 			OR r.room_id IN (SELECT room_id FROM rooms_with_equipment)
 		  )
 		  AND ($4::int IS NULL OR r.capacity >= $4)
 		  AND ($5::numeric IS NULL OR r.hourly_rate <= $5)
-		GROUP BY r.room_id, r.name, r.capacity, r.area_sqm, r.hourly_rate, r.created_at, c.name, c.address
-		ORDER BY r.hourly_rate
+		  AND ($6::int IS NULL OR r.coworking_id = $6)
+		  AND ($7::text IS NULL OR lower(c.city) = lower($7))
+		GROUP BY r.room_id, r.coworking_id, r.name, r.capacity, r.area_sqm, r.hourly_rate, r.created_at,
+		         r.setup_minutes, r.teardown_minutes, c.name, c.address, c.time_zone
+		ORDER BY r.hourly_rate, r.room_id
 	`
 
-	equipmentIDs := pq.Array(params.EquipmentIDs)
-	if len(params.EquipmentIDs) == 0 {
-		equipmentIDs = pq.Array([]int{})
-	}
-
-	rows, err := db.Query(query, params.StartsAt, params.EndsAt, equipmentIDs, params.MinCapacity, params.MaxRate)
+	rows, err := db.QueryContext(ctx, query, params.StartsAt, params.EndsAt, pq.Array(equipment),
+		params.MinCapacity, params.MaxRate, params.CoworkingID, params.City)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search rooms: %w", err)
 	}
@@ -218,209 +360,453 @@ func (db *DB) SearchAvailableRooms(params models.SearchRoomParams) ([]models.Roo
 	for rows.Next() {
 		var r models.Room
 		var equipmentList pq.StringArray
-		if err := rows.Scan(&r.RoomID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
-			&r.CoworkingName, &r.CoworkingAddress, &equipmentList); err != nil {
+		var equipmentIDs pq.Int64Array
+		if err := rows.Scan(&r.RoomID, &r.CoworkingID, &r.Name, &r.Capacity, &r.AreaSqm, &r.HourlyRate, &r.CreatedAt,
+			&r.SetupMinutes, &r.TeardownMinutes, &r.CoworkingName, &r.CoworkingAddress, &r.TimeZone,
+			&equipmentList, &equipmentIDs); err != nil {
 			return nil, fmt.Errorf("failed to scan room: %w", err)
 		}
 		r.EquipmentList = equipmentList
+		r.EquipmentIDs = ints(equipmentIDs)
 		rooms = append(rooms, r)
 	}
-	return rooms, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rooms: %w", err)
+	}
+	if len(rooms) == 0 {
+		return rooms, nil
+	}
+
+	roomIDs := make([]int, len(rooms))
+	for i, r := range rooms {
+		roomIDs[i] = r.RoomID
+	}
+	schedules, err := loadSchedules(ctx, db, roomIDs, params.StartsAt, params.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+	tariffs, err := loadTariffs(ctx, db, roomIDs)
+	if err != nil {
+		return nil, err
+	}
+	openRooms := rooms[:0]
+	for _, r := range rooms {
+		if schedules[r.RoomID].Covers(params.StartsAt, params.EndsAt) {
+			quote := tariffs[r.RoomID].Quote(params.StartsAt, params.EndsAt)
+			r.QuotedPrice = &quote.Total
+			openRooms = append(openRooms, r)
+		}
+	}
+	return openRooms, SortRooms(openRooms, params.Sort)
 }
 
-// CreateBooking создаёт новое бронирование
-func (db *DB) CreateBooking(roomID, userID int, startsAt, endsAt time.Time) (*models.Booking, error) {
+// CreateBooking создаёт новое бронирование без платежа; стоимость и её расчёт —
+// по тарифу комнаты (см. bookingPrice)
+func (db *DB) CreateBooking(ctx context.Context, roomID, userID int, startsAt, endsAt time.Time) (*models.Booking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	quote, zone, err := bookingPrice(ctx, tx, roomID, startsAt, endsAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOpeningHours(ctx, tx, roomID, startsAt, endsAt); err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO booking (room_id, user_id, starts_at, ends_at, total_amount, status)
-		SELECT $1, $2, $3, $4,
-		       r.hourly_rate * EXTRACT(EPOCH FROM ($4::timestamp - $3::timestamp)) / 3600,
-		       'pending'
-		FROM room r
-		WHERE r.room_id = $1
+		VALUES ($1, $2, $3, $4, $5, 'pending')
 		RETURNING booking_id, room_id, user_id, starts_at, ends_at, total_amount, status, created_at, updated_at
 	`
 	var b models.Booking
-	err := db.QueryRow(query, roomID, userID, startsAt, endsAt).Scan(
+	err = tx.QueryRowContext(ctx, query, roomID, userID, startsAt, endsAt, quote.Total).Scan(
 		&b.BookingID, &b.RoomID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.TotalAmount, &b.Status, &b.CreatedAt, &b.UpdatedAt,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("room with id %d not found", roomID)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23P01" { // exclusion_violation
+			return nil, fmt.Errorf("%w: %v", ErrRoomUnavailable, err)
 		}
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
+	if err := savePriceLines(ctx, tx, b.BookingID, quote.Lines); err != nil {
+		return nil, err
+	}
+	localizeBooking(&b, zone)
+	b.PriceLines = priceLines(quote.Lines)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return &b, nil
 }
 
-// CreateBookingWithPayment создаёт бронирование и платёж в одной транзакции
-func (db *DB) CreateBookingWithPayment(roomID, userID int, startsAt, endsAt time.Time, paymentMethod string) (*models.Booking, *models.Payment, error) {
-	tx, err := db.BeginTx()
+// CreateBookingWithPayment создаёт бронирование и платёж в одной транзакции;
+// при подключённом платёжном шлюзе для платежа после фиксации создаётся намерение оплаты.
+// Бесплатное бронирование подтверждается сразу и возвращается без платежа (nil).
+// Непустой promoCode погашается в той же транзакции и уменьшает стоимость (ErrPromoCodeInvalid, если код не подходит)
+func (db *DB) CreateBookingWithPayment(ctx context.Context, roomID, userID int, startsAt, endsAt time.Time, paymentMethod, promoCode string) (*models.Booking, *models.Payment, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	booking, payment, err := createBookingWithPaymentTx(ctx, tx, roomID, userID, startsAt, endsAt, paymentMethod, nil, promoCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Бронирование уже создано: если шлюз не ответил, намерение позже создаст CreatePaymentIntents
+	_ = db.attachIntent(ctx, payment)
+	return booking, payment, nil
+}
+
+// createBookingWithPaymentTx создаёт бронирование (при seriesID — вхождение серии) и платёж в транзакции tx;
+// непустой promoCode погашается для этого бронирования.
+// Бесплатное бронирование (нулевая ставка, промокод на всю сумму) сразу подтверждается:
+// платёж и счёт на ноль не выставляются, возвращаемый платёж — nil
+func createBookingWithPaymentTx(ctx context.Context, tx *sql.Tx, roomID, userID int, startsAt, endsAt time.Time, paymentMethod string, seriesID *int, promoCode string) (*models.Booking, *models.Payment, error) {
+	quote, zone, err := bookingPrice(ctx, tx, roomID, startsAt, endsAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkOpeningHours(ctx, tx, roomID, startsAt, endsAt); err != nil {
+		return nil, nil, err
+	}
+
+	var promo *promoDiscount
+	var discount money.Money
+	if promoCode != "" {
+		if promo, err = lockPromoCode(ctx, tx, promoCode, roomID, userID); err != nil {
+			return nil, nil, err
+		}
+		quote, discount = promo.apply(quote)
+	}
+
+	status := "pending"
+	if quote.Total.IsZero() {
+		status = "confirmed"
+	}
+
 	// Создание бронирования
 	bookingQuery := `
-		INSERT INTO booking (room_id, user_id, starts_at, ends_at, total_amount, status)
-		SELECT $1, $2, $3, $4,
-		       r.hourly_rate * EXTRACT(EPOCH FROM ($4::timestamp - $3::timestamp)) / 3600,
-		       'pending'
-		FROM room r
-		WHERE r.room_id = $1
-		RETURNING booking_id, room_id, user_id, starts_at, ends_at, total_amount, status, created_at, updated_at
+		INSERT INTO booking (room_id, user_id, starts_at, ends_at, total_amount, status, series_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING booking_id, room_id, user_id, starts_at, ends_at, total_amount, status, created_at, updated_at, series_id
 	`
 	var booking models.Booking
-	err = tx.QueryRow(bookingQuery, roomID, userID, startsAt, endsAt).Scan(
+	err = tx.QueryRowContext(ctx, bookingQuery, roomID, userID, startsAt, endsAt, quote.Total, status, seriesID).Scan(
 		&booking.BookingID, &booking.RoomID, &booking.UserID, &booking.StartsAt, &booking.EndsAt,
-		&booking.TotalAmount, &booking.Status, &booking.CreatedAt, &booking.UpdatedAt,
+		&booking.TotalAmount, &booking.Status, &booking.CreatedAt, &booking.UpdatedAt, &booking.SeriesID,
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil, fmt.Errorf("room with id %d not found", roomID)
-		}
 		// Проверка на EXCLUDE constraint (пересечение бронирований)
 		if pqErr, ok := err.(*pq.Error); ok {
 			if pqErr.Code == "23P01" { // exclusion_violation
-				return nil, nil, fmt.Errorf("комната занята в выбранное время: %w", err)
+				return nil, nil, fmt.Errorf("%w: %v", ErrRoomUnavailable, err)
 			}
 		}
 		return nil, nil, fmt.Errorf("failed to create booking: %w", err)
 	}
+	if err := savePriceLines(ctx, tx, booking.BookingID, quote.Lines); err != nil {
+		return nil, nil, err
+	}
+	if promo != nil {
+		if err := saveRedemption(ctx, tx, promo, booking.BookingID, userID, discount); err != nil {
+			return nil, nil, err
+		}
+	}
+	localizeBooking(&booking, zone)
+	booking.PriceLines = priceLines(quote.Lines)
+	if booking.Status == "confirmed" {
+		return &booking, nil, nil
+	}
 
-	// Создание платежа
-	paymentQuery := `
-		INSERT INTO payment (booking_id, amount, status, payment_method)
-		VALUES ($1, $2, 'pending', $3)
-		RETURNING payment_id, booking_id, amount, status, payment_method, paid_at, created_at
-	`
-	var payment models.Payment
-	err = tx.QueryRow(paymentQuery, booking.BookingID, booking.TotalAmount, paymentMethod).Scan(
-		&payment.PaymentID, &payment.BookingID, &payment.Amount, &payment.Status,
-		&payment.PaymentMethod, &payment.PaidAt, &payment.CreatedAt,
-	)
+	payment, err := insertPaymentTx(ctx, tx, booking.BookingID, booking.TotalAmount, paymentMethod)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create payment: %w", err)
+		return nil, nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	return &booking, payment, nil
+}
+
+// GetRoomTimeZone возвращает часовой пояс коворкинга, в котором находится комната
+func (db *DB) GetRoomTimeZone(ctx context.Context, roomID int) (string, error) {
+	return roomTimeZone(ctx, db, roomID)
+}
+
+func roomTimeZone(ctx context.Context, q queryRower, roomID int) (string, error) {
+	var zone string
+	err := q.QueryRowContext(ctx, `
+		SELECT c.time_zone
+		FROM room r
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE r.room_id = $1
+	`, roomID).Scan(&zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to get room time zone: %w", err)
+	}
+	return zone, nil
+}
+
+// GetCoworkingTimeZone возвращает часовой пояс коворкинга
+func (db *DB) GetCoworkingTimeZone(ctx context.Context, coworkingID int) (string, error) {
+	var zone string
+	err := db.QueryRowContext(ctx, `SELECT time_zone FROM coworking WHERE coworking_id = $1`, coworkingID).Scan(&zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to get coworking time zone: %w", err)
+	}
+	return zone, nil
+}
+
+// GetBookingTimeZone возвращает часовой пояс коворкинга, к которому относится бронирование
+func (db *DB) GetBookingTimeZone(ctx context.Context, bookingID int) (string, error) {
+	var zone string
+	err := db.QueryRowContext(ctx, `
+		SELECT c.time_zone
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE b.booking_id = $1
+	`, bookingID).Scan(&zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return "", fmt.Errorf("failed to get booking time zone: %w", err)
 	}
+	return zone, nil
+}
 
-	return &booking, &payment, nil
+// localizeBooking переводит время бронирования в часовой пояс его коворкинга
+func localizeBooking(b *models.Booking, zone string) {
+	b.TimeZone = zone
+	b.StartsAt = localtime.In(b.StartsAt, zone)
+	b.EndsAt = localtime.In(b.EndsAt, zone)
 }
 
-// ConfirmPaymentAndBooking подтверждает оплату и бронирование в одной транзакции
-func (db *DB) ConfirmPaymentAndBooking(paymentID int) (*models.Payment, *models.Booking, error) {
-	tx, err := db.BeginTx()
+// ConfirmPaymentAndBooking подтверждает оплату в одной транзакции; бронирование переходит
+// в confirmed, когда его баланс покрывает стоимость. Возвращает бронирование в текущем статусе
+func (db *DB) ConfirmPaymentAndBooking(ctx context.Context, paymentID int) (*models.Payment, *models.Booking, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Обновление платежа
-	paymentQuery := `
-		UPDATE payment
-		SET status = 'paid', paid_at = NOW()
-		WHERE payment_id = $1 AND status = 'pending'
-		RETURNING payment_id, booking_id, amount, status, payment_method, paid_at, created_at
-	`
-	var payment models.Payment
-	err = tx.QueryRow(paymentQuery, paymentID).Scan(
-		&payment.PaymentID, &payment.BookingID, &payment.Amount, &payment.Status,
-		&payment.PaymentMethod, &payment.PaidAt, &payment.CreatedAt,
-	)
+	// Блокировка бронирования: одновременно подтверждённые платежи одного бронирования
+	// видят друг друга при расчёте баланса
+	var bookingID int
+	var status, paymentStatus string
+	err = tx.QueryRowContext(ctx, `
+		SELECT b.booking_id, b.status, p.status
+		FROM booking b
+		JOIN payment p ON p.booking_id = b.booking_id
+		WHERE p.payment_id = $1
+		FOR UPDATE OF b
+	`, paymentID).Scan(&bookingID, &status, &paymentStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, nil, fmt.Errorf("payment not found or already paid")
+			return nil, nil, fmt.Errorf("payment not found or already paid: %w", ErrConflict)
 		}
-		return nil, nil, fmt.Errorf("failed to update payment: %w", err)
+		return nil, nil, fmt.Errorf("failed to lock booking: %w", err)
+	}
+	if status == "cancelled" {
+		return nil, nil, fmt.Errorf("booking %d is cancelled: %w", bookingID, ErrConflict)
+	}
+	if paymentStatus != "pending" {
+		return nil, nil, fmt.Errorf("payment not found or already paid: %w", ErrConflict)
+	}
+
+	payment, _, err := confirmPaymentTx(ctx, tx, bookingID, paymentID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Обновление бронирования
-	bookingQuery := `
-		UPDATE booking
-		SET status = 'confirmed', updated_at = NOW()
-		WHERE booking_id = $1 AND status = 'pending'
-		RETURNING booking_id, room_id, user_id, starts_at, ends_at, total_amount, status, created_at, updated_at
-	`
 	var booking models.Booking
-	err = tx.QueryRow(bookingQuery, payment.BookingID).Scan(
+	var zone string
+	err = tx.QueryRowContext(ctx, `
+		SELECT b.booking_id, b.room_id, b.user_id, b.starts_at, b.ends_at, b.total_amount, b.status,
+		       b.created_at, b.updated_at, b.series_id, c.time_zone
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE b.booking_id = $1
+	`, bookingID).Scan(
 		&booking.BookingID, &booking.RoomID, &booking.UserID, &booking.StartsAt, &booking.EndsAt,
-		&booking.TotalAmount, &booking.Status, &booking.CreatedAt, &booking.UpdatedAt,
+		&booking.TotalAmount, &booking.Status, &booking.CreatedAt, &booking.UpdatedAt, &booking.SeriesID, &zone,
 	)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to update booking: %w", err)
+		return nil, nil, fmt.Errorf("failed to get booking: %w", err)
 	}
+	localizeBooking(&booking, zone)
 
 	if err := tx.Commit(); err != nil {
 		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &payment, &booking, nil
+	return payment, &booking, nil
 }
 
-// CancelBookingWithRefund отменяет бронирование и возвращает средства
-func (db *DB) CancelBookingWithRefund(bookingID, userID int) error {
-	tx, err := db.BeginTx()
+// confirmPaymentTx отмечает платёж оплаченным, выдаёт на него квитанцию и подтверждает
+// pending-бронирование, если его баланс покрывает стоимость; возвращает платёж и признак
+// подтверждения. Бронирование должно быть заблокировано вызывающим
+func confirmPaymentTx(ctx context.Context, tx *sql.Tx, bookingID, paymentID int) (*models.Payment, bool, error) {
+	payment, err := scanPayment(tx.QueryRowContext(ctx, `
+		UPDATE payment
+		SET status = 'paid', paid_at = NOW(), failure_reason = NULL
+		WHERE payment_id = $1 AND status <> 'paid'
+		RETURNING `+paymentColumns,
+		paymentID))
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		if err == sql.ErrNoRows {
+			return nil, false, fmt.Errorf("payment not found or already paid: %w", ErrConflict)
+		}
+		return nil, false, fmt.Errorf("failed to update payment: %w", err)
+	}
+	// Нулевые платежи остались от бесплатных бронирований до их подтверждения без оплаты;
+	// квитанция на ноль не выдаётся (invoice_amount_check)
+	if !payment.Amount.IsZero() {
+		if err := issueDocumentTx(ctx, tx, paymentID, DocumentReceipt); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Подтверждение бронирования, если баланс покрывает стоимость
+	err = tx.QueryRowContext(ctx, `
+		UPDATE booking b
+		SET status = 'confirmed', updated_at = NOW()
+		FROM booking_balance bb
+		WHERE b.booking_id = $1 AND b.status = 'pending'
+		  AND bb.booking_id = b.booking_id AND bb.balance >= b.total_amount
+		RETURNING b.booking_id
+	`, bookingID).Scan(&bookingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return payment, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to update booking: %w", err)
+	}
+
+	if err := recordStatusChange(ctx, tx, []int{bookingID}, "pending", "confirmed", ReasonPaymentConfirmed); err != nil {
+		return nil, false, err
+	}
+
+	// Оплаченное предложение из листа ожидания принято
+	_, err = tx.ExecContext(ctx, `
+		UPDATE waitlist_entry
+		SET status = 'accepted'
+		WHERE offered_booking_id = $1 AND status = 'offered'
+	`, bookingID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to accept waitlist offer: %w", err)
+	}
+	return payment, true, nil
+}
+
+// CancelBookingWithRefund отменяет бронирование и возвращает оплату по политике отмены
+// комнаты (см. QuoteCancellation)
+func (db *DB) CancelBookingWithRefund(ctx context.Context, bookingID, userID int) (*models.CancellationResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	result, err := cancelBookingTx(ctx, tx, bookingID, userID, ReasonCancelledByUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// cancelBookingTx отменяет бронирование пользователя и возвращает оплату по политике отмены в транзакции tx
+func cancelBookingTx(ctx context.Context, tx *sql.Tx, bookingID, userID int, reason string) (*models.CancellationResult, error) {
 	// Отмена бронирования
 	bookingQuery := `
+		WITH old AS (
+			SELECT status FROM booking WHERE booking_id = $1 FOR UPDATE
+		)
 		UPDATE booking
 		SET status = 'cancelled', updated_at = NOW()
 		WHERE booking_id = $1 AND user_id = $2 AND status IN ('pending', 'confirmed')
-		RETURNING booking_id
+		RETURNING (SELECT status FROM old)
 	`
-	var bid int
-	err = tx.QueryRow(bookingQuery, bookingID, userID).Scan(&bid)
+	var oldStatus string
+	err := tx.QueryRowContext(ctx, bookingQuery, bookingID, userID).Scan(&oldStatus)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return fmt.Errorf("booking not found or cannot be cancelled")
+			return nil, fmt.Errorf("booking not found or cannot be cancelled: %w", ErrConflict)
 		}
-		return fmt.Errorf("failed to cancel booking: %w", err)
+		return nil, fmt.Errorf("failed to cancel booking: %w", err)
 	}
 
-	// Возврат средств (если был оплачен)
-	refundQuery := `
-		UPDATE payment
-		SET status = 'refunded'
-		WHERE booking_id = $1 AND status = 'paid'
-	`
-	_, err = tx.Exec(refundQuery, bookingID)
+	// Возврат средств по оплаченным платежам; платежи по бронированию меняются только
+	// под блокировкой его строки, поэтому баланс не изменится до конца транзакции
+	quote, err := cancellationQuote(ctx, tx, bookingID, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to refund payment: %w", err)
+		return nil, err
+	}
+	result := &models.CancellationResult{Quote: quote}
+	if quote.RefundAmount.Sign() > 0 {
+		if result.Refunds, err = refundPaidTx(ctx, tx, bookingID, quote.RefundAmount, &quote.RefundPercent, reason); err != nil {
+			return nil, err
+		}
+	}
+	if err := cancelPendingPaymentsTx(ctx, tx, bookingID); err != nil {
+		return nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	if err := recordStatusChange(ctx, tx, []int{bookingID}, oldStatus, "cancelled", reason); err != nil {
+		return nil, err
 	}
 
-	return nil
+	// Освободившийся интервал предлагается листу ожидания
+	if err := releaseSlotTx(ctx, tx, bookingID, "declined"); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // GetUserBookings возвращает историю бронирований пользователя
-func (db *DB) GetUserBookings(userID int) ([]models.Booking, error) {
+func (db *DB) GetUserBookings(ctx context.Context, userID int) ([]models.Booking, error) {
 	query := `
 		SELECT
 			b.booking_id, b.room_id, b.user_id, b.starts_at, b.ends_at, b.total_amount,
-			b.status, b.created_at, b.updated_at,
+			b.status, b.created_at, b.updated_at, b.series_id, b.needs_relocation,
 			r.name AS room_name,
 			c.name AS coworking_name,
 			c.address AS coworking_address,
-			COALESCE(p.status, 'no_payment') AS payment_status,
-			p.paid_at
+			c.time_zone,
+			bb.payment_status,
+			bb.last_paid_at
 		FROM booking b
 		JOIN room r ON b.room_id = r.room_id
 		JOIN coworking c ON r.coworking_id = c.coworking_id
-		LEFT JOIN payment p ON b.booking_id = p.booking_id
+		JOIN booking_balance bb ON b.booking_id = bb.booking_id
 		WHERE b.user_id = $1
 		ORDER BY b.created_at DESC
 	`
-	rows, err := db.Query(query, userID)
+	rows, err := db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user bookings: %w", err)
 	}
@@ -431,24 +817,33 @@ func (db *DB) GetUserBookings(userID int) ([]models.Booking, error) {
 		var b models.Booking
 		var paymentStatus string
 		if err := rows.Scan(&b.BookingID, &b.RoomID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.TotalAmount,
-			&b.Status, &b.CreatedAt, &b.UpdatedAt, &b.RoomName, &b.CoworkingName, &b.CoworkingAddress,
-			&paymentStatus, &b.PaidAt); err != nil {
+			&b.Status, &b.CreatedAt, &b.UpdatedAt, &b.SeriesID, &b.NeedsRelocation, &b.RoomName, &b.CoworkingName,
+			&b.CoworkingAddress, &b.TimeZone, &paymentStatus, &b.PaidAt); err != nil {
 			return nil, fmt.Errorf("failed to scan booking: %w", err)
 		}
+		localizeBooking(&b, b.TimeZone)
 		b.PaymentStatus = &paymentStatus
 		bookings = append(bookings, b)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read user bookings: %w", err)
+	}
 	return bookings, nil
 }
 
-// GetRoomOccupancy возвращает отчёт о загрузке комнат за период
-func (db *DB) GetRoomOccupancy(startDate, endDate time.Time) ([]models.RoomOccupancy, error) {
+// GetRoomOccupancy возвращает отчёт о загрузке комнат за период [startDate, endDate).
+// Границы периода — местное время каждого коворкинга (часовой пояс значений не учитывается).
+// Загрузка считается от часов работы комнаты за период — по её расписанию, с учётом
+// праздников и перехода на летнее время — за вычетом часов, закрытых блокировками
+// (они возвращаются отдельно в BlackoutHours).
+// coworkingIDs ограничивает отчёт указанными коворкингами; nil — все коворкинги
+func (db *DB) GetRoomOccupancy(ctx context.Context, startDate, endDate time.Time, coworkingIDs []int) ([]models.RoomOccupancy, error) {
 	query := `
 		WITH period AS (
-			SELECT $1::timestamp AS start_date, $2::timestamp AS end_date
-		),
-		total_hours AS (
-			SELECT EXTRACT(EPOCH FROM (end_date - start_date)) / 3600 AS hours FROM period
+			SELECT c.coworking_id,
+			       $1::timestamp AT TIME ZONE c.time_zone AS start_at,
+			       $2::timestamp AT TIME ZONE c.time_zone AS end_at
+			FROM coworking c
 		)
 		SELECT
 			r.room_id,
@@ -456,60 +851,113 @@ func (db *DB) GetRoomOccupancy(startDate, endDate time.Time) ([]models.RoomOccup
 			c.name AS coworking_name,
 			COUNT(b.booking_id) AS total_bookings,
 			COALESCE(SUM(EXTRACT(EPOCH FROM (b.ends_at - b.starts_at)) / 3600), 0) AS booked_hours,
-			(SELECT hours FROM total_hours) AS total_hours,
-			ROUND(
-				COALESCE(SUM(EXTRACT(EPOCH FROM (b.ends_at - b.starts_at)) / 3600), 0) / (SELECT hours FROM total_hours) * 100,
-				2
-			) AS occupancy_percentage
+			p.start_at,
+			p.end_at
 		FROM room r
 		JOIN coworking c ON r.coworking_id = c.coworking_id
+		JOIN period p ON p.coworking_id = c.coworking_id
 		LEFT JOIN booking b ON r.room_id = b.room_id
 			AND b.status IN ('confirmed', 'completed')
-			AND b.starts_at >= (SELECT start_date FROM period)
-			AND b.ends_at <= (SELECT end_date FROM period)
-		GROUP BY r.room_id, r.name, c.name
-		ORDER BY occupancy_percentage DESC
+			AND b.starts_at >= p.start_at
+			AND b.ends_at <= p.end_at
+		WHERE ($3::int[] IS NULL OR r.coworking_id = ANY($3))
+		GROUP BY r.room_id, r.name, c.name, p.start_at, p.end_at
 	`
-	rows, err := db.Query(query, startDate, endDate)
+	tx, err := db.BeginTx(ctx, txReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, localtime.WallString(startDate), localtime.WallString(endDate), pq.Array(coworkingIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get room occupancy: %w", err)
 	}
 	defer rows.Close()
 
 	var occupancies []models.RoomOccupancy
+	var periods []schedule.Interval
 	for rows.Next() {
 		var o models.RoomOccupancy
+		var period schedule.Interval
 		if err := rows.Scan(&o.RoomID, &o.RoomName, &o.CoworkingName, &o.TotalBookings,
-			&o.BookedHours, &o.TotalHours, &o.OccupancyPercentage); err != nil {
+			&o.BookedHours, &period.Start, &period.End); err != nil {
 			return nil, fmt.Errorf("failed to scan occupancy: %w", err)
 		}
 		occupancies = append(occupancies, o)
+		periods = append(periods, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read room occupancy: %w", err)
+	}
+	rows.Close()
+
+	// Знаменатель — часы работы комнаты; запас в сутки покрывает разницу поясов коворкингов
+	roomIDs := make([]int, len(occupancies))
+	for i, o := range occupancies {
+		roomIDs[i] = o.RoomID
+	}
+	schedules, err := loadSchedules(ctx, tx, roomIDs, startDate.Add(-24*time.Hour), endDate.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := loadBlackouts(ctx, tx, roomIDs, startDate.Add(-24*time.Hour), endDate.Add(24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	for i := range occupancies {
+		o := &occupancies[i]
+		s, p := schedules[o.RoomID], periods[i]
+		o.TotalHours = s.Hours(p.Start, p.End).Hours()
+		o.BlackoutHours = s.HoursWithin(blackouts[o.RoomID], p.Start, p.End).Hours()
+		if available := o.TotalHours - o.BlackoutHours; available > 0 {
+			o.OccupancyPercentage = math.Round(o.BookedHours/available*100*100) / 100
+		}
+	}
+	sort.SliceStable(occupancies, func(i, j int) bool {
+		return occupancies[i].OccupancyPercentage > occupancies[j].OccupancyPercentage
+	})
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 	return occupancies, nil
 }
 
-// GetRevenueReport возвращает отчёт о выручке за период
-func (db *DB) GetRevenueReport(startDate, endDate time.Time) ([]models.RevenueReport, error) {
+// GetRevenueReport возвращает отчёт о выручке по бронированиям, созданным в период [startDate, endDate)
+// по местному времени коворкинга (как в GetRoomOccupancy). Суммы считаются по балансу бронирований
+// (booking_balance): подтверждённая выручка — оплаченное за вычетом возвратов.
+// coworkingIDs ограничивает отчёт указанными коворкингами; nil — все коворкинги
+func (db *DB) GetRevenueReport(ctx context.Context, startDate, endDate time.Time, coworkingIDs []int) ([]models.RevenueReport, error) {
 	query := `
 		SELECT
 			c.coworking_id,
 			c.name AS coworking_name,
 			c.address,
 			COUNT(DISTINCT b.booking_id) AS total_bookings,
-			COALESCE(SUM(p.amount), 0) AS total_revenue,
-			COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS confirmed_revenue,
-			COALESCE(SUM(CASE WHEN p.status = 'pending' THEN p.amount ELSE 0 END), 0) AS pending_revenue,
-			COALESCE(SUM(CASE WHEN p.status = 'refunded' THEN p.amount ELSE 0 END), 0) AS refunded_amount
+			COALESCE(SUM(bb.paid_amount + bb.pending_amount), 0) AS total_revenue,
+			COALESCE(SUM(bb.balance), 0) AS confirmed_revenue,
+			COALESCE(SUM(bb.pending_amount), 0) AS pending_revenue,
+			COALESCE(SUM(bb.refunded_amount), 0) AS refunded_amount,
+			COALESCE(SUM(pr.amount) FILTER (WHERE b.status <> 'cancelled'), 0) AS promo_discount
 		FROM coworking c
 		LEFT JOIN room r ON c.coworking_id = r.coworking_id
 		LEFT JOIN booking b ON r.room_id = b.room_id
-			AND b.created_at >= $1
-			AND b.created_at <= $2
-		LEFT JOIN payment p ON b.booking_id = p.booking_id
+			AND b.created_at >= $1::timestamp AT TIME ZONE c.time_zone
+			AND b.created_at < $2::timestamp AT TIME ZONE c.time_zone
+		LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
+		LEFT JOIN promo_redemption pr ON b.booking_id = pr.booking_id
+		WHERE ($3::int[] IS NULL OR c.coworking_id = ANY($3))
 		GROUP BY c.coworking_id, c.name, c.address
 		ORDER BY total_revenue DESC
 	`
-	rows, err := db.Query(query, startDate, endDate)
+	tx, err := db.BeginTx(ctx, txReport)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, localtime.WallString(startDate), localtime.WallString(endDate), pq.Array(coworkingIDs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get revenue report: %w", err)
 	}
@@ -519,16 +967,60 @@ func (db *DB) GetRevenueReport(startDate, endDate time.Time) ([]models.RevenueRe
 	for rows.Next() {
 		var r models.RevenueReport
 		if err := rows.Scan(&r.CoworkingID, &r.CoworkingName, &r.Address, &r.TotalBookings,
-			&r.TotalRevenue, &r.ConfirmedRevenue, &r.PendingRevenue, &r.RefundedAmount); err != nil {
+			&r.TotalRevenue, &r.ConfirmedRevenue, &r.PendingRevenue, &r.RefundedAmount, &r.PromoDiscount); err != nil {
 			return nil, fmt.Errorf("failed to scan revenue report: %w", err)
 		}
 		reports = append(reports, r)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read revenue report: %w", err)
+	}
+	rows.Close()
+
+	// Разбивка стоимости по строкам расчёта за тот же период
+	rows, err = tx.QueryContext(ctx, `
+		SELECT c.coworking_id, bpl.kind, bpl.description, SUM(bpl.amount)
+		FROM booking_price_line bpl
+		JOIN booking b ON bpl.booking_id = b.booking_id
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE b.status <> 'cancelled'
+			AND b.created_at >= $1::timestamp AT TIME ZONE c.time_zone
+			AND b.created_at < $2::timestamp AT TIME ZONE c.time_zone
+			AND ($3::int[] IS NULL OR c.coworking_id = ANY($3))
+		GROUP BY c.coworking_id, bpl.kind, bpl.description
+		ORDER BY c.coworking_id, bpl.kind DESC, SUM(bpl.amount) DESC
+	`, localtime.WallString(startDate), localtime.WallString(endDate), pq.Array(coworkingIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price breakdown: %w", err)
+	}
+	breakdown := make(map[int][]models.PriceBreakdown)
+	for rows.Next() {
+		var coworkingID int
+		var item models.PriceBreakdown
+		if err := rows.Scan(&coworkingID, &item.Kind, &item.Description, &item.Amount); err != nil {
+			return nil, fmt.Errorf("failed to scan price breakdown: %w", err)
+		}
+		breakdown[coworkingID] = append(breakdown[coworkingID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read price breakdown: %w", err)
+	}
+	for i := range reports {
+		reports[i].PriceBreakdown = breakdown[reports[i].CoworkingID]
+		if reports[i].PriceBreakdown == nil {
+			reports[i].PriceBreakdown = []models.PriceBreakdown{}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return reports, nil
 }
 
-// GetUserStatistics возвращает статистику пользователя
-func (db *DB) GetUserStatistics(userID int) (*models.UserStatistics, error) {
+// GetUserStatistics возвращает статистику пользователя; TotalPaid — сумма балансов его бронирований
+func (db *DB) GetUserStatistics(ctx context.Context, userID int) (*models.UserStatistics, error) {
 	query := `
 		SELECT
 			u.user_id,
@@ -539,20 +1031,23 @@ func (db *DB) GetUserStatistics(userID int) (*models.UserStatistics, error) {
 			COUNT(CASE WHEN b.status = 'completed' THEN 1 END) AS completed_bookings,
 			COUNT(CASE WHEN b.status = 'cancelled' THEN 1 END) AS cancelled_bookings,
 			COALESCE(SUM(b.total_amount), 0) AS total_spent,
-			COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS total_paid
+			COALESCE(SUM(bb.balance), 0) AS total_paid
 		FROM "user" u
 		LEFT JOIN booking b ON u.user_id = b.user_id
-		LEFT JOIN payment p ON b.booking_id = p.booking_id
+		LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
 		WHERE u.user_id = $1
 		GROUP BY u.user_id, u.full_name, u.email
 	`
 	var stats models.UserStatistics
-	err := db.QueryRow(query, userID).Scan(
+	err := db.QueryRowContext(ctx, query, userID).Scan(
 		&stats.UserID, &stats.FullName, &stats.Email, &stats.TotalBookings,
 		&stats.ConfirmedBookings, &stats.CompletedBookings, &stats.CancelledBookings,
 		&stats.TotalSpent, &stats.TotalPaid,
 	)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user with id %d %w", userID, ErrNotFound)
+		}
 		return nil, fmt.Errorf("failed to get user statistics: %w", err)
 	}
 	return &stats, nil
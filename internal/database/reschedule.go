@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+
+	"github.com/lib/pq"
+)
+
+// RescheduleBooking переносит или продлевает бронирование пользователя в одной транзакции:
+// меняет room_id/starts_at/ends_at (booking_no_overlap проверяется заново),
+// пересчитывает total_amount и его расчёт по тарифу комнаты (с промокодом бронирования) и приводит
+// к нему платежи: ожидающие платежи заменяются одним платежом на новый остаток,
+// переплата возвращается по оплаченным (см. adjustPaymentsTx)
+func (db *DB) RescheduleBooking(ctx context.Context, bookingID, userID int, req models.RescheduleBookingRequest) (*models.RescheduleResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := rescheduleBookingTx(ctx, tx, bookingID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// rescheduleBookingTx выполняет перенос бронирования в транзакции tx
+func rescheduleBookingTx(ctx context.Context, tx *sql.Tx, bookingID, userID int, req models.RescheduleBookingRequest) (*models.RescheduleResult, error) {
+	// Текущее состояние бронирования
+	var current models.Booking
+	err := tx.QueryRowContext(ctx, `
+		SELECT booking_id, room_id, starts_at, ends_at, total_amount, status
+		FROM booking
+		WHERE booking_id = $1 AND user_id = $2
+		FOR UPDATE
+	`, bookingID, userID).Scan(&current.BookingID, &current.RoomID, &current.StartsAt, &current.EndsAt,
+		&current.TotalAmount, &current.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking with id %d %w", bookingID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if current.Status != "pending" && current.Status != "confirmed" {
+		return nil, fmt.Errorf("booking in status %s cannot be rescheduled: %w", current.Status, ErrConflict)
+	}
+
+	roomID, startsAt, endsAt := current.RoomID, current.StartsAt, current.EndsAt
+	if req.RoomID != nil {
+		roomID = *req.RoomID
+	}
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		endsAt = *req.EndsAt
+	}
+	if !startsAt.Before(endsAt) {
+		return nil, fmt.Errorf("starts_at must be before ends_at: %w", ErrConflict)
+	}
+
+	quote, zone, err := bookingPrice(ctx, tx, roomID, startsAt, endsAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOpeningHours(ctx, tx, roomID, startsAt, endsAt); err != nil {
+		return nil, err
+	}
+
+	// Погашенный промокод применяется к новой стоимости без повторной проверки сроков и лимитов
+	promo, err := bookingPromo(ctx, tx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	var discount money.Money
+	if promo != nil {
+		if err := promo.checkRoom(ctx, tx, roomID); err != nil {
+			return nil, err
+		}
+		quote, discount = promo.apply(quote)
+	}
+
+	// Перенос с пересчётом стоимости; EXCLUDE constraint проверяет пересечения.
+	// Перенос в другую комнату снимает отметку needs_relocation (на архивную комнату не пустит триггер)
+	var booking models.Booking
+	err = tx.QueryRowContext(ctx, `
+		UPDATE booking
+		SET room_id = $2,
+		    starts_at = $3,
+		    ends_at = $4,
+		    total_amount = $5,
+		    needs_relocation = needs_relocation AND room_id = $2,
+		    updated_at = NOW()
+		WHERE booking_id = $1
+		RETURNING booking_id, room_id, user_id, starts_at, ends_at, total_amount, status, created_at, updated_at, series_id
+	`, bookingID, roomID, startsAt, endsAt, quote.Total).Scan(
+		&booking.BookingID, &booking.RoomID, &booking.UserID, &booking.StartsAt, &booking.EndsAt,
+		&booking.TotalAmount, &booking.Status, &booking.CreatedAt, &booking.UpdatedAt, &booking.SeriesID,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23P01" { // exclusion_violation
+			return nil, fmt.Errorf("%w: %v", ErrRoomUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to reschedule booking: %w", err)
+	}
+	if err := savePriceLines(ctx, tx, bookingID, quote.Lines); err != nil {
+		return nil, err
+	}
+	if promo != nil {
+		if err := saveRedemption(ctx, tx, promo, bookingID, booking.UserID, discount); err != nil {
+			return nil, err
+		}
+	}
+	localizeBooking(&booking, zone)
+	booking.PriceLines = priceLines(quote.Lines)
+
+	result := &models.RescheduleResult{Booking: &booking}
+	if result.Payments, result.Refunds, result.Balance, err = adjustPaymentsTx(ctx, tx, bookingID, "reschedule"); err != nil {
+		return nil, err
+	}
+	// Бесплатное бронирование, ставшее платным, возвращено в pending
+	err = tx.QueryRowContext(ctx, `SELECT status FROM booking WHERE booking_id = $1`, bookingID).Scan(&booking.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking status: %w", err)
+	}
+	return result, nil
+}
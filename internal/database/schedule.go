@@ -0,0 +1,367 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/schedule"
+
+	"github.com/lib/pq"
+)
+
+// GetCoworkingHours возвращает недельное расписание коворкинга
+func (db *DB) GetCoworkingHours(ctx context.Context, coworkingID int) ([]models.OpeningHours, error) {
+	return db.getOpeningHours(ctx, "coworking_id", coworkingID)
+}
+
+// GetRoomHours возвращает собственное расписание комнаты; пустой список — расписание коворкинга
+func (db *DB) GetRoomHours(ctx context.Context, roomID int) ([]models.OpeningHours, error) {
+	return db.getOpeningHours(ctx, "room_id", roomID)
+}
+
+func (db *DB) getOpeningHours(ctx context.Context, ownerColumn string, ownerID int) ([]models.OpeningHours, error) {
+	query := `
+		SELECT weekday, left(opens_at::text, 5), left(closes_at::text, 5)
+		FROM opening_hours
+		WHERE ` + ownerColumn + ` = $1
+		ORDER BY weekday, opens_at
+	`
+	rows, err := db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening hours: %w", err)
+	}
+	defer rows.Close()
+
+	var hours []models.OpeningHours
+	for rows.Next() {
+		var h models.OpeningHours
+		if err := rows.Scan(&h.Weekday, &h.OpensAt, &h.ClosesAt); err != nil {
+			return nil, fmt.Errorf("failed to scan opening hours: %w", err)
+		}
+		hours = append(hours, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read opening hours: %w", err)
+	}
+	return hours, nil
+}
+
+// SetCoworkingHours заменяет недельное расписание коворкинга
+func (db *DB) SetCoworkingHours(ctx context.Context, coworkingID int, hours []models.OpeningHours) error {
+	return db.setOpeningHours(ctx, "coworking_id", coworkingID, hours)
+}
+
+// SetRoomHours заменяет собственное расписание комнаты; пустой список возвращает комнату
+// к расписанию коворкинга
+func (db *DB) SetRoomHours(ctx context.Context, roomID int, hours []models.OpeningHours) error {
+	return db.setOpeningHours(ctx, "room_id", roomID, hours)
+}
+
+func (db *DB) setOpeningHours(ctx context.Context, ownerColumn string, ownerID int, hours []models.OpeningHours) error {
+	for _, h := range hours {
+		if h.Weekday < 1 || h.Weekday > 7 {
+			return fmt.Errorf("weekday must be between 1 and 7, got %d: %w", h.Weekday, ErrConflict)
+		}
+		if _, err := schedule.ParseWindow(h.OpensAt, h.ClosesAt); err != nil {
+			return fmt.Errorf("%v: %w", err, ErrConflict)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM opening_hours WHERE `+ownerColumn+` = $1`, ownerID); err != nil {
+		return fmt.Errorf("failed to clear opening hours: %w", err)
+	}
+	for _, h := range hours {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO opening_hours (`+ownerColumn+`, weekday, opens_at, closes_at)
+			VALUES ($1, $2, $3, $4)
+		`, ownerID, h.Weekday, h.OpensAt, h.ClosesAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return fmt.Errorf("%s with id %d %w", strings.TrimSuffix(ownerColumn, "_id"), ownerID, ErrNotFound)
+			}
+			return fmt.Errorf("failed to set opening hours: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetRoomBuffer задаёт буферы подготовки и уборки комнаты в минутах (0–240).
+// Новые значения действуют на бронирования, созданные или перенесённые после изменения
+func (db *DB) SetRoomBuffer(ctx context.Context, roomID, setupMinutes, teardownMinutes int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE room SET setup_minutes = $2, teardown_minutes = $3 WHERE room_id = $1
+	`, roomID, setupMinutes, teardownMinutes)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" { // check_violation
+			return fmt.Errorf("buffers must be between 0 and 240 minutes: %w", ErrConflict)
+		}
+		return fmt.Errorf("failed to set room buffer: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set room buffer: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetHolidays возвращает праздничные и сокращённые дни коворкинга начиная с from (YYYY-MM-DD)
+func (db *DB) GetHolidays(ctx context.Context, coworkingID int, from string) ([]models.Holiday, error) {
+	query := `
+		SELECT holiday_id, coworking_id, day::text, name,
+		       left(opens_at::text, 5), left(closes_at::text, 5)
+		FROM coworking_holiday
+		WHERE coworking_id = $1 AND day >= $2::date
+		ORDER BY day
+	`
+	rows, err := db.QueryContext(ctx, query, coworkingID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []models.Holiday
+	for rows.Next() {
+		var h models.Holiday
+		if err := rows.Scan(&h.HolidayID, &h.CoworkingID, &h.Day, &h.Name, &h.OpensAt, &h.ClosesAt); err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	return holidays, nil
+}
+
+// CreateHoliday добавляет праздничный или сокращённый день коворкинга
+func (db *DB) CreateHoliday(ctx context.Context, coworkingID int, req models.CreateHolidayRequest) (*models.Holiday, error) {
+	if _, err := time.Parse(schedule.DayLayout, req.Day); err != nil {
+		return nil, fmt.Errorf("invalid day %q: expected YYYY-MM-DD: %w", req.Day, ErrConflict)
+	}
+	if (req.OpensAt == nil) != (req.ClosesAt == nil) {
+		return nil, fmt.Errorf("opens_at and closes_at must be set together: %w", ErrConflict)
+	}
+	if req.OpensAt != nil {
+		if _, err := schedule.ParseWindow(*req.OpensAt, *req.ClosesAt); err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrConflict)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO coworking_holiday (coworking_id, day, name, opens_at, closes_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING holiday_id, coworking_id, day::text, name,
+		          left(opens_at::text, 5), left(closes_at::text, 5)
+	`
+	var h models.Holiday
+	err = tx.QueryRowContext(ctx, query, coworkingID, req.Day, req.Name, req.OpensAt, req.ClosesAt).Scan(
+		&h.HolidayID, &h.CoworkingID, &h.Day, &h.Name, &h.OpensAt, &h.ClosesAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505": // unique_violation
+				return nil, fmt.Errorf("holiday on %s already exists: %w", req.Day, ErrConflict)
+			case "23503": // foreign_key_violation
+				return nil, fmt.Errorf("coworking with id %d %w", coworkingID, ErrNotFound)
+			}
+		}
+		return nil, fmt.Errorf("failed to create holiday: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &h, nil
+}
+
+// DeleteHoliday удаляет праздничный день
+func (db *DB) DeleteHoliday(ctx context.Context, holidayID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM coworking_holiday WHERE holiday_id = $1`, holidayID)
+	if err != nil {
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete holiday: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("holiday with id %d %w", holidayID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetHolidayCoworkingID возвращает коворкинг праздничного дня
+func (db *DB) GetHolidayCoworkingID(ctx context.Context, holidayID int) (int, error) {
+	var coworkingID int
+	err := db.QueryRowContext(ctx, `
+		SELECT coworking_id FROM coworking_holiday WHERE holiday_id = $1
+	`, holidayID).Scan(&coworkingID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("holiday with id %d %w", holidayID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get holiday: %w", err)
+	}
+	return coworkingID, nil
+}
+
+// checkOpeningHours проверяет, что [startsAt, endsAt) целиком лежит в часах работы комнаты
+func checkOpeningHours(ctx context.Context, q querier, roomID int, startsAt, endsAt time.Time) error {
+	schedules, err := loadSchedules(ctx, q, []int{roomID}, startsAt, endsAt)
+	if err != nil {
+		return err
+	}
+	s, ok := schedules[roomID]
+	if !ok {
+		return fmt.Errorf("room with id %d %w", roomID, ErrNotFound)
+	}
+	if !s.Covers(startsAt, endsAt) {
+		local := startsAt.In(s.Location)
+		return fmt.Errorf("%w: %s - %s", ErrOutsideOpeningHours,
+			local.Format(localtime.Layout), endsAt.In(s.Location).Format(localtime.Layout))
+	}
+	return nil
+}
+
+// loadSchedules загружает расписания комнат roomIDs с праздниками на период [from, to):
+// собственное расписание комнаты, а если его нет — расписание её коворкинга
+func loadSchedules(ctx context.Context, q querier, roomIDs []int, from, to time.Time) (map[int]*schedule.Schedule, error) {
+	schedules := make(map[int]*schedule.Schedule, len(roomIDs))
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.room_id, c.time_zone
+		FROM room r
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE r.room_id = ANY($1)
+	`, pq.Array(roomIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get room time zones: %w", err)
+	}
+	for rows.Next() {
+		var roomID int
+		var zone string
+		if err := rows.Scan(&roomID, &zone); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan room time zone: %w", err)
+		}
+		loc, err := localtime.Location(zone)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		schedules[roomID] = schedule.New(loc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read room time zones: %w", err)
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT r.room_id, oh.weekday, oh.opens_at::text, oh.closes_at::text
+		FROM room r
+		JOIN opening_hours oh ON oh.room_id = r.room_id
+			OR (oh.coworking_id = r.coworking_id
+				AND NOT EXISTS (SELECT 1 FROM opening_hours own WHERE own.room_id = r.room_id))
+		WHERE r.room_id = ANY($1)
+	`, pq.Array(roomIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get opening hours: %w", err)
+	}
+	for rows.Next() {
+		var roomID, weekday int
+		var opens, closes string
+		if err := rows.Scan(&roomID, &weekday, &opens, &closes); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan opening hours: %w", err)
+		}
+		w, err := schedule.ParseWindow(opens, closes)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("invalid opening hours of room %d: %w", roomID, err)
+		}
+		schedules[roomID].AddWeekly(weekday, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read opening hours: %w", err)
+	}
+
+	// Запас в сутки с каждой стороны: даты праздников — местные, а from/to — моменты времени
+	rows, err = q.QueryContext(ctx, `
+		SELECT r.room_id, h.day::text, h.opens_at::text, h.closes_at::text
+		FROM room r
+		JOIN coworking_holiday h ON h.coworking_id = r.coworking_id
+		WHERE r.room_id = ANY($1)
+		  AND h.day BETWEEN $2::date - 1 AND $3::date + 1
+	`, pq.Array(roomIDs), from.UTC().Format(schedule.DayLayout), to.UTC().Format(schedule.DayLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roomID int
+		var day string
+		var opens, closes *string
+		if err := rows.Scan(&roomID, &day, &opens, &closes); err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		if opens == nil || closes == nil {
+			schedules[roomID].SetDay(day, nil)
+			continue
+		}
+		w, err := schedule.ParseWindow(*opens, *closes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday hours of room %d: %w", roomID, err)
+		}
+		schedules[roomID].SetDay(day, &w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays: %w", err)
+	}
+	return schedules, nil
+}
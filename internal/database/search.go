@@ -0,0 +1,128 @@
+package database
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+
+	"github.com/lib/pq"
+)
+
+// Порядки результатов поиска комнат (models.SearchRoomParams.Sort); «-» в начале — по убыванию
+const (
+	SortByPrice    = "price"    // стоимость запрошенного периода, без неё — почасовая ставка
+	SortByCapacity = "capacity" // вместимость
+	SortByArea     = "area"     // площадь; комнаты без площади — в конце
+)
+
+// ValidateRoomSort проверяет порядок результатов поиска; пустая строка — по стоимости
+func ValidateRoomSort(order string) error {
+	switch strings.TrimPrefix(order, "-") {
+	case "", SortByPrice, SortByCapacity, SortByArea:
+		return nil
+	}
+	return fmt.Errorf("unknown sort %q: expected %s, %s or %s", order, SortByPrice, SortByCapacity, SortByArea)
+}
+
+// SortRooms упорядочивает результаты поиска; при равенстве — по ставке и ID комнаты,
+// чтобы порядок не зависел от того, по каким коворкингам шёл поиск
+func SortRooms(rooms []models.Room, order string) error {
+	if err := ValidateRoomSort(order); err != nil {
+		return err
+	}
+	key, desc := strings.CutPrefix(order, "-")
+	slices.SortStableFunc(rooms, func(a, b models.Room) int {
+		if key == SortByArea && (a.AreaSqm == nil) != (b.AreaSqm == nil) {
+			if a.AreaSqm == nil {
+				return 1
+			}
+			return -1
+		}
+
+		var c int
+		switch key {
+		case SortByCapacity:
+			c = cmp.Compare(a.Capacity, b.Capacity)
+		case SortByArea:
+			if a.AreaSqm != nil && b.AreaSqm != nil {
+				c = cmp.Compare(*a.AreaSqm, *b.AreaSqm)
+			}
+		default:
+			c = roomPrice(a).Cmp(roomPrice(b))
+		}
+		if desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		if c = a.HourlyRate.Cmp(b.HourlyRate); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.RoomID, b.RoomID)
+	})
+	return nil
+}
+
+func roomPrice(r models.Room) money.Money {
+	if r.QuotedPrice != nil {
+		return *r.QuotedPrice
+	}
+	return r.HourlyRate
+}
+
+// resolveEquipment объединяет идентификаторы оборудования с найденными по названиям
+// (без учёта регистра); неизвестное название — ErrNotFound
+func resolveEquipment(ctx context.Context, q querier, ids []int, names []string) ([]int, error) {
+	equipment := append([]int{}, ids...)
+	if len(names) == 0 {
+		return equipment, nil
+	}
+
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+	rows, err := q.QueryContext(ctx, `
+		SELECT lower(name), equipment_id FROM equipment WHERE lower(name) = ANY($1)
+	`, pq.Array(lowered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get equipment by name: %w", err)
+	}
+	defer rows.Close()
+
+	found := make(map[string][]int)
+	for rows.Next() {
+		var name string
+		var id int
+		if err := rows.Scan(&name, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan equipment: %w", err)
+		}
+		found[name] = append(found[name], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read equipment: %w", err)
+	}
+
+	for i, name := range lowered {
+		matched := found[name]
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("equipment %q %w", names[i], ErrNotFound)
+		}
+		equipment = append(equipment, matched...)
+	}
+	return equipment, nil
+}
+
+func ints(a pq.Int64Array) []int {
+	out := make([]int, len(a))
+	for i, v := range a {
+		out[i] = int(v)
+	}
+	return out
+}
@@ -0,0 +1,440 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/money"
+	"coworking-booking/internal/recurrence"
+)
+
+// CreateBookingSeries создаёт серию и все её вхождения в одной транзакции.
+// Вхождения, пересекающиеся с существующими бронированиями (booking_no_overlap) или
+// выпадающие на нерабочее время, пропускаются и возвращаются в Conflicts;
+// если пропущены все — серия не создаётся
+func (db *DB) CreateBookingSeries(ctx context.Context, userID int, req models.CreateSeriesRequest, rule recurrence.Rule) (*models.SeriesResult, error) {
+	if !req.StartsAt.Before(req.EndsAt) {
+		return nil, fmt.Errorf("starts_at must be before ends_at: %w", ErrConflict)
+	}
+
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	zone, err := roomTimeZone(ctx, tx, req.RoomID)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesQuery := `
+		INSERT INTO booking_series (user_id, room_id, rrule, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING series_id, user_id, room_id, rrule, starts_at, ends_at, status, created_at
+	`
+	series := models.BookingSeries{TimeZone: zone}
+	err = tx.QueryRowContext(ctx, seriesQuery, userID, req.RoomID, rule.String(), req.StartsAt, req.EndsAt).Scan(
+		&series.SeriesID, &series.UserID, &series.RoomID, &series.RRule,
+		&series.StartsAt, &series.EndsAt, &series.Status, &series.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create booking series: %w", err)
+	}
+
+	localizeSeries(&series)
+
+	// Вхождения повторяются по местным часам коворкинга: 10:00 остаётся 10:00
+	// и после перехода на летнее время
+	result := &models.SeriesResult{Series: &series, Bookings: []models.BookingWithPayment{}}
+	duration := req.EndsAt.Sub(req.StartsAt)
+
+	occurrences, err := rule.Occurrences(series.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, startsAt := range occurrences {
+		endsAt := startsAt.Add(duration)
+
+		var booking *models.Booking
+		var payment *models.Payment
+		err := withSavepoint(ctx, tx, func() error {
+			var err error
+			booking, payment, err = createBookingWithPaymentTx(ctx, tx, req.RoomID, userID, startsAt, endsAt, req.PaymentMethod, &series.SeriesID, "")
+			return err
+		})
+		if errors.Is(err, ErrRoomUnavailable) || errors.Is(err, ErrOutsideOpeningHours) {
+			reason := "room_unavailable"
+			if errors.Is(err, ErrOutsideOpeningHours) {
+				reason = "closed"
+			}
+			result.Conflicts = append(result.Conflicts, models.SeriesConflict{StartsAt: startsAt, EndsAt: endsAt, Reason: reason})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Bookings = append(result.Bookings, models.BookingWithPayment{Booking: booking, Payment: payment})
+	}
+
+	if len(result.Bookings) == 0 {
+		return nil, fmt.Errorf("%w: все вхождения серии пересекаются с существующими бронированиями или приходятся на нерабочее время", ErrRoomUnavailable)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetBookingSeries возвращает серию вместе со всеми её вхождениями и их последними платежами;
+// у бесплатных вхождений платежа нет (Payment == nil)
+func (db *DB) GetBookingSeries(ctx context.Context, seriesID int) (*models.SeriesResult, error) {
+	var series models.BookingSeries
+	err := db.QueryRowContext(ctx, `
+		SELECT s.series_id, s.user_id, s.room_id, s.rrule, s.starts_at, s.ends_at, s.status, s.created_at, c.time_zone
+		FROM booking_series s
+		JOIN room r ON s.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE s.series_id = $1
+	`, seriesID).Scan(
+		&series.SeriesID, &series.UserID, &series.RoomID, &series.RRule,
+		&series.StartsAt, &series.EndsAt, &series.Status, &series.CreatedAt, &series.TimeZone,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking series with id %d %w", seriesID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking series: %w", err)
+	}
+	localizeSeries(&series)
+
+	query := `
+		SELECT b.booking_id, b.room_id, b.user_id, b.starts_at, b.ends_at, b.total_amount,
+		       b.status, b.series_id, b.created_at, b.updated_at, c.time_zone,
+		       p.payment_id, p.booking_id, p.amount, p.status, p.payment_method, p.paid_at, p.created_at, p.updated_at,
+		       bb.payment_status
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		JOIN booking_balance bb ON bb.booking_id = b.booking_id
+		LEFT JOIN LATERAL (
+			-- Последний платёж вхождения: доплата за перенос или исходный платёж
+			SELECT * FROM payment
+			WHERE booking_id = b.booking_id
+			ORDER BY created_at DESC, payment_id DESC
+			LIMIT 1
+		) p ON TRUE
+		WHERE b.series_id = $1
+		ORDER BY b.starts_at
+	`
+	rows, err := db.QueryContext(ctx, query, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series bookings: %w", err)
+	}
+	defer rows.Close()
+
+	result := &models.SeriesResult{Series: &series, Bookings: []models.BookingWithPayment{}}
+	for rows.Next() {
+		var b models.Booking
+		var p models.Payment
+		var zone string
+		// Колонки платежа NULL, если платежей у вхождения нет
+		var paymentID, paymentBookingID *int
+		var amount *money.Money
+		var status *string
+		var createdAt, updatedAt *time.Time
+		err := rows.Scan(
+			&b.BookingID, &b.RoomID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.TotalAmount,
+			&b.Status, &b.SeriesID, &b.CreatedAt, &b.UpdatedAt, &zone,
+			&paymentID, &paymentBookingID, &amount, &status, &p.PaymentMethod, &p.PaidAt, &createdAt, &updatedAt,
+			&b.PaymentStatus,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan series booking: %w", err)
+		}
+		localizeBooking(&b, zone)
+		bp := models.BookingWithPayment{Booking: &b}
+		if paymentID != nil {
+			p.PaymentID, p.BookingID, p.Amount, p.Status = *paymentID, *paymentBookingID, *amount, *status
+			p.CreatedAt, p.UpdatedAt = *createdAt, *updatedAt
+			bp.Payment = &p
+		}
+		result.Bookings = append(result.Bookings, bp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate series bookings: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetSeriesOwner возвращает владельца серии
+func (db *DB) GetSeriesOwner(ctx context.Context, seriesID int) (int, error) {
+	var userID int
+	err := db.QueryRowContext(ctx, `SELECT user_id FROM booking_series WHERE series_id = $1`, seriesID).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("booking series with id %d %w", seriesID, ErrNotFound)
+		}
+		return 0, fmt.Errorf("failed to get booking series: %w", err)
+	}
+	return userID, nil
+}
+
+// UpdateBookingSeries изменяет вхождения серии в области req.Scope:
+// this — только выбранное вхождение, following — его и последующие, all — все активные.
+// Сдвиг начала и новая длительность выбранного вхождения применяются к каждому
+// затронутому вхождению через тот же перенос, что и RescheduleBooking (с корректировкой платежей).
+// Изменение атомарно: при пересечении хотя бы одного вхождения ничего не меняется,
+// а в ошибке перечисляются конфликтующие даты
+func (db *DB) UpdateBookingSeries(ctx context.Context, seriesID, userID int, req models.UpdateSeriesRequest) ([]models.RescheduleResult, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Опорное вхождение
+	var pivot models.Booking
+	var zone string
+	err = tx.QueryRowContext(ctx, `
+		SELECT b.booking_id, b.room_id, b.starts_at, b.ends_at, c.time_zone
+		FROM booking b
+		JOIN room r ON b.room_id = r.room_id
+		JOIN coworking c ON r.coworking_id = c.coworking_id
+		WHERE b.booking_id = $1 AND b.series_id = $2 AND b.user_id = $3
+		FOR UPDATE OF b
+	`, req.BookingID, seriesID, userID).Scan(&pivot.BookingID, &pivot.RoomID, &pivot.StartsAt, &pivot.EndsAt, &zone)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("booking with id %d in series %d %w", req.BookingID, seriesID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	newStart, newEnd := pivot.StartsAt, pivot.EndsAt
+	if req.StartsAt != nil {
+		newStart = *req.StartsAt
+	}
+	if req.EndsAt != nil {
+		newEnd = *req.EndsAt
+	}
+	if !newStart.Before(newEnd) {
+		return nil, fmt.Errorf("starts_at must be before ends_at: %w", ErrConflict)
+	}
+	localizeBooking(&pivot, zone)
+
+	// Сдвиг считается по местным часам коворкинга, чтобы перенос «на час позже»
+	// давал то же время на часах у вхождений по обе стороны перехода на летнее время
+	shift := localtime.Wall(localtime.In(newStart, zone)).Sub(localtime.Wall(pivot.StartsAt))
+	duration := newEnd.Sub(newStart)
+
+	// При сдвиге вперёд вхождения переносятся с конца, назад — с начала,
+	// чтобы перенесённое вхождение не пересеклось с ещё не перенесённым соседом
+	order := "ASC"
+	if shift > 0 {
+		order = "DESC"
+	}
+
+	var targets []models.Booking
+	switch req.Scope {
+	case models.SeriesScopeThis:
+		targets = []models.Booking{pivot}
+	case models.SeriesScopeFollowing, models.SeriesScopeAll:
+		query := `
+			SELECT booking_id, starts_at
+			FROM booking
+			WHERE series_id = $1
+			  AND status IN ('pending', 'confirmed')
+			  AND ($2 = 'all' OR starts_at >= $3)
+			ORDER BY starts_at ` + order + `
+			FOR UPDATE
+		`
+		rows, err := tx.QueryContext(ctx, query, seriesID, req.Scope, pivot.StartsAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get series bookings: %w", err)
+		}
+		for rows.Next() {
+			var b models.Booking
+			if err := rows.Scan(&b.BookingID, &b.StartsAt); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan series booking: %w", err)
+			}
+			b.StartsAt = localtime.In(b.StartsAt, zone)
+			targets = append(targets, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to iterate series bookings: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown series scope %q: %w", req.Scope, ErrConflict)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("series %d has no active bookings to update: %w", seriesID, ErrConflict)
+	}
+
+	results := make([]models.RescheduleResult, 0, len(targets))
+	var conflicts []string
+	for _, target := range targets {
+		startsAt := localtime.ShiftWall(target.StartsAt, shift)
+		endsAt := startsAt.Add(duration)
+
+		var result *models.RescheduleResult
+		err := withSavepoint(ctx, tx, func() error {
+			var err error
+			result, err = rescheduleBookingTx(ctx, tx, target.BookingID, userID, models.RescheduleBookingRequest{
+				RoomID:   req.RoomID,
+				StartsAt: &startsAt,
+				EndsAt:   &endsAt,
+			})
+			return err
+		})
+		if errors.Is(err, ErrRoomUnavailable) {
+			conflicts = append(conflicts, startsAt.Format("2006-01-02 15:04"))
+			continue
+		}
+		if errors.Is(err, ErrOutsideOpeningHours) {
+			conflicts = append(conflicts, startsAt.Format("2006-01-02 15:04")+" (нерабочее время)")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *result)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrRoomUnavailable, strings.Join(conflicts, ", "))
+	}
+
+	// Для all шаблон серии сдвигается вместе с вхождениями
+	if req.Scope == models.SeriesScopeAll {
+		var seriesStart time.Time
+		err = tx.QueryRowContext(ctx, `
+			SELECT starts_at FROM booking_series WHERE series_id = $1 FOR UPDATE
+		`, seriesID).Scan(&seriesStart)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get booking series: %w", err)
+		}
+		seriesStart = localtime.ShiftWall(localtime.In(seriesStart, zone), shift)
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE booking_series
+			SET room_id = COALESCE($2, room_id),
+			    starts_at = $3,
+			    ends_at = $4
+			WHERE series_id = $1
+		`, seriesID, req.RoomID, seriesStart, seriesStart.Add(duration))
+		if err != nil {
+			return nil, fmt.Errorf("failed to update booking series: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// CancelBookingSeries отменяет вхождения серии с возвратом средств, как CancelBookingWithRefund:
+// начиная с req.FromBookingID или все ещё не начавшиеся. Если активных вхождений
+// не осталось, серия переводится в cancelled. Возвращает идентификаторы отменённых бронирований
+func (db *DB) CancelBookingSeries(ctx context.Context, seriesID, userID int, req models.CancelSeriesRequest) ([]int, error) {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var from time.Time
+	if req.FromBookingID != nil {
+		err = tx.QueryRowContext(ctx, `
+			SELECT starts_at FROM booking WHERE booking_id = $1 AND series_id = $2
+		`, *req.FromBookingID, seriesID).Scan(&from)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("booking with id %d in series %d %w", *req.FromBookingID, seriesID, ErrNotFound)
+			}
+			return nil, fmt.Errorf("failed to get booking: %w", err)
+		}
+	} else {
+		if err := tx.QueryRowContext(ctx, `SELECT NOW()`).Scan(&from); err != nil {
+			return nil, fmt.Errorf("failed to get current time: %w", err)
+		}
+	}
+
+	ids, err := queryIDs(ctx, tx, `
+		SELECT booking_id
+		FROM booking
+		WHERE series_id = $1 AND user_id = $2
+		  AND status IN ('pending', 'confirmed')
+		  AND starts_at >= $3
+		ORDER BY starts_at
+		FOR UPDATE
+	`, seriesID, userID, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series bookings: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("series %d has no bookings to cancel: %w", seriesID, ErrConflict)
+	}
+
+	for _, id := range ids {
+		if _, err := cancelBookingTx(ctx, tx, id, userID, ReasonSeriesCancelled); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE booking_series
+		SET status = 'cancelled'
+		WHERE series_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM booking
+			WHERE series_id = $1 AND status IN ('pending', 'confirmed')
+		  )
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update booking series: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return ids, nil
+}
+
+// localizeSeries переводит шаблон серии в часовой пояс её коворкинга (series.TimeZone)
+func localizeSeries(s *models.BookingSeries) {
+	s.StartsAt = localtime.In(s.StartsAt, s.TimeZone)
+	s.EndsAt = localtime.In(s.EndsAt, s.TimeZone)
+}
+
+// withSavepoint выполняет fn внутри точки сохранения: при ошибке транзакция
+// откатывается только до неё и остаётся пригодной для дальнейших запросов
+func withSavepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT attempt`); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT attempt`); rbErr != nil {
+			return fmt.Errorf("failed to rollback to savepoint: %w", rbErr)
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT attempt`); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
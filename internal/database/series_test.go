@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/models"
+	"coworking-booking/internal/recurrence"
+)
+
+func TestGetBookingSeriesPayments(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	rule, err := recurrence.Parse("FREQ=DAILY;COUNT=3", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		rate        string
+		wantStatus  string
+		wantPayment bool
+	}{
+		{"free occurrences have no payment", "0", "confirmed", false},
+		{"paid occurrences show the pending payment", "1000.00", "pending", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, err := f.db.CreateBookingSeries(ctx, f.userID, models.CreateSeriesRequest{
+				RoomID: f.room(t, tt.rate), StartsAt: f.at(0), EndsAt: f.at(time.Hour), PaymentMethod: "card",
+			}, rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := f.db.GetBookingSeries(ctx, created.Series.SeriesID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Bookings) != 3 {
+				t.Fatalf("got %d occurrences, want 3", len(got.Bookings))
+			}
+			for _, bp := range got.Bookings {
+				if bp.Booking.Status != tt.wantStatus {
+					t.Errorf("occurrence %d: status %s, want %s", bp.Booking.BookingID, bp.Booking.Status, tt.wantStatus)
+				}
+				if (bp.Payment != nil) != tt.wantPayment {
+					t.Fatalf("occurrence %d: payment %+v", bp.Booking.BookingID, bp.Payment)
+				}
+				if bp.Payment != nil && (bp.Payment.BookingID != bp.Booking.BookingID ||
+					bp.Payment.Status != "pending" || bp.Payment.Amount.String() != "1000.00") {
+					t.Errorf("occurrence %d: payment %+v", bp.Booking.BookingID, bp.Payment)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBookingSeriesShowsLatestPayment(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	rule, err := recurrence.Parse("FREQ=WEEKLY;COUNT=2", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := f.db.CreateBookingSeries(ctx, f.userID, models.CreateSeriesRequest{
+		RoomID: f.room(t, "1000.00"), StartsAt: f.at(0), EndsAt: f.at(time.Hour), PaymentMethod: "card",
+	}, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := created.Bookings[0]
+	if _, _, err := f.db.ConfirmPaymentAndBooking(ctx, first.Payment.PaymentID); err != nil {
+		t.Fatal(err)
+	}
+	end := first.Booking.EndsAt.Add(time.Hour)
+	if _, err := f.db.RescheduleBooking(ctx, first.Booking.BookingID, f.userID, models.RescheduleBookingRequest{EndsAt: &end}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := f.db.GetBookingSeries(ctx, created.Series.SeriesID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Доплата за продление — последний платёж первого вхождения
+	p := got.Bookings[0].Payment
+	if p == nil || p.PaymentID == first.Payment.PaymentID || p.Status != "pending" || p.Amount.String() != "1000.00" {
+		t.Errorf("first occurrence payment = %+v, want the surcharge", p)
+	}
+	if s := got.Bookings[0].Booking.PaymentStatus; s == nil || *s != "partially_paid" {
+		t.Errorf("first occurrence payment status = %v", s)
+	}
+}
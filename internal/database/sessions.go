@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+// CreateSession сохраняет новую сессию; в БД хранится только хеш токена
+func (db *DB) CreateSession(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (*models.Session, error) {
+	query := `
+		INSERT INTO session (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING session_id, user_id, created_at, expires_at, revoked_at
+	`
+	var s models.Session
+	err := db.QueryRowContext(ctx, query, userID, tokenHash, expiresAt).Scan(
+		&s.SessionID, &s.UserID, &s.CreatedAt, &s.ExpiresAt, &s.RevokedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return &s, nil
+}
+
+// GetUserBySessionToken возвращает владельца активной (не отозванной и не истёкшей) сессии
+func (db *DB) GetUserBySessionToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	query := `
+		SELECT u.user_id, u.email, u.full_name, u.role, u.created_at
+		FROM session s
+		JOIN "user" u ON s.user_id = u.user_id
+		WHERE s.token_hash = $1
+		  AND s.revoked_at IS NULL
+		  AND s.expires_at > NOW()
+	`
+	var user models.User
+	err := db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&user.UserID, &user.Email, &user.FullName, &user.Role, &user.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session %w", ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &user, nil
+}
+
+// RevokeSession отзывает сессию (logout)
+func (db *DB) RevokeSession(ctx context.Context, tokenHash string) error {
+	query := `
+		UPDATE session
+		SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL
+	`
+	res, err := db.ExecContext(ctx, query, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("session %w", ErrNotFound)
+	}
+	return nil
+}
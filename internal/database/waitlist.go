@@ -0,0 +1,297 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"coworking-booking/internal/localtime"
+	"coworking-booking/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// WaitlistOfferWindow — сколько времени у пользователя из листа ожидания есть на оплату
+// предложенного бронирования; по истечении планировщик отменяет его и предлагает слот следующему
+const WaitlistOfferWindow = 30 * time.Minute
+
+// waitlistCandidates ограничивает число заявок, которым пробуется предложить освободившийся слот
+const waitlistCandidates = 20
+
+// JoinWaitlist ставит пользователя в лист ожидания на интервал времени: в конкретную комнату
+// (req.RoomID) или в любую комнату, подходящую под критерии поиска. Если подходящая комната
+// уже свободна, возвращается ErrConflict — её можно забронировать сразу
+func (db *DB) JoinWaitlist(ctx context.Context, userID int, req models.JoinWaitlistRequest) (*models.WaitlistEntry, error) {
+	if !req.StartsAt.Before(req.EndsAt) {
+		return nil, fmt.Errorf("starts_at must be before ends_at: %w", ErrConflict)
+	}
+
+	if req.RoomID != nil {
+		if err := checkOpeningHours(ctx, db, *req.RoomID, req.StartsAt, req.EndsAt); err != nil {
+			return nil, err
+		}
+	}
+
+	// Заявка хранит оборудование идентификаторами, а город — как есть (сравнивается без учёта
+	// регистра); порядок в ней не сохраняется, поэтому и проверка свободных комнат идёт без него
+	equipmentIDs, err := resolveEquipment(ctx, db, req.EquipmentIDs, req.EquipmentNames)
+	if err != nil {
+		return nil, err
+	}
+	search := req.SearchRoomParams
+	search.EquipmentIDs, search.EquipmentNames = equipmentIDs, nil
+	search.Sort = ""
+
+	available, err := db.SearchAvailableRooms(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range available {
+		if req.RoomID == nil || *req.RoomID == r.RoomID {
+			return nil, fmt.Errorf("room %d is available for the requested time: %w", r.RoomID, ErrConflict)
+		}
+	}
+	paymentMethod := req.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = "card"
+	}
+
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO waitlist_entry (user_id, room_id, coworking_id, city, starts_at, ends_at, min_capacity, max_rate,
+		                            equipment_ids, payment_method)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + waitlistColumns
+	entry, err := scanWaitlistEntry(tx.QueryRowContext(ctx, query,
+		userID, req.RoomID, req.CoworkingID, req.City, req.StartsAt, req.EndsAt, req.MinCapacity, req.MaxRate,
+		pq.Array(equipmentIDs), paymentMethod))
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			if pqErr.Constraint == "fk_waitlist_entry_coworking" {
+				return nil, fmt.Errorf("coworking with id %d %w", *req.CoworkingID, ErrNotFound)
+			}
+			return nil, fmt.Errorf("room with id %d %w", *req.RoomID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return entry, nil
+}
+
+// GetUserWaitlist возвращает заявки пользователя в листе ожидания, новые первыми
+func (db *DB) GetUserWaitlist(ctx context.Context, userID int) ([]models.WaitlistEntry, error) {
+	query := `
+		SELECT ` + waitlistColumns + `
+		FROM waitlist_entry
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.WaitlistEntry
+	for rows.Next() {
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+// LeaveWaitlist снимает ожидающую заявку пользователя
+func (db *DB) LeaveWaitlist(ctx context.Context, entryID, userID int) error {
+	tx, err := db.BeginTx(ctx, txWrite)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE waitlist_entry
+		SET status = 'cancelled'
+		WHERE entry_id = $1 AND user_id = $2 AND status = 'waiting'
+	`, entryID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("waiting entry with id %d %w", entryID, ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// offerFreedSlotTx предлагает освободившийся интервал комнаты первой по очереди (FIFO)
+// подходящей заявке: для неё создаётся pending-бронирование с платежом, заявка переходит
+// в offered. Заявки блокируются FOR UPDATE без SKIP LOCKED, чтобы очередь не нарушалась,
+// а EXCLUDE constraint гарантирует, что один слот не достанется двоим.
+// Заявки, чей интервал всё ещё пересекается с другими бронированиями, пропускаются
+func offerFreedSlotTx(ctx context.Context, tx *sql.Tx, roomID int, startsAt, endsAt time.Time) (*models.WaitlistEntry, error) {
+	query := `
+		SELECT w.entry_id, w.user_id, w.starts_at, w.ends_at, w.payment_method
+		FROM waitlist_entry w
+		WHERE w.status = 'waiting'
+		  AND w.starts_at > NOW()
+		  AND tstzrange(w.starts_at, w.ends_at) && tstzrange($2, $3)
+		  AND (
+			w.room_id = $1
+			OR (w.room_id IS NULL AND EXISTS (
+				SELECT 1
+				FROM room r
+				JOIN coworking c ON r.coworking_id = c.coworking_id
+				WHERE r.room_id = $1
+				  AND (w.coworking_id IS NULL OR r.coworking_id = w.coworking_id)
+				  AND (w.city IS NULL OR lower(c.city) = lower(w.city))
+				  AND (w.min_capacity IS NULL OR r.capacity >= w.min_capacity)
+				  AND (w.max_rate IS NULL OR r.hourly_rate <= w.max_rate)
+				  AND (
+					SELECT COUNT(DISTINCT re.equipment_id)
+					FROM room_equipment re
+					WHERE re.room_id = r.room_id AND re.equipment_id = ANY(w.equipment_ids)
+				  ) = CARDINALITY(w.equipment_ids)
+			))
+		  )
+		ORDER BY w.created_at, w.entry_id
+		LIMIT $4
+		FOR UPDATE OF w
+	`
+	rows, err := tx.QueryContext(ctx, query, roomID, startsAt, endsAt, waitlistCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist candidates: %w", err)
+	}
+	var candidates []models.WaitlistEntry
+	for rows.Next() {
+		var c models.WaitlistEntry
+		if err := rows.Scan(&c.EntryID, &c.UserID, &c.StartsAt, &c.EndsAt, &c.PaymentMethod); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan waitlist candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read waitlist candidates: %w", err)
+	}
+
+	for _, c := range candidates {
+		var booking *models.Booking
+		err := withSavepoint(ctx, tx, func() error {
+			var err error
+			booking, _, err = createBookingWithPaymentTx(ctx, tx, roomID, c.UserID, c.StartsAt, c.EndsAt, c.PaymentMethod, nil, "")
+			return err
+		})
+		if errors.Is(err, ErrRoomUnavailable) || errors.Is(err, ErrOutsideOpeningHours) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Бесплатное бронирование подтверждено сразу — оплачивать нечего, заявка принята
+		query := `
+			UPDATE waitlist_entry
+			SET status = CASE WHEN $5 THEN 'accepted' ELSE 'offered' END,
+			    room_id = $2,
+			    offered_booking_id = $3,
+			    offer_expires_at = CASE WHEN $5 THEN NULL ELSE NOW() + make_interval(secs => $4) END
+			WHERE entry_id = $1
+			RETURNING ` + waitlistColumns
+		entry, err := scanWaitlistEntry(tx.QueryRowContext(ctx, query,
+			c.EntryID, roomID, booking.BookingID, WaitlistOfferWindow.Seconds(), booking.Status == "confirmed"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to offer waitlist slot: %w", err)
+		}
+		return entry, nil
+	}
+	return nil, nil
+}
+
+// releaseSlotTx закрывает предложение из листа ожидания, если отменённое бронирование было им
+// (status — declined или expired), и предлагает освободившийся интервал следующей заявке
+func releaseSlotTx(ctx context.Context, tx *sql.Tx, bookingID int, offerStatus string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE waitlist_entry
+		SET status = $2
+		WHERE offered_booking_id = $1 AND status = 'offered'
+	`, bookingID, offerStatus)
+	if err != nil {
+		return fmt.Errorf("failed to close waitlist offer: %w", err)
+	}
+
+	var roomID int
+	var startsAt, endsAt time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT room_id, starts_at, ends_at FROM booking WHERE booking_id = $1
+	`, bookingID).Scan(&roomID, &startsAt, &endsAt)
+	if err != nil {
+		return fmt.Errorf("failed to get released booking: %w", err)
+	}
+
+	if _, err := offerFreedSlotTx(ctx, tx, roomID, startsAt, endsAt); err != nil {
+		return err
+	}
+	return nil
+}
+
+// waitlistColumns вместе с полями заявки возвращает часовой пояс коворкинга её комнаты
+// (или коворкинга из критериев); заявки на любой коворкинг показываются в поясе по умолчанию
+const waitlistColumns = `entry_id, user_id, room_id, coworking_id, city, starts_at, ends_at, min_capacity, max_rate,
+		equipment_ids, payment_method, status, offered_booking_id, offer_expires_at, created_at,
+		COALESCE((
+			SELECT c.time_zone
+			FROM room r
+			JOIN coworking c ON r.coworking_id = c.coworking_id
+			WHERE r.room_id = waitlist_entry.room_id
+		), (
+			SELECT c.time_zone FROM coworking c WHERE c.coworking_id = waitlist_entry.coworking_id
+		), '` + localtime.DefaultZone + `')`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWaitlistEntry(row rowScanner) (*models.WaitlistEntry, error) {
+	var e models.WaitlistEntry
+	var equipmentIDs pq.Int64Array
+	err := row.Scan(&e.EntryID, &e.UserID, &e.RoomID, &e.CoworkingID, &e.City, &e.StartsAt, &e.EndsAt,
+		&e.MinCapacity, &e.MaxRate, &equipmentIDs, &e.PaymentMethod, &e.Status, &e.OfferedBookingID, &e.OfferExpiresAt, &e.CreatedAt, &e.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	e.StartsAt = localtime.In(e.StartsAt, e.TimeZone)
+	e.EndsAt = localtime.In(e.EndsAt, e.TimeZone)
+	if e.OfferExpiresAt != nil {
+		expires := localtime.In(*e.OfferExpiresAt, e.TimeZone)
+		e.OfferExpiresAt = &expires
+	}
+	e.EquipmentIDs = make([]int, len(equipmentIDs))
+	for i, id := range equipmentIDs {
+		e.EquipmentIDs[i] = int(id)
+	}
+	return &e, nil
+}
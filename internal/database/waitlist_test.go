@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+// joinWaitlist ставит нового пользователя в лист ожидания на [f.at(0), f.at(time.Hour))
+func (f *fixture) joinWaitlist(t *testing.T, req models.JoinWaitlistRequest) *models.WaitlistEntry {
+	t.Helper()
+	req.StartsAt, req.EndsAt = f.at(0), f.at(time.Hour)
+	entry, err := f.db.JoinWaitlist(context.Background(), f.user(t), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+// book бронирует комнату на [f.at(0), f.at(time.Hour)) от имени пользователя фикстуры
+func (f *fixture) book(t *testing.T, roomID int) int {
+	t.Helper()
+	booking, _, err := f.db.CreateBookingWithPayment(context.Background(), roomID, f.userID, f.at(0), f.at(time.Hour), "card", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return booking.BookingID
+}
+
+// waitlistEntry перечитывает заявку её владельца
+func (f *fixture) waitlistEntry(t *testing.T, e *models.WaitlistEntry) models.WaitlistEntry {
+	t.Helper()
+	entries, err := f.db.GetUserWaitlist(context.Background(), e.UserID)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("waitlist of user %d = %+v, %v", e.UserID, entries, err)
+	}
+	return entries[0]
+}
+
+func TestFreedSlotOfferedWithinCoworking(t *testing.T) {
+	f := newFixture(t)
+	other := newFixture(t) // коворкинг без комнат — свободных в нём нет
+	ctx := context.Background()
+	bookingID := f.book(t, f.room(t, "1000.00"))
+
+	// Более ранняя заявка на другой коворкинг не должна получить слот
+	elsewhere := f.joinWaitlist(t, models.JoinWaitlistRequest{
+		SearchRoomParams: models.SearchRoomParams{CoworkingID: &other.coworkingID},
+	})
+	if elsewhere.CoworkingID == nil || *elsewhere.CoworkingID != other.coworkingID {
+		t.Fatalf("coworking_id = %v, want %d", elsewhere.CoworkingID, other.coworkingID)
+	}
+	here := f.joinWaitlist(t, models.JoinWaitlistRequest{
+		SearchRoomParams: models.SearchRoomParams{CoworkingID: &f.coworkingID},
+	})
+
+	if _, err := f.db.CancelBookingWithRefund(ctx, bookingID, f.userID); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.waitlistEntry(t, elsewhere); got.Status != "waiting" {
+		t.Errorf("entry for another coworking: status %s, want waiting", got.Status)
+	}
+	got := f.waitlistEntry(t, here)
+	if got.Status != "offered" || got.OfferedBookingID == nil || got.OfferExpiresAt == nil {
+		t.Errorf("entry for this coworking = %+v, want an offer", got)
+	}
+}
+
+func TestFreedSlotOfferedWithinCity(t *testing.T) {
+	// Уникальные города, чтобы комнаты других тестов не попали в поиск
+	city, otherCity := uniqueName(t), uniqueName(t)
+	f := newFixtureIn(t, "Europe/Moscow", city)
+	ctx := context.Background()
+	bookingID := f.book(t, f.room(t, "1000.00"))
+
+	elsewhere := f.joinWaitlist(t, models.JoinWaitlistRequest{
+		SearchRoomParams: models.SearchRoomParams{City: &otherCity},
+	})
+	upper := strings.ToUpper(city)
+	here := f.joinWaitlist(t, models.JoinWaitlistRequest{
+		SearchRoomParams: models.SearchRoomParams{City: &upper},
+	})
+	if here.City == nil || *here.City != upper {
+		t.Fatalf("city = %v, want %s", here.City, upper)
+	}
+
+	if _, err := f.db.CancelBookingWithRefund(ctx, bookingID, f.userID); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.waitlistEntry(t, elsewhere); got.Status != "waiting" {
+		t.Errorf("entry for another city: status %s, want waiting", got.Status)
+	}
+	if got := f.waitlistEntry(t, here); got.Status != "offered" {
+		t.Errorf("entry for this city: status %s, want offered", got.Status)
+	}
+}
+
+func TestFreedSlotOfferedForSameRoom(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	freed, busy := f.room(t, "1000.00"), f.room(t, "1000.00")
+	bookingID := f.book(t, freed)
+	f.book(t, busy)
+
+	otherRoom := f.joinWaitlist(t, models.JoinWaitlistRequest{RoomID: &busy})
+	sameRoom := f.joinWaitlist(t, models.JoinWaitlistRequest{RoomID: &freed})
+
+	if _, err := f.db.CancelBookingWithRefund(ctx, bookingID, f.userID); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.waitlistEntry(t, otherRoom); got.Status != "waiting" || *got.RoomID != busy {
+		t.Errorf("entry for another room = %+v, want waiting", got)
+	}
+	if got := f.waitlistEntry(t, sameRoom); got.Status != "offered" || *got.RoomID != freed {
+		t.Errorf("entry for the freed room = %+v, want an offer", got)
+	}
+}
@@ -0,0 +1,67 @@
+// Package invoice печатает счета и квитанции об оплате (models.Invoice) в HTML,
+// пригодный для просмотра в браузере, печати и отправки по почте.
+package invoice
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"coworking-booking/internal/models"
+)
+
+//go:embed invoice.html.tmpl
+var templates embed.FS
+
+var page = template.Must(template.New("invoice.html.tmpl").Funcs(template.FuncMap{
+	"date":     func(t time.Time) string { return t.Format("02.01.2006") },
+	"datetime": func(t time.Time) string { return t.Format("02.01.2006 15:04") },
+	"hours":    func(minutes int) string { return fmt.Sprintf("%d:%02d", minutes/60, minutes%60) },
+	"inc":      func(i int) int { return i + 1 },
+	"method":   MethodName,
+}).ParseFS(templates, "invoice.html.tmpl"))
+
+// Title возвращает заголовок документа: «Счёт № …» или «Квитанция об оплате № …»
+func Title(inv *models.Invoice) string {
+	if inv.Kind == "receipt" {
+		return "Квитанция об оплате № " + inv.Number
+	}
+	return "Счёт на оплату № " + inv.Number
+}
+
+// MethodName возвращает название способа оплаты для документа
+func MethodName(method *string) string {
+	if method == nil {
+		return "не указан"
+	}
+	switch *method {
+	case "card":
+		return "банковская карта"
+	case "cash":
+		return "наличные"
+	case "bank_transfer":
+		return "банковский перевод"
+	default:
+		return *method
+	}
+}
+
+// RenderHTML записывает документ в w самостоятельной HTML-страницей
+func RenderHTML(w io.Writer, inv *models.Invoice) error {
+	data := struct {
+		*models.Invoice
+		Title     string
+		Cancelled bool
+	}{
+		Invoice: inv,
+		Title:   Title(inv),
+		// Счёт по отменённому или отклонённому платежу оплачивать уже не нужно
+		Cancelled: inv.Kind == "invoice" && (inv.PaymentStatus == "cancelled" || inv.PaymentStatus == "failed"),
+	}
+	if err := page.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return nil
+}
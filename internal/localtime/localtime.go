@@ -0,0 +1,80 @@
+// Package localtime переводит время между абсолютными моментами (timestamptz в БД)
+// и местным временем коворкинга, заданным часовым поясом IANA.
+package localtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultZone — часовой пояс коворкинга по умолчанию
+const DefaultZone = "Europe/Moscow"
+
+// Layout — формат ввода и вывода местного времени в CLI
+const Layout = "2006-01-02 15:04"
+
+var locations sync.Map // map[string]*time.Location
+
+// Location возвращает часовой пояс по имени IANA; загруженные пояса кэшируются
+func Location(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// Validate проверяет, что name — известный часовой пояс IANA, включая UTC и его синонимы.
+// Local не допускается: он зависит от настроек сервера
+func Validate(name string) error {
+	if name == "" || name == "Local" {
+		return fmt.Errorf("unknown time zone %q", name)
+	}
+	_, err := Location(name)
+	return err
+}
+
+// Parse разбирает местное время value в формате layout в часовом поясе zone.
+// Для несуществующего времени (переход на летнее) time.Date сдвигает его вперёд
+func Parse(layout, value, zone string) (time.Time, error) {
+	loc, err := Location(zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// In переводит t в часовой пояс zone; при неизвестном поясе t возвращается без изменений
+func In(t time.Time, zone string) time.Time {
+	loc, err := Location(zone)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// Wall возвращает показания часов t (без учёта пояса) как время в UTC.
+// Разность двух Wall — сдвиг «по часам на стене», не зависящий от переходов на летнее время
+func Wall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// FromWall — обратное к Wall: показания часов w в часовом поясе loc
+func FromWall(w time.Time, loc *time.Location) time.Time {
+	return time.Date(w.Year(), w.Month(), w.Day(), w.Hour(), w.Minute(), w.Second(), w.Nanosecond(), loc)
+}
+
+// ShiftWall сдвигает t на d по местным часам: «10:00 + 1ч» остаётся 11:00 и в день перехода
+func ShiftWall(t time.Time, d time.Duration) time.Time {
+	return FromWall(Wall(t).Add(d), t.Location())
+}
+
+// WallString форматирует показания часов t для передачи в SQL как timestamp без пояса
+func WallString(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05.999999")
+}
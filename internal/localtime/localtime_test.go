@@ -0,0 +1,23 @@
+package localtime
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"Europe/Moscow", false},
+		{"UTC", false},
+		{"Etc/UTC", false},
+		{"Zulu", false},
+		{"", true},
+		{"Local", true},
+		{"Mars/Olympus", true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
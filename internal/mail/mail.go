@@ -0,0 +1,105 @@
+// Package mail отправляет письма: через SMTP-сервер или, если он не настроен,
+// в журнал приложения (для локального запуска).
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message — письмо с HTML-телом
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+}
+
+// Sender отправляет письма
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config содержит параметры SMTP-сервера; пустой Host — письма пишутся в журнал
+type Config struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+}
+
+// New создаёт отправителя по конфигурации
+func New(cfg Config) Sender {
+	if cfg.Host == "" {
+		return logSender{}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &smtpSender{cfg: cfg}
+}
+
+// logSender записывает письмо в журнал вместо отправки
+type logSender struct{}
+
+func (logSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail (SMTP is not configured): to=%s subject=%q size=%d", msg.To, msg.Subject, len(msg.HTML))
+	return nil
+}
+
+// smtpSender отправляет письма через SMTP с авторизацией PLAIN (STARTTLS, если сервер его поддерживает)
+type smtpSender struct {
+	cfg Config
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient %q", msg.To)
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	body.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.HTML))
+	for len(encoded) > 76 {
+		body.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	body.WriteString(encoded + "\r\n")
+
+	var auth smtp.Auth
+	if s.cfg.User != "" {
+		auth = smtp.PlainAuth("", s.cfg.User, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	// smtp.SendMail не принимает контекст: отправка выполняется в горутине, а ожидание
+	// прерывается по ctx (соединение дорабатывает в фоне)
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.cfg.From, []string{msg.To}, body.Bytes())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
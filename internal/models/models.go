@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"coworking-booking/internal/money"
+)
 
 // User представляет пользователя системы
 type User struct {
@@ -12,29 +16,91 @@ type User struct {
 	CreatedAt    time.Time `json:"created_at"`
 }
 
+// Session представляет сессию пользователя, выданную при входе
+type Session struct {
+	SessionID int        `json:"session_id"`
+	UserID    int        `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// RegisterRequest представляет запрос на регистрацию
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"`
+}
+
+// LoginRequest представляет запрос на вход
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// LoginResponse представляет выданный при входе токен
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+// SetRoleRequest представляет запрос на смену роли пользователя
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// AssignManagerRequest представляет запрос на назначение менеджера коворкинга
+type AssignManagerRequest struct {
+	UserID int `json:"user_id"`
+}
+
 // Coworking представляет коворкинг-пространство
 type Coworking struct {
-	CoworkingID int       `json:"coworking_id"`
-	Name        string    `json:"name"`
-	Address     string    `json:"address"`
-	Description *string   `json:"description,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
+	CoworkingID int        `json:"coworking_id"`
+	Name        string     `json:"name"`
+	Address     string     `json:"address"`
+	City        string     `json:"city"` // первая часть адреса до запятой
+	Description *string    `json:"description,omitempty"`
+	TimeZone    string     `json:"time_zone"`
+	LegalName   *string    `json:"legal_name,omitempty"` // юридическое лицо в счетах; нет — название коворкинга
+	TaxID       *string    `json:"tax_id,omitempty"`     // ИНН
+	VATPercent  int        `json:"vat_percent"`          // НДС, включённый в цены; 0 — без НДС
+	CreatedAt   time.Time  `json:"created_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"` // коворкинг закрыт; нет — работает
+}
+
+// LegalEntityRequest представляет реквизиты юридического лица коворкинга для счетов
+type LegalEntityRequest struct {
+	LegalName  *string `json:"legal_name"`
+	TaxID      *string `json:"tax_id"`
+	VATPercent int     `json:"vat_percent"`
 }
 
 // Room представляет переговорную комнату
 type Room struct {
-	RoomID       int      `json:"room_id"`
-	CoworkingID  int      `json:"coworking_id"`
-	Name         string   `json:"name"`
-	Capacity     int      `json:"capacity"`
-	AreaSqm      *float64 `json:"area_sqm,omitempty"`
-	HourlyRate   float64  `json:"hourly_rate"`
-	CreatedAt    time.Time `json:"created_at"`
+	RoomID      int         `json:"room_id"`
+	CoworkingID int         `json:"coworking_id"`
+	Name        string      `json:"name"`
+	Capacity    int         `json:"capacity"`
+	AreaSqm     *float64    `json:"area_sqm,omitempty"`
+	HourlyRate  money.Money `json:"hourly_rate"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ArchivedAt  *time.Time  `json:"archived_at,omitempty"` // комната выведена из работы; нет — работает
+
+	// Буферы подготовки и уборки вокруг каждого бронирования, минуты; не оплачиваются
+	SetupMinutes    int `json:"setup_minutes"`
+	TeardownMinutes int `json:"teardown_minutes"`
 
 	// Дополнительные поля для представления
 	CoworkingName    string   `json:"coworking_name,omitempty"`
 	CoworkingAddress string   `json:"coworking_address,omitempty"`
+	TimeZone         string   `json:"time_zone,omitempty"`
 	EquipmentList    []string `json:"equipment_list,omitempty"`
+	EquipmentIDs     []int    `json:"equipment_ids,omitempty"` // в том же порядке, что и EquipmentList
+
+	// Стоимость запрошенного интервала по тарифу комнаты (только в результатах поиска)
+	QuotedPrice *money.Money `json:"quoted_price,omitempty"`
 }
 
 // Equipment представляет тип оборудования
@@ -46,75 +112,534 @@ type Equipment struct {
 
 // Booking представляет бронирование
 type Booking struct {
-	BookingID   int       `json:"booking_id"`
-	RoomID      int       `json:"room_id"`
-	UserID      int       `json:"user_id"`
-	StartsAt    time.Time `json:"starts_at"`
-	EndsAt      time.Time `json:"ends_at"`
-	TotalAmount float64   `json:"total_amount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	BookingID   int         `json:"booking_id"`
+	RoomID      int         `json:"room_id"`
+	UserID      int         `json:"user_id"`
+	StartsAt    time.Time   `json:"starts_at"`
+	EndsAt      time.Time   `json:"ends_at"`
+	TotalAmount money.Money `json:"total_amount"`
+	Status      string      `json:"status"`
+	SeriesID    *int        `json:"series_id,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// Комната или коворкинг архивированы: бронирование нужно перенести или отменить
+	NeedsRelocation bool `json:"needs_relocation,omitempty"`
 
 	// Дополнительные поля для детального представления
-	RoomName         string  `json:"room_name,omitempty"`
-	CoworkingName    string  `json:"coworking_name,omitempty"`
-	CoworkingAddress string  `json:"coworking_address,omitempty"`
-	UserName         string  `json:"user_name,omitempty"`
-	UserEmail        string  `json:"user_email,omitempty"`
-	PaymentStatus    *string `json:"payment_status,omitempty"`
-	PaidAt           *time.Time `json:"paid_at,omitempty"`
+	RoomName         string      `json:"room_name,omitempty"`
+	CoworkingName    string      `json:"coworking_name,omitempty"`
+	CoworkingAddress string      `json:"coworking_address,omitempty"`
+	UserName         string      `json:"user_name,omitempty"`
+	UserEmail        string      `json:"user_email,omitempty"`
+	PaymentStatus    *string     `json:"payment_status,omitempty"`
+	PaidAt           *time.Time  `json:"paid_at,omitempty"`
+	TimeZone         string      `json:"time_zone,omitempty"`   // часовой пояс коворкинга, в нём заданы StartsAt/EndsAt
+	PriceLines       []PriceLine `json:"price_lines,omitempty"` // расчёт total_amount
 }
 
 // Payment представляет платёж
 type Payment struct {
-	PaymentID     int        `json:"payment_id"`
-	BookingID     int        `json:"booking_id"`
-	Amount        float64    `json:"amount"`
-	Status        string     `json:"status"`
-	PaymentMethod *string    `json:"payment_method,omitempty"`
-	PaidAt        *time.Time `json:"paid_at,omitempty"`
-	CreatedAt     time.Time  `json:"created_at"`
+	PaymentID     int         `json:"payment_id"`
+	BookingID     int         `json:"booking_id"`
+	Amount        money.Money `json:"amount"`
+	Status        string      `json:"status"`
+	PaymentMethod *string     `json:"payment_method,omitempty"`
+	PaidAt        *time.Time  `json:"paid_at,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	Provider      *string     `json:"provider,omitempty"`       // платёжный шлюз; нет — подтверждается вручную
+	ProviderRef   *string     `json:"provider_ref,omitempty"`   // намерение оплаты в шлюзе
+	FailureReason *string     `json:"failure_reason,omitempty"` // причина отказа шлюза
+}
+
+// Invoice представляет счёт (Kind = invoice) или квитанцию об оплате (Kind = receipt)
+// по платежу. Документ — снимок на момент выдачи; PaymentStatus — текущий статус платежа
+type Invoice struct {
+	InvoiceID     int           `json:"invoice_id"`
+	Kind          string        `json:"kind"`
+	Number        string        `json:"number"` // <коворкинг>-<номер>, номер сквозной в пределах коворкинга и вида
+	CoworkingID   int           `json:"coworking_id"`
+	BookingID     int           `json:"booking_id"`
+	PaymentID     int           `json:"payment_id"`
+	PaymentStatus string        `json:"payment_status"`
+	IssuedAt      time.Time     `json:"issued_at"`
+	SellerName    string        `json:"seller_name"`
+	SellerTaxID   *string       `json:"seller_tax_id,omitempty"`
+	SellerAddress string        `json:"seller_address"`
+	BuyerName     string        `json:"buyer_name"`
+	BuyerEmail    string        `json:"buyer_email"`
+	RoomName      string        `json:"room_name"`
+	StartsAt      time.Time     `json:"starts_at"`
+	EndsAt        time.Time     `json:"ends_at"`
+	TimeZone      string        `json:"time_zone"`
+	BookingTotal  money.Money   `json:"booking_total"`
+	Amount        money.Money   `json:"amount"` // сумма платежа, НДС включён
+	VATPercent    int           `json:"vat_percent"`
+	VATAmount     money.Money   `json:"vat_amount"`
+	PaymentMethod *string       `json:"payment_method,omitempty"`
+	PaidAt        *time.Time    `json:"paid_at,omitempty"`
+	Lines         []InvoiceLine `json:"lines,omitempty"`
+}
+
+// InvoiceLine представляет строку расчёта стоимости в документе
+type InvoiceLine struct {
+	Description string      `json:"description"`
+	Minutes     int         `json:"minutes"`
+	HourlyRate  money.Money `json:"hourly_rate"`
+	Amount      money.Money `json:"amount"`
+}
+
+// InvoiceEmailRequest представляет запрос на отправку документа по почте
+type InvoiceEmailRequest struct {
+	To string `json:"to,omitempty"` // по умолчанию — email покупателя
+}
+
+// InvoiceEmailResult представляет результат отправки документа
+type InvoiceEmailResult struct {
+	InvoiceID int    `json:"invoice_id"`
+	To        string `json:"to"`
+}
+
+// PaymentEventResult представляет результат обработки уведомления платёжного шлюза
+type PaymentEventResult struct {
+	EventID       string   `json:"event_id"`
+	EventType     string   `json:"event_type"`
+	Outcome       string   `json:"outcome"`   // confirmed, paid, failed, refunded или ignored
+	Duplicate     bool     `json:"duplicate"` // событие уже было обработано, повтор ничего не изменил
+	Payment       *Payment `json:"payment,omitempty"`
+	Refunds       []Refund `json:"refunds,omitempty"`
+	BookingStatus string   `json:"booking_status,omitempty"`
+}
+
+// AddPaymentRequest представляет запрос на новый платёж по бронированию (депозит, оплата частями)
+type AddPaymentRequest struct {
+	Amount        *money.Money `json:"amount,omitempty"` // по умолчанию — весь неоплаченный остаток
+	PaymentMethod string       `json:"payment_method"`
+}
+
+// BookingBalance — баланс бронирования по всем платежам и возвратам
+type BookingBalance struct {
+	BookingID      int         `json:"booking_id"`
+	TotalAmount    money.Money `json:"total_amount"`
+	PaidAmount     money.Money `json:"paid_amount"`
+	PendingAmount  money.Money `json:"pending_amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Balance        money.Money `json:"balance"`    // оплачено за вычетом возвратов
+	AmountDue      money.Money `json:"amount_due"` // total_amount - balance; < 0 — переплата
+	PaymentStatus  string      `json:"payment_status"`
+}
+
+// BookingLedger представляет платежи и возвраты бронирования с балансом
+type BookingLedger struct {
+	Balance  *BookingBalance `json:"balance"`
+	Payments []Payment       `json:"payments"`
+	Refunds  []Refund        `json:"refunds"`
 }
 
 // RoomOccupancy представляет отчёт о загрузке комнаты
 type RoomOccupancy struct {
-	RoomID             int     `json:"room_id"`
-	RoomName           string  `json:"room_name"`
-	CoworkingName      string  `json:"coworking_name"`
-	TotalBookings      int     `json:"total_bookings"`
-	BookedHours        float64 `json:"booked_hours"`
-	TotalHours         float64 `json:"total_hours"`
+	RoomID              int     `json:"room_id"`
+	RoomName            string  `json:"room_name"`
+	CoworkingName       string  `json:"coworking_name"`
+	TotalBookings       int     `json:"total_bookings"`
+	BookedHours         float64 `json:"booked_hours"`
+	TotalHours          float64 `json:"total_hours"`    // часы работы комнаты за период (по расписанию и праздникам)
+	BlackoutHours       float64 `json:"blackout_hours"` // часы работы, закрытые блокировками
 	OccupancyPercentage float64 `json:"occupancy_percentage"`
 }
 
 // RevenueReport представляет отчёт о выручке
 type RevenueReport struct {
-	CoworkingID      int     `json:"coworking_id"`
-	CoworkingName    string  `json:"coworking_name"`
-	Address          string  `json:"address"`
-	TotalBookings    int     `json:"total_bookings"`
-	TotalRevenue     float64 `json:"total_revenue"`
-	ConfirmedRevenue float64 `json:"confirmed_revenue"`
-	PendingRevenue   float64 `json:"pending_revenue"`
-	RefundedAmount   float64 `json:"refunded_amount,omitempty"`
+	CoworkingID      int         `json:"coworking_id"`
+	CoworkingName    string      `json:"coworking_name"`
+	Address          string      `json:"address"`
+	TotalBookings    int         `json:"total_bookings"`
+	TotalRevenue     money.Money `json:"total_revenue"`
+	ConfirmedRevenue money.Money `json:"confirmed_revenue"`
+	PendingRevenue   money.Money `json:"pending_revenue"`
+	RefundedAmount   money.Money `json:"refunded_amount,omitempty"`
+	PromoDiscount    money.Money `json:"promo_discount"` // скидки по промокодам неотменённых бронирований
+
+	// Из чего сложилась стоимость активных и завершённых бронирований периода
+	PriceBreakdown []PriceBreakdown `json:"price_breakdown"`
+}
+
+// PriceBreakdown — сумма строк расчёта цены одного вида и описания
+type PriceBreakdown struct {
+	Kind        string      `json:"kind"`
+	Description string      `json:"description"`
+	Amount      money.Money `json:"amount"`
 }
 
 // SearchRoomParams представляет параметры поиска комнат
 type SearchRoomParams struct {
-	StartsAt     time.Time `json:"starts_at"`
-	EndsAt       time.Time `json:"ends_at"`
-	EquipmentIDs []int     `json:"equipment_ids,omitempty"`
-	MinCapacity  *int      `json:"min_capacity,omitempty"`
-	MaxRate      *float64  `json:"max_rate,omitempty"`
+	StartsAt       time.Time    `json:"starts_at"`
+	EndsAt         time.Time    `json:"ends_at"`
+	CoworkingID    *int         `json:"coworking_id,omitempty"`
+	City           *string      `json:"city,omitempty"` // без учёта регистра
+	EquipmentIDs   []int        `json:"equipment_ids,omitempty"`
+	EquipmentNames []string     `json:"equipment_names,omitempty"` // без учёта регистра, вместе с EquipmentIDs
+	MinCapacity    *int         `json:"min_capacity,omitempty"`
+	MaxRate        *money.Money `json:"max_rate,omitempty"`
+	Sort           string       `json:"sort,omitempty"` // price (по умолчанию), capacity, area; «-» — по убыванию
 }
 
 // CreateBookingRequest представляет запрос на создание бронирования
+// Пользователь берётся из сессии, а не из тела запроса
 type CreateBookingRequest struct {
-	RoomID   int       `json:"room_id"`
-	UserID   int       `json:"user_id"`
+	RoomID        int       `json:"room_id"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	PaymentMethod string    `json:"payment_method,omitempty"`
+	PromoCode     string    `json:"promo_code,omitempty"`
+}
+
+// RescheduleBookingRequest представляет запрос на перенос или продление бронирования.
+// Незаданные поля сохраняют текущее значение
+type RescheduleBookingRequest struct {
+	RoomID   *int       `json:"room_id,omitempty"`
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+}
+
+// RescheduleResult представляет результат переноса бронирования
+type RescheduleResult struct {
+	Booking  *Booking        `json:"booking"`
+	Payments []Payment       `json:"payments,omitempty"` // созданные или изменённые ожидающие платежи
+	Refunds  []Refund        `json:"refunds,omitempty"`  // возврат разницы по оплаченным платежам
+	Balance  *BookingBalance `json:"balance,omitempty"`  // нет, если по бронированию не создавались платежи
+}
+
+// BookingWithPayment представляет бронирование вместе с созданным платежом
+type BookingWithPayment struct {
+	Booking *Booking `json:"booking"`
+	Payment *Payment `json:"payment"`
+}
+
+// BookingSeries представляет серию повторяющихся бронирований
+type BookingSeries struct {
+	SeriesID  int       `json:"series_id"`
+	UserID    int       `json:"user_id"`
+	RoomID    int       `json:"room_id"`
+	RRule     string    `json:"rrule"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Status    string    `json:"status"`
+	TimeZone  string    `json:"time_zone,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateSeriesRequest представляет запрос на создание серии бронирований.
+// StartsAt/EndsAt задают первое вхождение, RRule — правило повторения
+type CreateSeriesRequest struct {
+	RoomID        int       `json:"room_id"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	RRule         string    `json:"rrule"`
+	PaymentMethod string    `json:"payment_method"`
+}
+
+// SeriesConflict описывает пропущенное вхождение серии
+type SeriesConflict struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"` // room_unavailable — комната занята, closed — вне часов работы
+}
+
+// SeriesResult представляет серию вместе с её вхождениями
+type SeriesResult struct {
+	Series    *BookingSeries       `json:"series"`
+	Bookings  []BookingWithPayment `json:"bookings"`
+	Conflicts []SeriesConflict     `json:"conflicts,omitempty"`
+}
+
+// Области применения изменения серии
+const (
+	SeriesScopeThis      = "this"
+	SeriesScopeFollowing = "following"
+	SeriesScopeAll       = "all"
+)
+
+// UpdateSeriesRequest представляет запрос на изменение вхождений серии.
+// BookingID — вхождение, относительно которого задаются новые время и комната;
+// для following/all сдвиг начала и новая длительность применяются к остальным вхождениям
+type UpdateSeriesRequest struct {
+	BookingID int        `json:"booking_id"`
+	Scope     string     `json:"scope"`
+	RoomID    *int       `json:"room_id,omitempty"`
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+}
+
+// CancelSeriesRequest представляет запрос на отмену серии.
+// FromBookingID — отменить это вхождение и следующие; nil — все будущие вхождения
+type CancelSeriesRequest struct {
+	FromBookingID *int `json:"from_booking_id,omitempty"`
+}
+
+// WaitlistEntry представляет заявку в листе ожидания.
+// RoomID задаёт конкретную комнату; если он пуст, подходит любая комната по критериям поиска
+// (CoworkingID и City ограничивают их одним коворкингом или городом)
+type WaitlistEntry struct {
+	EntryID          int          `json:"entry_id"`
+	UserID           int          `json:"user_id"`
+	RoomID           *int         `json:"room_id,omitempty"`
+	CoworkingID      *int         `json:"coworking_id,omitempty"`
+	City             *string      `json:"city,omitempty"`
+	StartsAt         time.Time    `json:"starts_at"`
+	EndsAt           time.Time    `json:"ends_at"`
+	MinCapacity      *int         `json:"min_capacity,omitempty"`
+	MaxRate          *money.Money `json:"max_rate,omitempty"`
+	EquipmentIDs     []int        `json:"equipment_ids"`
+	PaymentMethod    string       `json:"payment_method"`
+	Status           string       `json:"status"`
+	OfferedBookingID *int         `json:"offered_booking_id,omitempty"`
+	OfferExpiresAt   *time.Time   `json:"offer_expires_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	TimeZone         string       `json:"time_zone"`
+}
+
+// JoinWaitlistRequest представляет запрос на постановку в лист ожидания:
+// конкретная комната (RoomID) или критерии поиска SearchRoomParams
+type JoinWaitlistRequest struct {
+	SearchRoomParams
+	RoomID        *int   `json:"room_id,omitempty"`
+	PaymentMethod string `json:"payment_method"`
+}
+
+// OpeningHours представляет интервал работы в день недели по местному времени коворкинга
+type OpeningHours struct {
+	Weekday  int    `json:"weekday"`   // ISO: 1 — понедельник, 7 — воскресенье
+	OpensAt  string `json:"opens_at"`  // HH:MM
+	ClosesAt string `json:"closes_at"` // HH:MM; 24:00 — до конца суток
+}
+
+// SetOpeningHoursRequest представляет запрос на замену недельного расписания.
+// Пустой список у комнаты означает, что она работает по расписанию коворкинга
+type SetOpeningHoursRequest struct {
+	Hours []OpeningHours `json:"hours"`
+}
+
+// Holiday представляет праздничный или сокращённый день коворкинга.
+// Без OpensAt/ClosesAt коворкинг закрыт весь день
+type Holiday struct {
+	HolidayID   int     `json:"holiday_id"`
+	CoworkingID int     `json:"coworking_id"`
+	Day         string  `json:"day"` // YYYY-MM-DD
+	Name        string  `json:"name"`
+	OpensAt     *string `json:"opens_at,omitempty"`
+	ClosesAt    *string `json:"closes_at,omitempty"`
+}
+
+// CreateHolidayRequest представляет запрос на добавление праздничного дня
+type CreateHolidayRequest struct {
+	Day      string  `json:"day"`
+	Name     string  `json:"name"`
+	OpensAt  *string `json:"opens_at,omitempty"`
+	ClosesAt *string `json:"closes_at,omitempty"`
+}
+
+// Blackout представляет период, когда комната (RoomID) или все комнаты коворкинга
+// (CoworkingID) недоступны для бронирования: уборка, ремонт, закрытое мероприятие
+type Blackout struct {
+	BlackoutID  int       `json:"blackout_id"`
+	RoomID      *int      `json:"room_id,omitempty"`
+	CoworkingID *int      `json:"coworking_id,omitempty"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	Reason      string    `json:"reason"`
+	CreatedBy   int       `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	TimeZone    string    `json:"time_zone,omitempty"` // часовой пояс коворкинга, в нём заданы StartsAt/EndsAt
+}
+
+// CreateBlackoutRequest представляет запрос на создание блокировки
+type CreateBlackoutRequest struct {
 	StartsAt time.Time `json:"starts_at"`
 	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+// BlackoutResult представляет созданную блокировку и активные бронирования,
+// которые с ней пересекаются: их менеджер должен перенести или отменить
+type BlackoutResult struct {
+	Blackout            *Blackout `json:"blackout"`
+	ConflictingBookings []Booking `json:"conflicting_bookings"`
+}
+
+// Виды правил цены
+const (
+	PricingTimeWindow       = "time_window"       // пиковые/непиковые часы
+	PricingWeekend          = "weekend"           // надбавка за субботу и воскресенье
+	PricingMinDuration      = "min_duration"      // минимальная оплачиваемая длительность
+	PricingDurationDiscount = "duration_discount" // скидка за длинный блок
+)
+
+// PricingRule представляет правило цены коворкинга или комнаты. Правила комнаты
+// заменяют правила коворкинга того же вида
+type PricingRule struct {
+	PricingRuleID   int     `json:"pricing_rule_id"`
+	CoworkingID     *int    `json:"coworking_id,omitempty"`
+	RoomID          *int    `json:"room_id,omitempty"`
+	Kind            string  `json:"kind"`
+	Name            string  `json:"name"`
+	Weekdays        []int   `json:"weekdays,omitempty"`  // time_window: дни ISO; пусто — все дни
+	OpensAt         *string `json:"opens_at,omitempty"`  // time_window, HH:MM
+	ClosesAt        *string `json:"closes_at,omitempty"` // time_window, HH:MM
+	RatePercent     *int    `json:"rate_percent,omitempty"`
+	MinMinutes      *int    `json:"min_minutes,omitempty"`
+	DiscountPercent *int    `json:"discount_percent,omitempty"`
+}
+
+// CreatePricingRuleRequest представляет запрос на создание правила цены
+type CreatePricingRuleRequest struct {
+	Kind            string  `json:"kind"`
+	Name            string  `json:"name"`
+	Weekdays        []int   `json:"weekdays,omitempty"`
+	OpensAt         *string `json:"opens_at,omitempty"`
+	ClosesAt        *string `json:"closes_at,omitempty"`
+	RatePercent     *int    `json:"rate_percent,omitempty"`
+	MinMinutes      *int    `json:"min_minutes,omitempty"`
+	DiscountPercent *int    `json:"discount_percent,omitempty"`
+}
+
+// PriceLine представляет строку расчёта стоимости бронирования
+type PriceLine struct {
+	Kind        string      `json:"kind"` // time, minimum, discount, promo
+	Description string      `json:"description"`
+	Minutes     int         `json:"minutes"`
+	HourlyRate  money.Money `json:"hourly_rate"`
+	Amount      money.Money `json:"amount"`
+}
+
+// PromoCode представляет промокод: скидка DiscountPercent процентов или DiscountAmount рублей.
+// Без CoworkingID и RoomID действует во всех коворкингах
+type PromoCode struct {
+	PromoCodeID     int          `json:"promo_code_id"`
+	Code            string       `json:"code"`
+	Description     *string      `json:"description,omitempty"`
+	DiscountPercent *int         `json:"discount_percent,omitempty"`
+	DiscountAmount  *money.Money `json:"discount_amount,omitempty"`
+	ValidFrom       time.Time    `json:"valid_from"`
+	ValidUntil      *time.Time   `json:"valid_until,omitempty"`
+	MaxRedemptions  *int         `json:"max_redemptions,omitempty"`
+	MaxPerUser      *int         `json:"max_per_user,omitempty"`
+	CoworkingID     *int         `json:"coworking_id,omitempty"`
+	RoomID          *int         `json:"room_id,omitempty"`
+	IsActive        bool         `json:"is_active"`
+	Redemptions     int          `json:"redemptions"` // погашения без учёта отменённых бронирований
+	CreatedBy       int          `json:"created_by"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// CreatePromoCodeRequest представляет запрос на создание промокода
+type CreatePromoCodeRequest struct {
+	Code            string       `json:"code"`
+	Description     *string      `json:"description,omitempty"`
+	DiscountPercent *int         `json:"discount_percent,omitempty"`
+	DiscountAmount  *money.Money `json:"discount_amount,omitempty"`
+	ValidFrom       *time.Time   `json:"valid_from,omitempty"` // по умолчанию — сейчас
+	ValidUntil      *time.Time   `json:"valid_until,omitempty"`
+	MaxRedemptions  *int         `json:"max_redemptions,omitempty"`
+	MaxPerUser      *int         `json:"max_per_user,omitempty"`
+	CoworkingID     *int         `json:"coworking_id,omitempty"`
+	RoomID          *int         `json:"room_id,omitempty"`
+}
+
+// CancellationTier — ступень политики отмены: при отмене не позднее чем за MinHoursBefore часов
+// до начала возвращается RefundPercent процентов оплаты
+type CancellationTier struct {
+	MinHoursBefore int `json:"min_hours_before"`
+	RefundPercent  int `json:"refund_percent"`
+}
+
+// SetCancellationPolicyRequest представляет запрос на замену политики отмены;
+// пустой список у комнаты — политика коворкинга, у коворкинга — полный возврат
+type SetCancellationPolicyRequest struct {
+	Tiers []CancellationTier `json:"tiers"`
+}
+
+// CancellationQuote — расчёт возврата при отмене бронирования сейчас
+type CancellationQuote struct {
+	BookingID     int                `json:"booking_id"`
+	StartsAt      time.Time          `json:"starts_at"`
+	HoursBefore   float64            `json:"hours_before"` // отрицательное — бронирование уже началось
+	Policy        []CancellationTier `json:"policy"`       // пусто — полный возврат
+	RefundPercent int                `json:"refund_percent"`
+	PaidAmount    money.Money        `json:"paid_amount"`
+	RefundAmount  money.Money        `json:"refund_amount"`
+}
+
+// Refund представляет возврат по оплаченному платежу
+type Refund struct {
+	RefundID      int         `json:"refund_id"`
+	BookingID     int         `json:"booking_id"`
+	PaymentID     int         `json:"payment_id"`
+	Amount        money.Money `json:"amount"`
+	RefundPercent *int        `json:"refund_percent,omitempty"` // процент политики отмены; нет у возврата разницы при переносе
+	Reason        string      `json:"reason"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// CancellationResult представляет результат отмены бронирования
+type CancellationResult struct {
+	Quote   *CancellationQuote `json:"quote"`
+	Refunds []Refund           `json:"refunds,omitempty"` // по одному на оплаченный платёж; нет, если оплаты не было или возврат 0%
+}
+
+// CreateCoworkingRequest представляет запрос на создание коворкинга
+type CreateCoworkingRequest struct {
+	Name        string  `json:"name"`
+	Address     string  `json:"address"`
+	Description *string `json:"description,omitempty"`
+	TimeZone    string  `json:"time_zone,omitempty"` // IANA, по умолчанию Europe/Moscow
+}
+
+// CreateRoomRequest представляет запрос на создание комнаты
+type CreateRoomRequest struct {
+	Name       string      `json:"name"`
+	Capacity   int         `json:"capacity"`
+	AreaSqm    *float64    `json:"area_sqm,omitempty"`
+	HourlyRate money.Money `json:"hourly_rate"`
+}
+
+// UpdateCoworkingRequest представляет запрос на изменение коворкинга; nil — поле не меняется,
+// пустое описание удаляет его
+type UpdateCoworkingRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Address     *string `json:"address,omitempty"`
+	Description *string `json:"description,omitempty"`
+	TimeZone    *string `json:"time_zone,omitempty"`
+}
+
+// UpdateRoomRequest представляет запрос на изменение комнаты; nil — поле не меняется
+type UpdateRoomRequest struct {
+	Name       *string      `json:"name,omitempty"`
+	Capacity   *int         `json:"capacity,omitempty"`
+	AreaSqm    *float64     `json:"area_sqm,omitempty"`
+	HourlyRate *money.Money `json:"hourly_rate,omitempty"`
+}
+
+// ArchiveResult представляет результат архивирования коворкинга или комнаты: предстоящие
+// бронирования, отмеченные для переноса (needs_relocation); они не отменяются автоматически
+type ArchiveResult struct {
+	Coworking          *Coworking `json:"coworking,omitempty"`
+	Room               *Room      `json:"room,omitempty"`
+	RelocationBookings []Booking  `json:"relocation_bookings"`
+}
+
+// EquipmentRequest представляет запрос на создание или изменение типа оборудования;
+// при изменении пустое описание удаляет его
+type EquipmentRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+// SetRoomBufferRequest представляет запрос на изменение буферов комнаты
+type SetRoomBufferRequest struct {
+	SetupMinutes    int `json:"setup_minutes"`
+	TeardownMinutes int `json:"teardown_minutes"`
 }
 
 // CreatePaymentRequest представляет запрос на создание платежа
@@ -125,13 +650,42 @@ type CreatePaymentRequest struct {
 
 // UserStatistics представляет статистику пользователя
 type UserStatistics struct {
-	UserID             int     `json:"user_id"`
-	FullName           string  `json:"full_name"`
-	Email              string  `json:"email"`
-	TotalBookings      int     `json:"total_bookings"`
-	ConfirmedBookings  int     `json:"confirmed_bookings"`
-	CompletedBookings  int     `json:"completed_bookings"`
-	CancelledBookings  int     `json:"cancelled_bookings"`
-	TotalSpent         float64 `json:"total_spent"`
-	TotalPaid          float64 `json:"total_paid"`
+	UserID            int         `json:"user_id"`
+	FullName          string      `json:"full_name"`
+	Email             string      `json:"email"`
+	TotalBookings     int         `json:"total_bookings"`
+	ConfirmedBookings int         `json:"confirmed_bookings"`
+	CompletedBookings int         `json:"completed_bookings"`
+	CancelledBookings int         `json:"cancelled_bookings"`
+	TotalSpent        money.Money `json:"total_spent"`
+	TotalPaid         money.Money `json:"total_paid"`
+}
+
+// AuditEntry представляет запись журнала изменений: строка таблицы Entity вставлена (insert),
+// изменена (update) или удалена (delete). OldValues/NewValues — вся строка при insert и delete,
+// при update — только изменившиеся колонки
+type AuditEntry struct {
+	AuditID     int64          `json:"audit_id"`
+	OccurredAt  time.Time      `json:"occurred_at"`
+	TxID        int64          `json:"tx_id"` // записи одной операции имеют одинаковый tx_id
+	ActorUserID *int           `json:"actor_user_id,omitempty"`
+	Actor       string         `json:"actor"`
+	Action      string         `json:"action"`
+	Entity      string         `json:"entity"`
+	EntityID    *int           `json:"entity_id,omitempty"`
+	BookingID   *int           `json:"booking_id,omitempty"`
+	OldValues   map[string]any `json:"old_values,omitempty"`
+	NewValues   map[string]any `json:"new_values,omitempty"`
+}
+
+// AuditFilter задаёт выборку журнала изменений; нулевые поля её не ограничивают
+type AuditFilter struct {
+	Entity      string     // таблица: booking, payment, refund, ...
+	EntityID    int        // первичный ключ строки Entity
+	BookingID   int        // история бронирования с его платежами, возвратами и документами
+	PaymentID   int        // история платежа с возвратами и документами по нему
+	ActorUserID int        // изменения пользователя
+	From        *time.Time // не раньше
+	To          *time.Time // раньше
+	Limit       int        // последние Limit записей
 }
@@ -0,0 +1,247 @@
+// Package money реализует денежные суммы без потери точности: значение хранится
+// в минимальных единицах валюты (копейках) вместе с кодом валюты.
+//
+// В БД суммы лежат в DECIMAL(10,2) и читаются/пишутся как десятичная строка,
+// поэтому преобразование точное. Схема одновалютная: считанные из БД суммы
+// получают валюту DefaultCurrency.
+//
+// Правило округления одно на всю систему: стоимость считается как
+// ставка × длительность / 1 час (× коэффициент тарифа) и округляется до копейки
+// один раз на строку расчёта цены по правилу half-up (0,005 → 0,01), как
+// ROUND(numeric, 2) в PostgreSQL. См. ForDuration, ForDurationScaled и Percent.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCurrency — валюта сумм, хранящихся в БД (ISO 4217)
+const DefaultCurrency = "RUB"
+
+// minorPerUnit — число минимальных единиц в единице валюты (копеек в рубле)
+const minorPerUnit = 100
+
+// ErrInvalidAmount — строка не является суммой с точностью до копейки
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Money — денежная сумма в минимальных единицах валюты
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// New создаёт сумму из минимальных единиц в валюте по умолчанию
+func New(minor int64) Money {
+	return Money{Minor: minor, Currency: DefaultCurrency}
+}
+
+// Zero возвращает нулевую сумму в валюте по умолчанию
+func Zero() Money {
+	return New(0)
+}
+
+// Parse разбирает десятичную запись вида "1500", "1500.5", "-12.34".
+// Больше двух знаков после точки — ошибка: сумма не должна терять точность
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" || len(frac) > 2 || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	cents, _ := strconv.ParseInt(frac, 10, 64)
+	if units > (1<<63-1-cents)/minorPerUnit {
+		return Money{}, fmt.Errorf("%w: %q out of range", ErrInvalidAmount, s)
+	}
+
+	minor := units*minorPerUnit + cents
+	if neg {
+		minor = -minor
+	}
+	return New(minor), nil
+}
+
+// MustParse — Parse для констант; паникует при ошибке
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// ForDuration возвращает стоимость d по часовой ставке rate,
+// округлённую до копейки по правилу half-up (от нуля)
+func ForDuration(rate Money, d time.Duration) Money {
+	return ForDurationScaled(rate, d, 1, 1)
+}
+
+// ForDurationScaled возвращает стоимость d по ставке rate × num/den (коэффициент тарифа)
+// с одним округлением до копейки half-up
+func ForDurationScaled(rate Money, d time.Duration, num, den int64) Money {
+	n := new(big.Int).Mul(big.NewInt(rate.Minor), big.NewInt(int64(d)))
+	n.Mul(n, big.NewInt(num))
+	dd := new(big.Int).Mul(big.NewInt(int64(time.Hour)), big.NewInt(den))
+	return Money{Minor: roundHalfUp(n, dd), Currency: rate.currency()}
+}
+
+// Percent возвращает p процентов от m, округлённые до копейки half-up
+func (m Money) Percent(p int64) Money {
+	n := new(big.Int).Mul(big.NewInt(m.Minor), big.NewInt(p))
+	return Money{Minor: roundHalfUp(n, big.NewInt(100)), Currency: m.currency()}
+}
+
+// roundHalfUp делит num на den (den > 0) с округлением половины от нуля
+func roundHalfUp(num, den *big.Int) int64 {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	// |r| * 2 >= den — округление от нуля
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// Add возвращает m + o; суммы в разных валютах складывать нельзя
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.sameCurrency(o)}
+}
+
+// Sub возвращает m - o
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.sameCurrency(o)}
+}
+
+// Neg возвращает -m
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.currency()}
+}
+
+// Sign возвращает -1, 0 или 1
+func (m Money) Sign() int {
+	switch {
+	case m.Minor < 0:
+		return -1
+	case m.Minor > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero сообщает, равна ли сумма нулю
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// Cmp сравнивает суммы: -1, если m < o, 0 — равны, 1 — m > o
+func (m Money) Cmp(o Money) int {
+	m.sameCurrency(o)
+	return m.Sub(o).Sign()
+}
+
+// String возвращает десятичную запись с двумя знаками: "1500.00", "-0.50"
+func (m Money) String() string {
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	abs := uint64(minor)
+	if minor < 0 {
+		abs = uint64(-minor)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/minorPerUnit, abs%minorPerUnit)
+}
+
+// Value записывает сумму в БД десятичной строкой (numeric без потери точности)
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan читает numeric из БД
+func (m *Money) Scan(src any) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = New(v * minorPerUnit)
+		return nil
+	case nil:
+		return fmt.Errorf("%w: NULL", ErrInvalidAmount)
+	default:
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidAmount, src)
+	}
+
+	// numeric может прийти с лишними нулями в дробной части (SUM, ROUND)
+	if whole, frac, ok := strings.Cut(s, "."); ok && len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return fmt.Errorf("%w: %q has sub-minor digits", ErrInvalidAmount, s)
+		}
+		s = whole + "." + frac[:2]
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalJSON записывает сумму JSON-числом с двумя знаками после точки
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON принимает JSON-число или строку с десятичной записью
+func (m *Money) UnmarshalJSON(data []byte) error {
+	parsed, err := Parse(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) currency() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+func (m Money) sameCurrency(o Money) string {
+	if m.currency() != o.currency() {
+		panic(fmt.Sprintf("money: currency mismatch %s vs %s", m.currency(), o.currency()))
+	}
+	return m.currency()
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package money
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1500", 150000},
+		{"1500.5", 150050},
+		{"1500.05", 150005},
+		{" 0.01 ", 1},
+		{"+12.34", 1234},
+		{"-12.34", -1234},
+		{"-0.50", -50},
+		{"0", 0},
+		{"92233720368547758.07", 1<<63 - 1},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got.Minor != tt.want || got.Currency != DefaultCurrency {
+			t.Errorf("Parse(%q) = %+v, want %d %s", tt.in, got, tt.want, DefaultCurrency)
+		}
+	}
+}
+
+func TestParseRejectsLossOfPrecision(t *testing.T) {
+	// Parse не округляет: доли копейки — ошибка, а не 0,005 → 0,01
+	for _, in := range []string{"", "-", ".5", "1.005", "1.999", "12,50", "1e3", "--1", "1.2.3", "abc", "92233720368547758.08"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidAmount", in, err)
+		}
+	}
+}
+
+func TestScanTrimsTrailingZeros(t *testing.T) {
+	tests := []struct {
+		src     any
+		want    int64
+		wantErr bool
+	}{
+		{[]byte("1500.00"), 150000, false},
+		{"12.3400", 1234, false},
+		{int64(7), 700, false},
+		{"12.345", 0, true},
+		{nil, 0, true},
+		{3.14, 0, true},
+	}
+	for _, tt := range tests {
+		var m Money
+		err := m.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Scan(%v) error = %v, wantErr %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && m.Minor != tt.want {
+			t.Errorf("Scan(%v) = %d, want %d", tt.src, m.Minor, tt.want)
+		}
+	}
+}
+
+func TestPercentRoundsHalfUp(t *testing.T) {
+	tests := []struct {
+		minor, p, want int64
+	}{
+		{10000, 15, 1500},
+		{1, 50, 1},   // 0,005 → 0,01
+		{3, 50, 2},   // 0,015 → 0,02
+		{1, 49, 0},   // 0,0049 → 0
+		{-1, 50, -1}, // от нуля: −0,005 → −0,01
+		{-3, 50, -2}, // −0,015 → −0,02
+		{-1, 49, 0},  // −0,0049 → 0
+		{12345, 100, 12345},
+		{12345, 0, 0},
+		{999, 33, 330}, // 329,67 → 330
+	}
+	for _, tt := range tests {
+		if got := New(tt.minor).Percent(tt.p); got.Minor != tt.want {
+			t.Errorf("New(%d).Percent(%d) = %d, want %d", tt.minor, tt.p, got.Minor, tt.want)
+		}
+	}
+}
+
+func TestForDurationScaledRoundsOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     string
+		d        time.Duration
+		num, den int64
+		want     string
+	}{
+		{"whole hours", "1500.00", 2 * time.Hour, 1, 1, "3000.00"},
+		{"half hour", "1500.01", 30 * time.Minute, 1, 1, "750.01"}, // 750,005 → 750,01
+		{"one minute", "100.00", time.Minute, 1, 1, "1.67"},        // 1,6666…
+		{"one second", "1.00", time.Second, 1, 1, "0.00"},          // 0,000277…
+		{"half kopeck", "0.18", 100 * time.Second, 1, 1, "0.01"},   // ровно 0,005
+		{"peak x1.5", "1000.00", 90 * time.Minute, 3, 2, "2250.00"},
+		{"discount x0.9", "333.33", 20 * time.Minute, 9, 10, "100.00"}, // 99,999
+		// одно округление: 10 × (0,333… × 3) = 10,00, а не 10 × 3 × round(0,33)
+		{"scale after division", "1.00", 20 * time.Minute, 30, 1, "10.00"},
+		{"negative rate", "-1500.01", 30 * time.Minute, 1, 1, "-750.01"},
+		{"zero duration", "1500.00", 0, 1, 1, "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForDurationScaled(MustParse(tt.rate), tt.d, tt.num, tt.den)
+			if got.String() != tt.want {
+				t.Errorf("ForDurationScaled(%s, %s, %d/%d) = %s, want %s", tt.rate, tt.d, tt.num, tt.den, got, tt.want)
+			}
+		})
+	}
+	if got := ForDuration(MustParse("1200.00"), 45*time.Minute); got.String() != "900.00" {
+		t.Errorf("ForDuration = %s, want 900.00", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := map[int64]string{0: "0.00", 5: "0.05", -50: "-0.50", 150000: "1500.00", -123456: "-1234.56"}
+	for minor, want := range tests {
+		if got := New(minor).String(); got != want {
+			t.Errorf("New(%d).String() = %q, want %q", minor, got, want)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("10.05"), MustParse("0.10")
+	if got := a.Add(b); got.Minor != 1015 {
+		t.Errorf("Add = %s", got)
+	}
+	if got := b.Sub(a); got.Minor != -995 || got.Sign() != -1 {
+		t.Errorf("Sub = %s", got)
+	}
+	if got := a.Neg(); got.Minor != -1005 {
+		t.Errorf("Neg = %s", got)
+	}
+	if a.Cmp(b) != 1 || b.Cmp(a) != -1 || a.Cmp(a) != 0 {
+		t.Error("Cmp ordering is wrong")
+	}
+	// пустая валюта (нулевое значение структуры) считается валютой по умолчанию
+	if got := (Money{Minor: 1}).Add(New(1)); got.Currency != DefaultCurrency || got.Minor != 2 {
+		t.Errorf("Add with empty currency = %+v", got)
+	}
+}
+
+func TestCurrencyMismatchPanics(t *testing.T) {
+	usd := Money{Minor: 100, Currency: "USD"}
+	ops := map[string]func(){
+		"Add": func() { New(100).Add(usd) },
+		"Sub": func() { New(100).Sub(usd) },
+		"Cmp": func() { New(100).Cmp(usd) },
+	}
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s of RUB and USD did not panic", name)
+				}
+			}()
+			op()
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := MustParse("1500.5").MarshalJSON()
+	if err != nil || string(data) != "1500.50" {
+		t.Fatalf("MarshalJSON = %s, %v", data, err)
+	}
+	for _, in := range []string{`1500.50`, `"1500.50"`} {
+		var m Money
+		if err := m.UnmarshalJSON([]byte(in)); err != nil || m.Minor != 150050 {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v", in, m.Minor, err)
+		}
+	}
+	var m Money
+	if err := m.UnmarshalJSON([]byte(`1500.505`)); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("UnmarshalJSON(1500.505) error = %v, want ErrInvalidAmount", err)
+	}
+}
@@ -0,0 +1,137 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeName — имя встроенного шлюза
+const FakeName = "fake"
+
+// SignatureHeader — заголовок с подписью уведомления: "t=<unix-время>,v1=<hex HMAC-SHA256>"
+const SignatureHeader = "Payment-Signature"
+
+// SignatureTolerance — насколько подпись может отставать от текущего времени или опережать его
+const SignatureTolerance = 5 * time.Minute
+
+// Fake — локальный шлюз без реальных денег: выдаёт идентификаторы намерений и проверяет
+// уведомления, подписанные общим секретом. Уведомления формирует Sign (CLI, тесты, curl)
+type Fake struct {
+	secret []byte
+	now    func() time.Time
+
+	mu      sync.Mutex
+	intents map[int]string // платёж → намерение, чтобы повторный CreateIntent был идемпотентным
+}
+
+// NewFake создаёт локальный шлюз с секретом подписи уведомлений
+func NewFake(secret string) *Fake {
+	return &Fake{secret: []byte(secret), now: time.Now, intents: make(map[int]string)}
+}
+
+// Name возвращает FakeName
+func (f *Fake) Name() string {
+	return FakeName
+}
+
+// CreateIntent выдаёт идентификатор намерения вида fake_pi_<hex>
+func (f *Fake) CreateIntent(ctx context.Context, intent Intent) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ref, ok := f.intents[intent.PaymentID]; ok {
+		return ref, nil
+	}
+	ref, err := randomID("fake_pi_")
+	if err != nil {
+		return "", err
+	}
+	f.intents[intent.PaymentID] = ref
+	return ref, nil
+}
+
+// ParseWebhook проверяет подпись SignatureHeader и разбирает тело уведомления
+func (f *Fake) ParseWebhook(body []byte, header http.Header) (*Event, error) {
+	var timestamp, signature string
+	for _, part := range strings.Split(header.Get(SignatureHeader), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || signature == "" {
+		return nil, fmt.Errorf("malformed %s header: %w", SignatureHeader, ErrInvalidSignature)
+	}
+	if age := f.now().Sub(time.Unix(unix, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return nil, fmt.Errorf("signature timestamp outside tolerance: %w", ErrInvalidSignature)
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, f.mac(timestamp, body)) {
+		return nil, ErrInvalidSignature
+	}
+
+	var ev Event
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return nil, fmt.Errorf("invalid webhook body: %w", err)
+	}
+	switch ev.Type {
+	case EventSucceeded, EventFailed, EventRefunded:
+	default:
+		return nil, fmt.Errorf("unknown webhook event type %q", ev.Type)
+	}
+	if ev.ID == "" || ev.Ref == "" {
+		return nil, fmt.Errorf("webhook event requires id and intent_id")
+	}
+	if ev.Amount != nil && ev.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("webhook event amount must be positive")
+	}
+	return &ev, nil
+}
+
+// Sign сериализует событие (пустой ID заменяется случайным) и возвращает тело уведомления
+// и значение SignatureHeader на момент at
+func (f *Fake) Sign(ev Event, at time.Time) ([]byte, string, error) {
+	if ev.ID == "" {
+		id, err := randomID("evt_")
+		if err != nil {
+			return nil, "", err
+		}
+		ev.ID = id
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return body, "t=" + timestamp + ",v1=" + hex.EncodeToString(f.mac(timestamp, body)), nil
+}
+
+// mac — HMAC-SHA256 строки "<timestamp>.<body>"
+func (f *Fake) mac(timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, f.secret)
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+func randomID(prefix string) (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
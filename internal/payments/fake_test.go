@@ -0,0 +1,156 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/money"
+)
+
+func TestParseWebhook(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	f := NewFake("whsec_test")
+	f.now = func() time.Time { return now }
+	other := NewFake("whsec_other")
+	refund := money.MustParse("500.00")
+	zero := money.Zero()
+
+	succeeded := Event{ID: "evt_1", Type: EventSucceeded, Ref: "fake_pi_1"}
+	signed := func(signer *Fake, ev Event, at time.Time) ([]byte, http.Header) {
+		body, sig, err := signer.Sign(ev, at)
+		if err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		header := http.Header{}
+		header.Set(SignatureHeader, sig)
+		return body, header
+	}
+	withHeader := func(value string) http.Header {
+		header := http.Header{}
+		if value != "" {
+			header.Set(SignatureHeader, value)
+		}
+		return header
+	}
+	validBody, validHeader := signed(f, succeeded, now)
+
+	type webhookCase struct {
+		name    string
+		body    []byte
+		header  http.Header
+		wantSig bool   // ошибка подписи (ErrInvalidSignature)
+		wantErr string // иная ошибка разбора; пусто — событие принято
+	}
+	tests := []webhookCase{
+		{name: "valid", body: validBody, header: validHeader},
+		{name: "tampered body", body: []byte(strings.Replace(string(validBody), "fake_pi_1", "fake_pi_2", 1)), header: validHeader, wantSig: true},
+		{name: "no header", body: validBody, header: withHeader(""), wantSig: true},
+		{name: "no timestamp", body: validBody, header: withHeader("v1=" + strings.Repeat("0", 64)), wantSig: true},
+		{name: "no signature", body: validBody, header: withHeader("t=1709632800"), wantSig: true},
+		{name: "non-numeric timestamp", body: validBody, header: withHeader("t=yesterday,v1=00"), wantSig: true},
+		{name: "non-hex signature", body: validBody, header: withHeader("t=1709632800,v1=zz"), wantSig: true},
+		{name: "timestamp replaced", body: validBody, header: withHeader(strings.Replace(validHeader.Get(SignatureHeader), "t=1709632800", "t=1709632801", 1)), wantSig: true},
+	}
+	body, header := signed(other, succeeded, now)
+	tests = append(tests, webhookCase{name: "wrong secret", body: body, header: header, wantSig: true})
+
+	for _, tc := range []struct {
+		name    string
+		at      time.Time
+		wantSig bool
+	}{
+		{"at tolerance in the past", now.Add(-SignatureTolerance), false},
+		{"older than tolerance", now.Add(-SignatureTolerance - time.Second), true},
+		{"at tolerance in the future", now.Add(SignatureTolerance), false},
+		{"further in the future", now.Add(SignatureTolerance + time.Second), true},
+	} {
+		body, header := signed(f, succeeded, tc.at)
+		tests = append(tests, webhookCase{name: tc.name, body: body, header: header, wantSig: tc.wantSig})
+	}
+	for _, tc := range []struct {
+		name    string
+		ev      Event
+		wantErr string
+	}{
+		{"unknown event type", Event{ID: "evt_2", Type: "payment.pending", Ref: "fake_pi_1"}, "unknown webhook event type"},
+		{"missing intent", Event{ID: "evt_3", Type: EventFailed}, "requires id and intent_id"},
+		{"non-positive refund", Event{ID: "evt_4", Type: EventRefunded, Ref: "fake_pi_1", Amount: &zero}, "amount must be positive"},
+		{"partial refund", Event{ID: "evt_5", Type: EventRefunded, Ref: "fake_pi_1", Amount: &refund}, ""},
+	} {
+		body, header := signed(f, tc.ev, now)
+		tests = append(tests, webhookCase{name: tc.name, body: body, header: header, wantErr: tc.wantErr})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := f.ParseWebhook(tt.body, tt.header)
+			switch {
+			case tt.wantSig:
+				if !errors.Is(err, ErrInvalidSignature) {
+					t.Errorf("error = %v, want ErrInvalidSignature", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || errors.Is(err, ErrInvalidSignature) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case ev.ID == "" || ev.Ref != "fake_pi_1":
+				t.Errorf("event = %+v", ev)
+			}
+		})
+	}
+}
+
+func TestSignAssignsEventID(t *testing.T) {
+	f := NewFake("whsec_test")
+	at := time.Now()
+	body, sig, err := f.Sign(Event{Type: EventSucceeded, Ref: "fake_pi_1"}, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{}
+	header.Set(SignatureHeader, sig)
+	ev, err := f.ParseWebhook(body, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ev.ID, "evt_") {
+		t.Errorf("event ID = %q, want evt_ prefix", ev.ID)
+	}
+}
+
+func TestCreateIntentIsIdempotent(t *testing.T) {
+	f := NewFake("whsec_test")
+	ctx := context.Background()
+	first, err := f.CreateIntent(ctx, Intent{PaymentID: 1, Amount: money.MustParse("100.00")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := f.CreateIntent(ctx, Intent{PaymentID: 1, Amount: money.MustParse("100.00")})
+	other, _ := f.CreateIntent(ctx, Intent{PaymentID: 2, Amount: money.MustParse("100.00")})
+	if !strings.HasPrefix(first, "fake_pi_") || again != first || other == first {
+		t.Errorf("intents = %q, %q, %q", first, again, other)
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, name := range []string{"", "none"} {
+		if p, err := New(name, ""); p != nil || err != nil {
+			t.Errorf("New(%q) = %v, %v, want no provider", name, p, err)
+		}
+	}
+	if _, err := New(FakeName, ""); err == nil {
+		t.Error("New(fake) without secret succeeded")
+	}
+	if p, err := New(FakeName, "whsec_test"); err != nil || p.Name() != FakeName {
+		t.Errorf("New(fake) = %v, %v", p, err)
+	}
+	if _, err := New("stripe", "whsec_test"); err == nil {
+		t.Error("New(stripe) succeeded")
+	}
+}
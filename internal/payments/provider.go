@@ -0,0 +1,72 @@
+// Package payments описывает платёжный шлюз: создание намерения оплаты (payment intent)
+// для ожидающего платежа и проверку подписанных уведомлений (webhook) об его исходе.
+// Сами переходы статусов платежей выполняет database.ApplyPaymentEvent.
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"coworking-booking/internal/money"
+)
+
+// Типы событий шлюза
+const (
+	EventSucceeded = "payment.succeeded" // оплата прошла
+	EventFailed    = "payment.failed"    // оплата отклонена
+	EventRefunded  = "payment.refunded"  // шлюз вернул деньги (полностью или частично)
+)
+
+// ErrInvalidSignature — уведомление не подписано шлюзом, подпись устарела или тело повреждено
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Intent — данные ожидающего платежа, по которому шлюз создаёт намерение оплаты
+type Intent struct {
+	PaymentID int
+	BookingID int
+	Amount    money.Money
+	Method    string
+}
+
+// Event — проверенное уведомление шлюза
+type Event struct {
+	ID     string       `json:"id"`               // уникален в пределах шлюза; повтор не применяется дважды
+	Type   string       `json:"type"`             // EventSucceeded, EventFailed или EventRefunded
+	Ref    string       `json:"intent_id"`        // намерение оплаты, возвращённое CreateIntent
+	Amount *money.Money `json:"amount,omitempty"` // сумма возврата; нет — весь невозвращённый остаток
+	Reason string       `json:"reason,omitempty"` // причина отказа или возврата
+}
+
+// Provider — платёжный шлюз
+type Provider interface {
+	// Name — имя шлюза, под которым хранятся его намерения и события
+	Name() string
+	// CreateIntent создаёт намерение оплаты и возвращает его идентификатор в шлюзе.
+	// Повторный вызов для того же платежа возвращает то же намерение
+	CreateIntent(ctx context.Context, intent Intent) (string, error)
+	// ParseWebhook проверяет подпись уведомления и разбирает его
+	ParseWebhook(body []byte, header http.Header) (*Event, error)
+}
+
+// RequiresIntent сообщает, проводится ли платёж способом method через шлюз; наличные — нет
+func RequiresIntent(method string) bool {
+	return method != "cash"
+}
+
+// New создаёт шлюз по имени из конфигурации: "fake" — встроенный локальный шлюз,
+// "none" или пустое имя — без шлюза (nil, оплата подтверждается только вручную)
+func New(name, secret string) (Provider, error) {
+	switch name {
+	case "", "none":
+		return nil, nil
+	case FakeName:
+		if secret == "" {
+			return nil, fmt.Errorf("payment provider %s requires a webhook secret", name)
+		}
+		return NewFake(secret), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
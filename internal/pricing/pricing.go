@@ -0,0 +1,272 @@
+// Package pricing рассчитывает стоимость бронирования по тарифу комнаты: часовая ставка,
+// пиковые и непиковые окна, надбавка за выходные, минимальная оплачиваемая длительность
+// и скидки за длинные блоки (полдня, день). Результат — строки расчёта, сумма которых
+// равна стоимости; каждая строка округляется до копейки один раз (см. пакет money).
+// Окна задаются по местному времени коворкинга, поэтому час перехода на летнее время
+// тарифицируется по фактической длительности.
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"coworking-booking/internal/money"
+)
+
+// Виды строк расчёта
+const (
+	LineTime     = "time"     // время по ставке (базовой или с коэффициентом)
+	LineMinimum  = "minimum"  // доплата до минимальной длительности
+	LineDiscount = "discount" // скидка за длинный блок (отрицательная сумма)
+	LinePromo    = "promo"    // скидка по промокоду (отрицательная сумма)
+)
+
+// BaseDescription — описание времени без надбавок и скидок
+const BaseDescription = "Базовый тариф"
+
+// Window — пиковое (Percent > 100) или непиковое (Percent < 100) окно внутри суток
+type Window struct {
+	Name     string
+	Weekdays []int // дни недели ISO (1 — понедельник); пусто — все дни
+	Opens    time.Duration
+	Closes   time.Duration
+	Percent  int64 // ставка в процентах от базовой
+}
+
+// Discount — скидка Percent процентов на бронирование длительностью от MinDuration
+type Discount struct {
+	Name        string
+	MinDuration time.Duration
+	Percent     int64
+}
+
+// Tariff — правила цены одной комнаты
+type Tariff struct {
+	Rate           money.Money // базовая часовая ставка
+	Location       *time.Location
+	Windows        []Window
+	WeekendName    string
+	WeekendPercent int64 // ставка в субботу и воскресенье в процентах; 0 — без надбавки
+	MinDuration    time.Duration
+	Discounts      []Discount
+}
+
+// Line — строка расчёта цены
+type Line struct {
+	Kind        string
+	Description string
+	Minutes     int         // оплачиваемые минуты, округлённые до целых; 0 для скидки
+	HourlyRate  money.Money // ставка строки за час; 0 для скидки
+	Amount      money.Money
+}
+
+// Quote — расчёт цены: строки и итог
+type Quote struct {
+	Lines []Line
+	Total money.Money
+}
+
+// New создаёт тариф без надбавок и скидок: rate за час в часовом поясе loc
+func New(rate money.Money, loc *time.Location) *Tariff {
+	return &Tariff{Rate: rate, Location: loc}
+}
+
+// Quote рассчитывает стоимость бронирования [start, end).
+// Надбавка за выходные и окно перемножаются; если к моменту подходят несколько окон,
+// действует окно с наибольшей ставкой. Скидка — по самому длинному подходящему блоку
+// и считается от суммы всех строк до неё
+func (t *Tariff) Quote(start, end time.Time) Quote {
+	var lines []Line
+	var durations []time.Duration
+	var ratios []int64
+	index := make(map[string]int)
+
+	for _, seg := range t.segments(start, end) {
+		key := fmt.Sprintf("%s|%d", seg.description, seg.ratio)
+		i, ok := index[key]
+		if !ok {
+			i = len(lines)
+			index[key] = i
+			lines = append(lines, Line{Kind: LineTime, Description: seg.description})
+			durations = append(durations, 0)
+			ratios = append(ratios, seg.ratio)
+		}
+		durations[i] += seg.end.Sub(seg.start)
+	}
+	for i := range lines {
+		lines[i].Minutes = minutes(durations[i])
+		lines[i].HourlyRate = money.ForDurationScaled(t.Rate, time.Hour, ratios[i], ratioScale)
+		lines[i].Amount = money.ForDurationScaled(t.Rate, durations[i], ratios[i], ratioScale)
+	}
+
+	duration := end.Sub(start)
+	if t.MinDuration > duration {
+		extra := t.MinDuration - duration
+		lines = append(lines, Line{
+			Kind:        LineMinimum,
+			Description: fmt.Sprintf("Минимальная длительность %d мин", minutes(t.MinDuration)),
+			Minutes:     minutes(extra),
+			HourlyRate:  t.Rate,
+			Amount:      money.ForDuration(t.Rate, extra),
+		})
+	}
+
+	subtotal := money.Zero()
+	for _, l := range lines {
+		subtotal = subtotal.Add(l.Amount)
+	}
+	if d, ok := t.discount(duration); ok {
+		lines = append(lines, Line{
+			Kind:        LineDiscount,
+			Description: d.Name,
+			HourlyRate:  money.Zero(),
+			Amount:      subtotal.Percent(d.Percent).Neg(),
+		})
+	}
+
+	total := money.Zero()
+	for _, l := range lines {
+		total = total.Add(l.Amount)
+	}
+	return Quote{Lines: lines, Total: total}
+}
+
+// WithPromo добавляет к расчёту скидку по промокоду: percent процентов итога
+// либо фиксированную сумму fixed, но не больше итога. Возвращает новый расчёт и размер скидки
+func (q Quote) WithPromo(description string, percent int64, fixed money.Money) (Quote, money.Money) {
+	discount := fixed
+	if percent > 0 {
+		discount = q.Total.Percent(percent)
+	}
+	if discount.Cmp(q.Total) > 0 {
+		discount = q.Total
+	}
+	lines := append(append([]Line(nil), q.Lines...), Line{
+		Kind:        LinePromo,
+		Description: description,
+		HourlyRate:  money.Zero(),
+		Amount:      discount.Neg(),
+	})
+	return Quote{Lines: lines, Total: q.Total.Sub(discount)}, discount
+}
+
+// ratioScale — знаменатель коэффициента: проценты окна × проценты выходного
+const ratioScale = 100 * 100
+
+type segment struct {
+	start, end  time.Time
+	description string
+	ratio       int64 // коэффициент × ratioScale
+}
+
+// segments делит [start, end) на отрезки с постоянным коэффициентом
+func (t *Tariff) segments(start, end time.Time) []segment {
+	if !start.Before(end) {
+		return nil
+	}
+	local := start.In(t.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, t.Location)
+
+	var result []segment
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+		weekday := isoWeekday(day.Weekday())
+
+		cuts := []time.Time{day, next}
+		for _, w := range t.Windows {
+			if w.appliesOn(weekday) {
+				cuts = append(cuts, atOffset(day, w.Opens), atOffset(day, w.Closes))
+			}
+		}
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+
+		for i := 0; i+1 < len(cuts); i++ {
+			from, to := cuts[i], cuts[i+1]
+			if from.Before(start) {
+				from = start
+			}
+			if to.After(end) {
+				to = end
+			}
+			if !from.Before(to) {
+				continue
+			}
+			result = append(result, t.segment(day, weekday, from, to))
+		}
+	}
+	return result
+}
+
+func (t *Tariff) segment(day time.Time, weekday int, from, to time.Time) segment {
+	var names []string
+	weekend, window := int64(100), int64(100)
+
+	if t.WeekendPercent > 0 && weekday >= 6 {
+		weekend = t.WeekendPercent
+		names = append(names, t.WeekendName)
+	}
+	var best *Window
+	for i := range t.Windows {
+		w := &t.Windows[i]
+		if !w.appliesOn(weekday) || from.Before(atOffset(day, w.Opens)) || to.After(atOffset(day, w.Closes)) {
+			continue
+		}
+		if best == nil || w.Percent > best.Percent {
+			best = w
+		}
+	}
+	if best != nil {
+		window = best.Percent
+		names = append(names, best.Name)
+	}
+
+	description := BaseDescription
+	if len(names) > 0 {
+		description = strings.Join(names, " + ")
+	}
+	return segment{start: from, end: to, description: description, ratio: weekend * window}
+}
+
+// discount выбирает скидку с самым длинным порогом, не превышающим duration
+func (t *Tariff) discount(duration time.Duration) (Discount, bool) {
+	var best Discount
+	found := false
+	for _, d := range t.Discounts {
+		if d.MinDuration <= duration && (!found || d.MinDuration > best.MinDuration) {
+			best, found = d, true
+		}
+	}
+	return best, found
+}
+
+func (w *Window) appliesOn(isoWeekday int) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == isoWeekday {
+			return true
+		}
+	}
+	return false
+}
+
+func isoWeekday(d time.Weekday) int {
+	if d == time.Sunday {
+		return 7
+	}
+	return int(d)
+}
+
+// atOffset — момент, когда местные часы дня day показывают смещение d от полуночи
+func atOffset(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+func minutes(d time.Duration) int {
+	return int(math.Round(d.Minutes()))
+}
@@ -0,0 +1,276 @@
+package pricing
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"coworking-booking/internal/money"
+)
+
+func berlin(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	return loc
+}
+
+// describe сводит расчёт к строкам «вид|описание|минуты|ставка|сумма» для сравнения
+func describe(q Quote) []string {
+	out := make([]string, len(q.Lines))
+	for i, l := range q.Lines {
+		out[i] = fmt.Sprintf("%s|%s|%d|%s|%s", l.Kind, l.Description, l.Minutes, l.HourlyRate, l.Amount)
+	}
+	return out
+}
+
+func TestQuote(t *testing.T) {
+	loc := berlin(t)
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	rate := money.MustParse("1000.00")
+	peak := Window{Name: "Пик", Weekdays: []int{1, 2, 3, 4, 5}, Opens: 9 * time.Hour, Closes: 12 * time.Hour, Percent: 150}
+	lunch := Window{Name: "Обед", Weekdays: []int{1, 2, 3, 4, 5}, Opens: 11 * time.Hour, Closes: 12 * time.Hour, Percent: 200}
+	evening := Window{Name: "Вечер", Opens: 18 * time.Hour, Closes: 24 * time.Hour, Percent: 80}
+	night := Window{Name: "Ночь", Opens: 0, Closes: 3 * time.Hour, Percent: 50}
+	discounts := []Discount{
+		{Name: "Полдня", MinDuration: 4 * time.Hour, Percent: 10},
+		{Name: "День", MinDuration: 8 * time.Hour, Percent: 20},
+	}
+
+	tests := []struct {
+		name       string
+		tariff     Tariff
+		start, end string
+		want       []string
+		total      string
+	}{
+		{
+			name:   "base rate only",
+			tariff: Tariff{Rate: rate},
+			start:  "2024-03-04 13:00", end: "2024-03-04 15:30",
+			want:  []string{"time|Базовый тариф|150|1000.00|2500.00"},
+			total: "2500.00",
+		},
+		{
+			name:   "split by peak window",
+			tariff: Tariff{Rate: rate, Windows: []Window{peak}},
+			start:  "2024-03-04 08:00", end: "2024-03-04 13:00",
+			want: []string{
+				"time|Базовый тариф|120|1000.00|2000.00",
+				"time|Пик|180|1500.00|4500.00",
+			},
+			total: "6500.00",
+		},
+		{
+			name:   "window limited to weekdays",
+			tariff: Tariff{Rate: rate, Windows: []Window{peak}},
+			start:  "2024-03-09 09:00", end: "2024-03-09 10:00",
+			want:  []string{"time|Базовый тариф|60|1000.00|1000.00"},
+			total: "1000.00",
+		},
+		{
+			name:   "overlapping windows use highest rate",
+			tariff: Tariff{Rate: rate, Windows: []Window{peak, lunch}},
+			start:  "2024-03-04 10:00", end: "2024-03-04 12:00",
+			want: []string{
+				"time|Пик|60|1500.00|1500.00",
+				"time|Обед|60|2000.00|2000.00",
+			},
+			total: "3500.00",
+		},
+		{
+			name:   "weekend multiplies window",
+			tariff: Tariff{Rate: rate, Windows: []Window{evening}, WeekendName: "Выходные", WeekendPercent: 120},
+			start:  "2024-03-09 17:00", end: "2024-03-09 19:30",
+			want: []string{
+				"time|Выходные|60|1200.00|1200.00",
+				"time|Выходные + Вечер|90|960.00|1440.00",
+			},
+			total: "2640.00",
+		},
+		{
+			name:   "split at midnight into weekend",
+			tariff: Tariff{Rate: rate, WeekendName: "Выходные", WeekendPercent: 120},
+			start:  "2024-03-08 23:00", end: "2024-03-09 01:00",
+			want: []string{
+				"time|Базовый тариф|60|1000.00|1000.00",
+				"time|Выходные|60|1200.00|1200.00",
+			},
+			total: "2200.00",
+		},
+		{
+			name:   "same rate on different days is one line",
+			tariff: Tariff{Rate: rate, Windows: []Window{evening}},
+			start:  "2024-03-05 23:00", end: "2024-03-06 19:00",
+			want: []string{
+				"time|Вечер|120|800.00|1600.00",
+				"time|Базовый тариф|1080|1000.00|18000.00",
+			},
+			total: "19600.00",
+		},
+		{
+			// 31 марта в Берлине часы переводятся с 02:00 на 03:00: ночь короче на час
+			name:   "spring forward",
+			tariff: Tariff{Rate: rate, Windows: []Window{night}},
+			start:  "2024-03-31 01:00", end: "2024-03-31 04:00",
+			want: []string{
+				"time|Ночь|60|500.00|500.00",
+				"time|Базовый тариф|60|1000.00|1000.00",
+			},
+			total: "1500.00",
+		},
+		{
+			// 27 октября 03:00 снова становится 02:00: с полуночи до 04:00 проходит пять часов
+			name:   "fall back",
+			tariff: Tariff{Rate: rate, Windows: []Window{night}},
+			start:  "2024-10-27 00:00", end: "2024-10-27 04:00",
+			want: []string{
+				"time|Ночь|240|500.00|2000.00",
+				"time|Базовый тариф|60|1000.00|1000.00",
+			},
+			total: "3000.00",
+		},
+		{
+			name:   "whole day across fall back",
+			tariff: Tariff{Rate: rate},
+			start:  "2024-10-27 00:00", end: "2024-10-28 00:00",
+			want:  []string{"time|Базовый тариф|1500|1000.00|25000.00"},
+			total: "25000.00",
+		},
+		{
+			name:   "minimum duration",
+			tariff: Tariff{Rate: rate, MinDuration: time.Hour},
+			start:  "2024-03-04 13:00", end: "2024-03-04 13:20",
+			want: []string{
+				"time|Базовый тариф|20|1000.00|333.33",
+				"minimum|Минимальная длительность 60 мин|40|1000.00|666.67",
+			},
+			total: "1000.00",
+		},
+		{
+			name:   "below discount threshold",
+			tariff: Tariff{Rate: rate, Discounts: discounts},
+			start:  "2024-03-04 10:00", end: "2024-03-04 13:59",
+			want:  []string{"time|Базовый тариф|239|1000.00|3983.33"},
+			total: "3983.33",
+		},
+		{
+			name:   "half day discount",
+			tariff: Tariff{Rate: rate, Discounts: discounts},
+			start:  "2024-03-04 10:00", end: "2024-03-04 15:00",
+			want: []string{
+				"time|Базовый тариф|300|1000.00|5000.00",
+				"discount|Полдня|0|0.00|-500.00",
+			},
+			total: "4500.00",
+		},
+		{
+			name:   "longest discount wins and applies to all lines",
+			tariff: Tariff{Rate: rate, Windows: []Window{peak}, Discounts: discounts},
+			start:  "2024-03-04 08:00", end: "2024-03-04 16:00",
+			want: []string{
+				"time|Базовый тариф|300|1000.00|5000.00",
+				"time|Пик|180|1500.00|4500.00",
+				"discount|День|0|0.00|-1900.00",
+			},
+			total: "7600.00",
+		},
+		{
+			name:   "discount rounds half up",
+			tariff: Tariff{Rate: money.MustParse("0.05"), Discounts: []Discount{{Name: "Скидка", MinDuration: time.Hour, Percent: 10}}},
+			start:  "2024-03-04 10:00", end: "2024-03-04 11:00",
+			want: []string{
+				"time|Базовый тариф|60|0.05|0.05",
+				"discount|Скидка|0|0.00|-0.01",
+			},
+			total: "0.04",
+		},
+		{
+			name:   "empty range",
+			tariff: Tariff{Rate: rate, Windows: []Window{peak}},
+			start:  "2024-03-04 10:00", end: "2024-03-04 10:00",
+			want:  []string{},
+			total: "0.00",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tariff := tt.tariff
+			tariff.Location = loc
+			q := tariff.Quote(at(tt.start), at(tt.end))
+			if got := describe(q); !slices.Equal(got, tt.want) {
+				t.Errorf("lines:\n got %q\nwant %q", got, tt.want)
+			}
+			if q.Total.String() != tt.total {
+				t.Errorf("total = %s, want %s", q.Total, tt.total)
+			}
+			sum := money.Zero()
+			for _, l := range q.Lines {
+				sum = sum.Add(l.Amount)
+			}
+			if sum != q.Total {
+				t.Errorf("sum of lines %s != total %s", sum, q.Total)
+			}
+		})
+	}
+}
+
+func TestWithPromo(t *testing.T) {
+	base := Quote{
+		Lines: []Line{{Kind: LineTime, Description: BaseDescription, Minutes: 270, HourlyRate: money.MustParse("1000.00"), Amount: money.MustParse("4500.00")}},
+		Total: money.MustParse("4500.00"),
+	}
+	tests := []struct {
+		name     string
+		quote    Quote
+		percent  int64
+		fixed    string
+		discount string
+		total    string
+	}{
+		{"percent", base, 10, "0", "450.00", "4050.00"},
+		{"percent takes precedence over fixed", base, 10, "100.00", "450.00", "4050.00"},
+		{"fixed", base, 0, "500.00", "500.00", "4000.00"},
+		{"fixed clamped to total", base, 0, "10000.00", "4500.00", "0.00"},
+		{"fixed equal to total", base, 0, "4500.00", "4500.00", "0.00"},
+		{"full percent", base, 100, "0", "4500.00", "0.00"},
+		{"percent rounds half up", Quote{Total: money.MustParse("0.05")}, 10, "0", "0.01", "0.04"},
+		{"zero total", Quote{Total: money.Zero()}, 0, "500.00", "0.00", "0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, discount := tt.quote.WithPromo("SPRING", tt.percent, money.MustParse(tt.fixed))
+			if discount.String() != tt.discount || q.Total.String() != tt.total {
+				t.Errorf("WithPromo = %s (total %s), want %s (total %s)", discount, q.Total, tt.discount, tt.total)
+			}
+			if len(q.Lines) != len(tt.quote.Lines)+1 {
+				t.Fatalf("got %d lines, want %d", len(q.Lines), len(tt.quote.Lines)+1)
+			}
+			last := q.Lines[len(q.Lines)-1]
+			if last.Kind != LinePromo || last.Description != "SPRING" || last.Amount != discount.Neg() {
+				t.Errorf("promo line = %+v", last)
+			}
+		})
+	}
+
+	// исходный расчёт не меняется: строка промокода добавляется в копию
+	withSpare := base
+	withSpare.Lines = append(make([]Line, 0, 4), base.Lines...)
+	withSpare.WithPromo("A", 10, money.Zero())
+	b, _ := withSpare.WithPromo("B", 20, money.Zero())
+	if len(withSpare.Lines) != 1 || withSpare.Total != base.Total {
+		t.Errorf("WithPromo modified the receiver: %+v", withSpare)
+	}
+	if b.Lines[1].Description != "B" {
+		t.Errorf("second promo line = %+v", b.Lines[1])
+	}
+}
@@ -0,0 +1,30 @@
+package pricing
+
+import "time"
+
+// RefundTier — ступень политики отмены: при отмене не позднее чем за Notice до начала
+// возвращается Percent процентов оплаты
+type RefundTier struct {
+	Notice  time.Duration
+	Percent int64
+}
+
+// RefundPercent возвращает процент возврата при отмене за notice до начала бронирования:
+// ступень с самым длинным Notice, не превышающим notice. Без ступеней возврат полный;
+// если ни одна ступень не подходит (в том числе после начала) — возврата нет
+func RefundPercent(tiers []RefundTier, notice time.Duration) int64 {
+	if len(tiers) == 0 {
+		return 100
+	}
+	var best *RefundTier
+	for i := range tiers {
+		t := &tiers[i]
+		if notice >= 0 && t.Notice <= notice && (best == nil || t.Notice > best.Notice) {
+			best = t
+		}
+	}
+	if best == nil {
+		return 0
+	}
+	return best.Percent
+}
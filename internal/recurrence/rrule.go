@@ -0,0 +1,228 @@
+// Package recurrence реализует подмножество RFC 5545 RRULE для повторяющихся бронирований:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, COUNT, UNTIL и BYDAY (только для WEEKLY).
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Частота повторения
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+)
+
+// MaxOccurrences ограничивает число вхождений одной серии
+const MaxOccurrences = 200
+
+// ErrInvalidRule — правило не разобрано или не поддерживается
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Rule представляет разобранное правило повторения
+type Rule struct {
+	Freq     string
+	Interval int
+	Count    int       // 0 — не задано
+	Until    time.Time // нулевое — не задано; граница включительно
+	ByDay    []time.Weekday
+}
+
+// Parse разбирает строку вида "FREQ=WEEKLY;BYDAY=TU;COUNT=10" (допускается префикс "RRULE:").
+// Правило обязано быть ограниченным: COUNT или UNTIL. UNTIL без Z (дата или плавающее время)
+// читается по часам loc — часовому поясу коворкинга серии
+func Parse(s string, loc *time.Location) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	r := Rule{Interval: 1}
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Rule{}, fmt.Errorf("%w: %q", ErrInvalidRule, part)
+		}
+		key, value = strings.ToUpper(strings.TrimSpace(key)), strings.ToUpper(strings.TrimSpace(value))
+
+		switch key {
+		case "FREQ":
+			if value != Daily && value != Weekly && value != Monthly {
+				return Rule{}, fmt.Errorf("%w: unsupported FREQ %s", ErrInvalidRule, value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Rule{}, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value, loc)
+			if err != nil {
+				return Rule{}, fmt.Errorf("%w: UNTIL must be YYYYMMDD or YYYYMMDDTHHMMSS[Z]", ErrInvalidRule)
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, ok := weekdays[strings.TrimSpace(d)]
+				if !ok {
+					return Rule{}, fmt.Errorf("%w: unsupported BYDAY %s", ErrInvalidRule, d)
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		default:
+			return Rule{}, fmt.Errorf("%w: unsupported part %s", ErrInvalidRule, key)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return Rule{}, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	case r.Count == 0 && r.Until.IsZero():
+		return Rule{}, fmt.Errorf("%w: COUNT or UNTIL is required", ErrInvalidRule)
+	case r.Count > 0 && !r.Until.IsZero():
+		return Rule{}, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRule)
+	case r.Count > MaxOccurrences:
+		return Rule{}, fmt.Errorf("%w: COUNT must not exceed %d", ErrInvalidRule, MaxOccurrences)
+	case len(r.ByDay) > 0 && r.Freq != Weekly:
+		return Rule{}, fmt.Errorf("%w: BYDAY is supported only with FREQ=WEEKLY", ErrInvalidRule)
+	}
+	sort.Slice(r.ByDay, func(i, j int) bool { return weekdayIndex(r.ByDay[i]) < weekdayIndex(r.ByDay[j]) })
+	return r, nil
+}
+
+// String возвращает правило в каноническом виде RRULE
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wd := range r.ByDay {
+			for name, d := range weekdays {
+				if d == wd {
+					days = append(days, name)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences возвращает начала вхождений серии, начиная с dtstart (оно всегда первое,
+// если удовлетворяет правилу). Время суток сохраняется по локальным часам dtstart;
+// несуществующие даты (31 число в коротком месяце) пропускаются, как требует RFC 5545.
+// Если до UNTIL набирается больше MaxOccurrences вхождений, возвращается ErrInvalidRule
+func (r Rule) Occurrences(dtstart time.Time) ([]time.Time, error) {
+	// Для UNTIL собирается одно лишнее вхождение, чтобы заметить превышение лимита
+	limit := MaxOccurrences + 1
+	if r.Count > 0 && r.Count < limit {
+		limit = r.Count
+	}
+	var out []time.Time
+	add := func(t time.Time) bool {
+		if t.Before(dtstart) {
+			return true
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		out = append(out, t)
+		return len(out) < limit
+	}
+
+	y, m, d := dtstart.Date()
+	hh, mm, ss := dtstart.Clock()
+	loc := dtstart.Location()
+
+	// Защита от бесконечного цикла при пустом результате
+	const maxPeriods = 10 * MaxOccurrences
+
+	switch r.Freq {
+	case Daily:
+		for i := 0; i < maxPeriods; i++ {
+			if !add(time.Date(y, m, d+i*r.Interval, hh, mm, ss, 0, loc)) {
+				break
+			}
+		}
+
+	case Weekly:
+		byDay := r.ByDay
+		if len(byDay) == 0 {
+			byDay = []time.Weekday{dtstart.Weekday()}
+		}
+		// Недели начинаются с понедельника (WKST=MO)
+		weekStart := d - weekdayIndex(dtstart.Weekday())
+	weeks:
+		for i := 0; i < maxPeriods; i++ {
+			for _, wd := range byDay {
+				day := weekStart + i*7*r.Interval + weekdayIndex(wd)
+				if !add(time.Date(y, m, day, hh, mm, ss, 0, loc)) {
+					break weeks
+				}
+			}
+		}
+
+	case Monthly:
+		for i := 0; i < maxPeriods; i++ {
+			first := time.Date(y, m+time.Month(i*r.Interval), 1, hh, mm, ss, 0, loc)
+			t := time.Date(first.Year(), first.Month(), d, hh, mm, ss, 0, loc)
+			if t.Month() != first.Month() {
+				continue
+			}
+			if !add(t) {
+				break
+			}
+		}
+	}
+	if len(out) > MaxOccurrences {
+		return nil, fmt.Errorf("%w: UNTIL gives more than %d occurrences", ErrInvalidRule, MaxOccurrences)
+	}
+	return out, nil
+}
+
+// weekdayIndex нумерует дни недели с понедельника
+func weekdayIndex(wd time.Weekday) int {
+	return (int(wd) + 6) % 7
+}
+
+// parseUntil разбирает UNTIL: форма с Z — момент в UTC, остальные — местное время loc
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// Дата без времени включает весь местный день
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc), nil
+}
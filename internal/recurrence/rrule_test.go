@@ -0,0 +1,220 @@
+package recurrence
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	return loc
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"FREQ=YEARLY;COUNT=1",
+		"FREQ=DAILY",
+		"COUNT=3",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240301",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=201",
+		"FREQ=DAILY;INTERVAL=0;COUNT=3",
+		"FREQ=DAILY;BYDAY=MO;COUNT=3",
+		"FREQ=MONTHLY;BYDAY=MO;COUNT=3",
+		"FREQ=WEEKLY;BYDAY=XX;COUNT=3",
+		"FREQ=WEEKLY;BYDAY=1MO;COUNT=3",
+		"FREQ=DAILY;UNTIL=2024-03-01",
+		"FREQ=DAILY;BYMONTHDAY=1;COUNT=3",
+		"FREQ",
+	} {
+		if _, err := Parse(s, time.UTC); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidRule", s, err)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	moscow := loadLocation(t, "Europe/Moscow")
+	tests := []struct {
+		in, want string
+	}{
+		{"FREQ=DAILY;COUNT=5", "FREQ=DAILY;COUNT=5"},
+		{"RRULE:FREQ=DAILY;INTERVAL=1;COUNT=5", "FREQ=DAILY;COUNT=5"},
+		{" RRULE:freq=weekly; byday = th,mo ;interval=2;count=4", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4"},
+		{"FREQ=WEEKLY;BYDAY=SU,SA,FR,TH,WE,TU,MO;COUNT=7", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR,SA,SU;COUNT=7"},
+		{"FREQ=MONTHLY;UNTIL=20241231T210000Z", "FREQ=MONTHLY;UNTIL=20241231T210000Z"},
+		// дата без времени — до конца дня по Москве (UTC+3)
+		{"FREQ=MONTHLY;UNTIL=20241231", "FREQ=MONTHLY;UNTIL=20241231T205959Z"},
+		{"FREQ=DAILY;UNTIL=20241231T090000", "FREQ=DAILY;UNTIL=20241231T060000Z"},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.in, moscow)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got := r.String(); got != tt.want {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+		// каноническая форма разбирается в то же правило в любом поясе
+		again, err := Parse(r.String(), time.UTC)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", r.String(), err)
+			continue
+		}
+		if again.String() != r.String() || !again.Until.Equal(r.Until) {
+			t.Errorf("round trip of %q: got %q", r.String(), again.String())
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	moscow := loadLocation(t, "Europe/Moscow")
+	berlin := loadLocation(t, "Europe/Berlin")
+	at := func(loc *time.Location, s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name    string
+		rule    string
+		loc     *time.Location
+		dtstart string
+		want    []string
+	}{
+		{
+			name: "daily count", rule: "FREQ=DAILY;COUNT=3", loc: moscow, dtstart: "2024-03-05 10:00",
+			want: []string{"2024-03-05 10:00", "2024-03-06 10:00", "2024-03-07 10:00"},
+		},
+		{
+			name: "daily interval", rule: "FREQ=DAILY;INTERVAL=3;COUNT=3", loc: moscow, dtstart: "2024-02-27 10:00",
+			want: []string{"2024-02-27 10:00", "2024-03-01 10:00", "2024-03-04 10:00"},
+		},
+		{
+			name: "until is inclusive", rule: "FREQ=DAILY;UNTIL=20240307T070000Z", loc: moscow, dtstart: "2024-03-05 10:00",
+			want: []string{"2024-03-05 10:00", "2024-03-06 10:00", "2024-03-07 10:00"},
+		},
+		{
+			// 01:00 по Москве 8 марта — ещё 7 марта по UTC, но UNTIL-дата читается по Москве
+			name: "date-only until in series zone", rule: "FREQ=DAILY;UNTIL=20240307", loc: moscow, dtstart: "2024-03-05 01:00",
+			want: []string{"2024-03-05 01:00", "2024-03-06 01:00", "2024-03-07 01:00"},
+		},
+		{
+			name: "floating until in series zone", rule: "FREQ=DAILY;UNTIL=20240307T003000", loc: moscow, dtstart: "2024-03-05 01:00",
+			want: []string{"2024-03-05 01:00", "2024-03-06 01:00"},
+		},
+		{
+			name: "until before start", rule: "FREQ=DAILY;UNTIL=20240301", loc: moscow, dtstart: "2024-03-05 10:00",
+			want: nil,
+		},
+		{
+			// местное время сохраняется при переходе на летнее время
+			name: "daily across DST", rule: "FREQ=DAILY;COUNT=3", loc: berlin, dtstart: "2024-03-30 09:00",
+			want: []string{"2024-03-30 09:00", "2024-03-31 09:00", "2024-04-01 09:00"},
+		},
+		{
+			name: "weekly on start weekday", rule: "FREQ=WEEKLY;COUNT=3", loc: moscow, dtstart: "2024-03-05 10:00",
+			want: []string{"2024-03-05 10:00", "2024-03-12 10:00", "2024-03-19 10:00"},
+		},
+		{
+			// день недели до dtstart в первой неделе пропускается
+			name: "weekly byday", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=4", loc: moscow, dtstart: "2024-03-07 10:00",
+			want: []string{"2024-03-07 10:00", "2024-03-18 10:00", "2024-03-21 10:00", "2024-04-01 10:00"},
+		},
+		{
+			name: "weekly byday until", rule: "FREQ=WEEKLY;BYDAY=TU,SA;UNTIL=20240316", loc: moscow, dtstart: "2024-03-05 18:00",
+			want: []string{"2024-03-05 18:00", "2024-03-09 18:00", "2024-03-12 18:00", "2024-03-16 18:00"},
+		},
+		{
+			name: "weekly byday across year", rule: "FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3", loc: moscow, dtstart: "2024-12-27 09:00",
+			want: []string{"2024-12-27 09:00", "2024-12-30 09:00", "2025-01-03 09:00"},
+		},
+		{
+			name: "monthly", rule: "FREQ=MONTHLY;COUNT=3", loc: moscow, dtstart: "2024-11-15 10:00",
+			want: []string{"2024-11-15 10:00", "2024-12-15 10:00", "2025-01-15 10:00"},
+		},
+		{
+			// 31 числа нет в феврале, апреле и июне — эти месяцы пропускаются
+			name: "monthly skips missing day", rule: "FREQ=MONTHLY;COUNT=4", loc: moscow, dtstart: "2024-01-31 10:00",
+			want: []string{"2024-01-31 10:00", "2024-03-31 10:00", "2024-05-31 10:00", "2024-07-31 10:00"},
+		},
+		{
+			name: "monthly leap day", rule: "FREQ=MONTHLY;INTERVAL=12;COUNT=2", loc: moscow, dtstart: "2024-02-29 10:00",
+			want: []string{"2024-02-29 10:00", "2028-02-29 10:00"},
+		},
+		{
+			name: "monthly until", rule: "FREQ=MONTHLY;INTERVAL=2;UNTIL=20240601", loc: moscow, dtstart: "2023-12-30 10:00",
+			want: []string{"2023-12-30 10:00", "2024-04-30 10:00"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Parse(tt.rule, tt.loc)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got, err := r.Occurrences(at(tt.loc, tt.dtstart))
+			if err != nil {
+				t.Fatalf("Occurrences: %v", err)
+			}
+			formatted := make([]string, len(got))
+			for i, o := range got {
+				formatted[i] = o.Format("2006-01-02 15:04")
+				if o.Location() != tt.loc {
+					t.Errorf("occurrence %s is in %s, want %s", o, o.Location(), tt.loc)
+				}
+			}
+			if strings.Join(formatted, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("Occurrences:\n got %q\nwant %q", formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestOccurrencesCap(t *testing.T) {
+	dtstart := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{"FREQ=DAILY;COUNT=200", false},
+		{"FREQ=DAILY;UNTIL=20240920", false}, // ровно 200 дней
+		{"FREQ=DAILY;UNTIL=20240921", true},
+		{"FREQ=DAILY;UNTIL=20300101", true},
+		{"FREQ=WEEKLY;BYDAY=TU,TH,SA;UNTIL=20300101", true},
+	}
+	for _, tt := range tests {
+		r, err := Parse(tt.rule, time.UTC)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.rule, err)
+		}
+		got, err := r.Occurrences(dtstart)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("%s: error = %v, want ErrInvalidRule", tt.rule, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.rule, err)
+		}
+		if len(got) != MaxOccurrences {
+			t.Errorf("%s: %d occurrences, want %d", tt.rule, len(got), MaxOccurrences)
+		}
+		if !got[0].Equal(dtstart) {
+			t.Errorf("%s: first occurrence %s, want %s", tt.rule, got[0], dtstart)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package schedule вычисляет часы, в которые комнату можно бронировать: недельное
+// расписание коворкинга (или собственное расписание комнаты) с учётом праздников
+// и сокращённых дней. Расписание задаётся по местным часам коворкинга, поэтому
+// в день перехода на летнее время рабочий день короче или длиннее на час.
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DayLayout — формат даты праздничного дня
+const DayLayout = "2006-01-02"
+
+// Window — интервал работы внутри суток: смещения от местной полуночи, Closes ≤ 24ч
+type Window struct {
+	Opens  time.Duration
+	Closes time.Duration
+}
+
+// Interval — интервал абсолютного времени [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Schedule — расписание одной комнаты в часовом поясе её коворкинга.
+// Если недельное расписание не задано, комната открыта круглосуточно (кроме праздников)
+type Schedule struct {
+	Location *time.Location
+	weekly   [7][]Window         // по time.Weekday
+	days     map[string][]Window // особые дни; пустой список — выходной
+	hasWeek  bool
+}
+
+// New создаёт пустое (круглосуточное) расписание в часовом поясе loc
+func New(loc *time.Location) *Schedule {
+	return &Schedule{Location: loc, days: map[string][]Window{}}
+}
+
+// AddWeekly добавляет интервал работы в день недели isoWeekday (1 — понедельник, 7 — воскресенье)
+func (s *Schedule) AddWeekly(isoWeekday int, w Window) {
+	s.weekly[isoWeekday%7] = append(s.weekly[isoWeekday%7], w)
+	s.hasWeek = true
+}
+
+// SetDay задаёт особый день day (YYYY-MM-DD): выходной при nil или сокращённые часы w
+func (s *Schedule) SetDay(day string, w *Window) {
+	if w == nil {
+		s.days[day] = nil
+		return
+	}
+	s.days[day] = []Window{*w}
+}
+
+// Intervals возвращает интервалы работы, пересекающиеся с [from, to), обрезанные по нему;
+// смежные интервалы (например, круглосуточная работа через полночь) склеиваются
+func (s *Schedule) Intervals(from, to time.Time) []Interval {
+	if !from.Before(to) {
+		return nil
+	}
+	local := from.In(s.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.Location)
+
+	var result []Interval
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, w := range s.windows(day) {
+			iv := Interval{Start: atOffset(day, w.Opens), End: atOffset(day, w.Closes)}
+			if iv.Start.Before(from) {
+				iv.Start = from
+			}
+			if iv.End.After(to) {
+				iv.End = to
+			}
+			if iv.Start.Before(iv.End) {
+				result = append(result, iv)
+			}
+		}
+	}
+	return Merge(result)
+}
+
+// Covers сообщает, лежит ли [start, end) целиком в часах работы
+func (s *Schedule) Covers(start, end time.Time) bool {
+	ivs := s.Intervals(start, end)
+	return len(ivs) == 1 && ivs[0].Start.Equal(start) && ivs[0].End.Equal(end)
+}
+
+// Hours возвращает суммарное время работы в [from, to)
+func (s *Schedule) Hours(from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range s.Intervals(from, to) {
+		total += iv.End.Sub(iv.Start)
+	}
+	return total
+}
+
+// HoursWithin возвращает время работы в [from, to), попадающее в интервалы ivs;
+// ivs не должны пересекаться (см. Merge)
+func (s *Schedule) HoursWithin(ivs []Interval, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range ivs {
+		if iv.Start.Before(from) {
+			iv.Start = from
+		}
+		if iv.End.After(to) {
+			iv.End = to
+		}
+		total += s.Hours(iv.Start, iv.End)
+	}
+	return total
+}
+
+func (s *Schedule) windows(day time.Time) []Window {
+	if w, ok := s.days[day.Format(DayLayout)]; ok {
+		return w
+	}
+	if !s.hasWeek {
+		return []Window{{Opens: 0, Closes: 24 * time.Hour}}
+	}
+	return s.weekly[day.Weekday()]
+}
+
+// atOffset — момент, когда местные часы дня day показывают смещение d от полуночи
+func atOffset(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(),
+		int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// Merge сортирует интервалы и склеивает пересекающиеся и смежные
+func Merge(ivs []Interval) []Interval {
+	if len(ivs) == 0 {
+		return nil
+	}
+	sort.Slice(ivs, func(i, j int) bool { return ivs[i].Start.Before(ivs[j].Start) })
+	merged := []Interval{ivs[0]}
+	for _, iv := range ivs[1:] {
+		last := &merged[len(merged)-1]
+		if iv.Start.After(last.End) {
+			merged = append(merged, iv)
+			continue
+		}
+		if iv.End.After(last.End) {
+			last.End = iv.End
+		}
+	}
+	return merged
+}
+
+// ParseClock разбирает время суток "HH:MM" (или "HH:MM:SS", как TIME в PostgreSQL);
+// допускается "24:00" — конец суток
+func ParseClock(value string) (time.Duration, error) {
+	var h, m, sec int
+	n, _ := fmt.Sscanf(value, "%d:%d:%d", &h, &m, &sec)
+	if n < 2 || h < 0 || m < 0 || m > 59 || sec != 0 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time of day %q: expected HH:MM", value)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// ParseWindow разбирает интервал работы из пары "HH:MM"
+func ParseWindow(opens, closes string) (Window, error) {
+	o, err := ParseClock(opens)
+	if err != nil {
+		return Window{}, err
+	}
+	c, err := ParseClock(closes)
+	if err != nil {
+		return Window{}, err
+	}
+	if o >= c {
+		return Window{}, fmt.Errorf("opening time %s must be before closing time %s", opens, closes)
+	}
+	return Window{Opens: o, Closes: c}, nil
+}
@@ -0,0 +1,98 @@
+// Package scheduler выполняет фоновые переходы жизненного цикла бронирований:
+// отмену неоплаченных броней после окончания удержания и завершение прошедших,
+// а также создаёт в платёжном шлюзе недостающие намерения оплаты.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"coworking-booking/internal/database"
+)
+
+// Значения по умолчанию
+const (
+	DefaultHoldWindow = 30 * time.Minute
+	DefaultInterval   = time.Minute
+)
+
+// auditActor — исполнитель изменений планировщика в audit_log
+const auditActor = "scheduler"
+
+// Config содержит параметры планировщика
+type Config struct {
+	// HoldWindow — сколько pending-бронь удерживает слот без оплаты
+	HoldWindow time.Duration
+	// Interval — период между проходами
+	Interval time.Duration
+}
+
+// Scheduler периодически применяет переходы статусов бронирований.
+// Все изменения делаются в БД с SKIP LOCKED, поэтому безопасно запускать
+// планировщик на нескольких экземплярах одновременно
+type Scheduler struct {
+	db  *database.DB
+	cfg Config
+}
+
+// New создаёт планировщик; нулевые поля Config заменяются значениями по умолчанию
+func New(db *database.DB, cfg Config) *Scheduler {
+	if cfg.HoldWindow <= 0 {
+		cfg.HoldWindow = DefaultHoldWindow
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &Scheduler{db: db, cfg: cfg}
+}
+
+// Run выполняет проходы до отмены ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Booking scheduler started (hold window %s, interval %s)", s.cfg.HoldWindow, s.cfg.Interval)
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunOnce(ctx)
+		select {
+		case <-ctx.Done():
+			log.Println("Booking scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce выполняет один проход: истёкшие удержания, завершённые бронирования,
+// затем намерения оплаты для платежей, выставленных без них. Изменения записываются в audit_log
+// от имени auditActor
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	ctx = database.WithActor(ctx, database.SystemActor(auditActor))
+	expired, err := s.db.ExpireUnpaidBookings(ctx, s.cfg.HoldWindow)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error: scheduler: %v", err)
+		}
+	} else if len(expired) > 0 {
+		log.Printf("Scheduler: cancelled %d unpaid bookings %v", len(expired), expired)
+	}
+
+	completed, err := s.db.CompletePastBookings(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error: scheduler: %v", err)
+		}
+	} else if len(completed) > 0 {
+		log.Printf("Scheduler: completed %d bookings %v", len(completed), completed)
+	}
+
+	intents, err := s.db.CreatePaymentIntents(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error: scheduler: %v", err)
+		}
+	} else if len(intents) > 0 {
+		log.Printf("Scheduler: created payment intents for %d payments %v", len(intents), intents)
+	}
+}
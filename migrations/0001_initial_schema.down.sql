@@ -0,0 +1,12 @@
+DROP VIEW IF EXISTS room_with_equipment;
+DROP VIEW IF EXISTS booking_details;
+
+DROP TABLE IF EXISTS payment;
+DROP TABLE IF EXISTS booking;
+DROP TABLE IF EXISTS room_equipment;
+DROP TABLE IF EXISTS equipment;
+DROP TABLE IF EXISTS room;
+DROP TABLE IF EXISTS coworking;
+DROP TABLE IF EXISTS "user";
+
+DROP FUNCTION IF EXISTS update_updated_at_column();
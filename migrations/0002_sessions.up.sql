@@ -0,0 +1,19 @@
+CREATE TABLE session (
+    session_id SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+
+    CONSTRAINT fk_session_user FOREIGN KEY (user_id)
+        REFERENCES "user"(user_id) ON DELETE CASCADE,
+
+    CONSTRAINT session_expiry_check CHECK (expires_at > created_at)
+);
+
+CREATE INDEX idx_session_user ON session(user_id);
+
+COMMENT ON TABLE session IS 'Сессии пользователей (bearer-токены)';
+COMMENT ON COLUMN session.token_hash IS 'SHA-256 от токена в hex; сам токен в БД не хранится';
+COMMENT ON COLUMN session.revoked_at IS 'Время выхода из системы (logout); NULL — сессия активна';
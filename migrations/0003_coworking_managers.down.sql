@@ -0,0 +1 @@
+DROP TABLE IF EXISTS coworking_manager;
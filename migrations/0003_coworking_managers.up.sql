@@ -0,0 +1,17 @@
+CREATE TABLE coworking_manager (
+    coworking_id INTEGER NOT NULL,
+    user_id      INTEGER NOT NULL,
+    assigned_at  TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (coworking_id, user_id),
+
+    CONSTRAINT fk_coworking_manager_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_coworking_manager_user FOREIGN KEY (user_id)
+        REFERENCES "user"(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_coworking_manager_user ON coworking_manager(user_id);
+
+COMMENT ON TABLE coworking_manager IS 'Менеджеры, ответственные за коворкинг (платежи и отчёты)';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_booking_confirmed_ends;
+DROP INDEX IF EXISTS idx_booking_pending_created;
+DROP TABLE IF EXISTS booking_status_history;
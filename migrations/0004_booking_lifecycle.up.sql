@@ -0,0 +1,20 @@
+CREATE TABLE booking_status_history (
+    history_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL,
+    old_status VARCHAR(20) NOT NULL,
+    new_status VARCHAR(20) NOT NULL,
+    reason     VARCHAR(50) NOT NULL,
+    changed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_booking_status_history_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_booking_status_history_booking ON booking_status_history(booking_id);
+
+-- Выборки планировщика: просроченные pending и завершившиеся confirmed
+CREATE INDEX idx_booking_pending_created ON booking(created_at) WHERE status = 'pending';
+CREATE INDEX idx_booking_confirmed_ends ON booking(ends_at) WHERE status = 'confirmed';
+
+COMMENT ON TABLE booking_status_history IS 'История переходов статусов бронирований';
+COMMENT ON COLUMN booking_status_history.reason IS 'Причина: payment_confirmed, cancelled_by_user, hold_expired, completed';
@@ -0,0 +1,24 @@
+CREATE TABLE payment_adjustment (
+    adjustment_id SERIAL PRIMARY KEY,
+    booking_id    INTEGER NOT NULL,
+    payment_id    INTEGER NOT NULL,
+    amount        DECIMAL(10, 2) NOT NULL,
+    status        VARCHAR(20) NOT NULL,
+    reason        VARCHAR(50) NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_payment_adjustment_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_payment_adjustment_payment FOREIGN KEY (payment_id)
+        REFERENCES payment(payment_id) ON DELETE RESTRICT,
+
+    CONSTRAINT payment_adjustment_amount_check CHECK (amount <> 0),
+    CONSTRAINT payment_adjustment_status_check CHECK (status IN ('pending', 'paid', 'refunded'))
+);
+
+CREATE INDEX idx_payment_adjustment_booking ON payment_adjustment(booking_id);
+
+COMMENT ON TABLE payment_adjustment IS 'Доплаты и возвраты по уже оплаченным бронированиям (перенос, продление)';
+COMMENT ON COLUMN payment_adjustment.amount IS 'Положительная — клиент доплачивает, отрицательная — возврат клиенту';
+COMMENT ON COLUMN payment_adjustment.status IS 'Статус: pending (доплата ожидается), paid (доплата получена), refunded (разница возвращена)';
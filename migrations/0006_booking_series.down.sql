@@ -0,0 +1,2 @@
+ALTER TABLE booking DROP COLUMN IF EXISTS series_id;
+DROP TABLE IF EXISTS booking_series;
@@ -0,0 +1,35 @@
+CREATE TABLE booking_series (
+    series_id  SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL,
+    room_id    INTEGER NOT NULL,
+    rrule      VARCHAR(255) NOT NULL,
+    starts_at  TIMESTAMP NOT NULL,
+    ends_at    TIMESTAMP NOT NULL,
+    status     VARCHAR(20) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_booking_series_user FOREIGN KEY (user_id)
+        REFERENCES "user"(user_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_booking_series_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE RESTRICT,
+
+    CONSTRAINT booking_series_time_check CHECK (starts_at < ends_at),
+    CONSTRAINT booking_series_status_check CHECK (status IN ('active', 'cancelled'))
+);
+
+CREATE INDEX idx_booking_series_user ON booking_series(user_id);
+
+COMMENT ON TABLE booking_series IS 'Серии повторяющихся бронирований';
+COMMENT ON COLUMN booking_series.rrule IS 'Правило повторения (подмножество RFC 5545 RRULE): FREQ, INTERVAL, COUNT, UNTIL, BYDAY';
+COMMENT ON COLUMN booking_series.starts_at IS 'Начало первого вхождения (DTSTART)';
+COMMENT ON COLUMN booking_series.ends_at IS 'Окончание первого вхождения; задаёт длительность вхождений';
+
+ALTER TABLE booking ADD COLUMN series_id INTEGER;
+
+ALTER TABLE booking ADD CONSTRAINT fk_booking_series FOREIGN KEY (series_id)
+    REFERENCES booking_series(series_id) ON DELETE SET NULL;
+
+CREATE INDEX idx_booking_series ON booking(series_id) WHERE series_id IS NOT NULL;
+
+COMMENT ON COLUMN booking.series_id IS 'Серия, к которой относится вхождение; NULL — разовое бронирование';
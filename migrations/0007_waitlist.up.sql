@@ -0,0 +1,39 @@
+CREATE TABLE waitlist_entry (
+    entry_id           SERIAL PRIMARY KEY,
+    user_id            INTEGER NOT NULL,
+    room_id            INTEGER,
+    starts_at          TIMESTAMP NOT NULL,
+    ends_at            TIMESTAMP NOT NULL,
+    min_capacity       INTEGER,
+    max_rate           DECIMAL(10, 2),
+    equipment_ids      INTEGER[] NOT NULL DEFAULT '{}',
+    payment_method     VARCHAR(50) NOT NULL DEFAULT 'card',
+    status             VARCHAR(20) NOT NULL DEFAULT 'waiting',
+    offered_booking_id INTEGER,
+    offer_expires_at   TIMESTAMP,
+    created_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_waitlist_entry_user FOREIGN KEY (user_id)
+        REFERENCES "user"(user_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_waitlist_entry_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_waitlist_entry_booking FOREIGN KEY (offered_booking_id)
+        REFERENCES booking(booking_id) ON DELETE SET NULL,
+
+    CONSTRAINT waitlist_entry_time_check CHECK (starts_at < ends_at),
+    CONSTRAINT waitlist_entry_status_check CHECK (
+        status IN ('waiting', 'offered', 'accepted', 'declined', 'expired', 'cancelled')
+    )
+);
+
+-- Очередь FIFO: ожидающие заявки в порядке создания
+CREATE INDEX idx_waitlist_entry_waiting ON waitlist_entry(created_at, entry_id) WHERE status = 'waiting';
+CREATE INDEX idx_waitlist_entry_user ON waitlist_entry(user_id);
+CREATE INDEX idx_waitlist_entry_offered ON waitlist_entry(offered_booking_id) WHERE offered_booking_id IS NOT NULL;
+
+COMMENT ON TABLE waitlist_entry IS 'Лист ожидания на занятые интервалы времени';
+COMMENT ON COLUMN waitlist_entry.room_id IS 'Конкретная комната; NULL — любая комната, подходящая под критерии поиска';
+COMMENT ON COLUMN waitlist_entry.status IS 'Статус: waiting, offered (создано pending-бронирование), accepted (оплачено), declined, expired, cancelled';
+COMMENT ON COLUMN waitlist_entry.offer_expires_at IS 'Срок, до которого нужно оплатить предложенное бронирование';
@@ -0,0 +1,51 @@
+ALTER TABLE payment_adjustment ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE booking_status_history ALTER COLUMN changed_at TYPE TIMESTAMP;
+ALTER TABLE coworking_manager ALTER COLUMN assigned_at TYPE TIMESTAMP;
+ALTER TABLE session
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN expires_at TYPE TIMESTAMP,
+    ALTER COLUMN revoked_at TYPE TIMESTAMP;
+ALTER TABLE payment
+    ALTER COLUMN paid_at    TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE room ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE coworking ALTER COLUMN created_at TYPE TIMESTAMP;
+ALTER TABLE "user" ALTER COLUMN created_at TYPE TIMESTAMP;
+
+CREATE FUNCTION migration_room_time_zone(p_room_id INTEGER) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT c.time_zone
+    FROM room r
+    JOIN coworking c ON c.coworking_id = r.coworking_id
+    WHERE r.room_id = p_room_id
+$$;
+
+ALTER TABLE waitlist_entry
+    ALTER COLUMN starts_at TYPE TIMESTAMP
+        USING starts_at AT TIME ZONE COALESCE(migration_room_time_zone(room_id), 'Europe/Moscow'),
+    ALTER COLUMN ends_at TYPE TIMESTAMP
+        USING ends_at AT TIME ZONE COALESCE(migration_room_time_zone(room_id), 'Europe/Moscow'),
+    ALTER COLUMN offer_expires_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE booking_series
+    ALTER COLUMN starts_at  TYPE TIMESTAMP USING starts_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN ends_at    TYPE TIMESTAMP USING ends_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE booking DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking
+    ALTER COLUMN starts_at  TYPE TIMESTAMP USING starts_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN ends_at    TYPE TIMESTAMP USING ends_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE booking ADD CONSTRAINT booking_no_overlap EXCLUDE USING gist (
+    room_id WITH =,
+    tsrange(starts_at, ends_at) WITH &&
+) WHERE (status IN ('pending', 'confirmed'));
+
+DROP FUNCTION migration_room_time_zone(INTEGER);
+
+ALTER TABLE coworking DROP COLUMN time_zone;
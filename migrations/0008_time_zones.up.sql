@@ -0,0 +1,59 @@
+ALTER TABLE coworking ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow';
+
+COMMENT ON COLUMN coworking.time_zone IS 'Часовой пояс IANA (например Europe/Moscow); в нём задаются и отображаются времена бронирований';
+
+-- Время бронирований хранилось как местное время коворкинга; подзапросы в USING
+-- недопустимы, поэтому часовой пояс комнаты берётся через временную функцию
+CREATE FUNCTION migration_room_time_zone(p_room_id INTEGER) RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+    SELECT c.time_zone
+    FROM room r
+    JOIN coworking c ON c.coworking_id = r.coworking_id
+    WHERE r.room_id = p_room_id
+$$;
+
+ALTER TABLE booking DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking
+    ALTER COLUMN starts_at  TYPE TIMESTAMPTZ USING starts_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN ends_at    TYPE TIMESTAMPTZ USING ends_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE booking ADD CONSTRAINT booking_no_overlap EXCLUDE USING gist (
+    room_id WITH =,
+    tstzrange(starts_at, ends_at) WITH &&
+) WHERE (status IN ('pending', 'confirmed'));
+
+COMMENT ON CONSTRAINT booking_no_overlap ON booking IS 'Предотвращает double-booking: одна комната не может быть забронирована на пересекающиеся интервалы времени';
+
+ALTER TABLE booking_series
+    ALTER COLUMN starts_at  TYPE TIMESTAMPTZ USING starts_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN ends_at    TYPE TIMESTAMPTZ USING ends_at AT TIME ZONE migration_room_time_zone(room_id),
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+-- Заявки без комнаты относятся к любому коворкингу; считаем их время московским
+ALTER TABLE waitlist_entry
+    ALTER COLUMN starts_at TYPE TIMESTAMPTZ
+        USING starts_at AT TIME ZONE COALESCE(migration_room_time_zone(room_id), 'Europe/Moscow'),
+    ALTER COLUMN ends_at TYPE TIMESTAMPTZ
+        USING ends_at AT TIME ZONE COALESCE(migration_room_time_zone(room_id), 'Europe/Moscow'),
+    ALTER COLUMN offer_expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+DROP FUNCTION migration_room_time_zone(INTEGER);
+
+-- Служебные отметки времени записывались через NOW() в часовом поясе сессии
+ALTER TABLE "user" ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE coworking ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE room ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE payment
+    ALTER COLUMN paid_at    TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+ALTER TABLE session
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN expires_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN revoked_at TYPE TIMESTAMPTZ;
+ALTER TABLE coworking_manager ALTER COLUMN assigned_at TYPE TIMESTAMPTZ;
+ALTER TABLE booking_status_history ALTER COLUMN changed_at TYPE TIMESTAMPTZ;
+ALTER TABLE payment_adjustment ALTER COLUMN created_at TYPE TIMESTAMPTZ;
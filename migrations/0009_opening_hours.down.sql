@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS coworking_holiday;
+DROP TABLE IF EXISTS opening_hours;
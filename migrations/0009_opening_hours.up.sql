@@ -0,0 +1,46 @@
+CREATE TABLE opening_hours (
+    opening_hours_id SERIAL PRIMARY KEY,
+    coworking_id     INTEGER,
+    room_id          INTEGER,
+    weekday          SMALLINT NOT NULL,
+    opens_at         TIME NOT NULL,
+    closes_at        TIME NOT NULL,
+
+    CONSTRAINT fk_opening_hours_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_opening_hours_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT opening_hours_owner_check CHECK ((coworking_id IS NULL) <> (room_id IS NULL)),
+    CONSTRAINT opening_hours_weekday_check CHECK (weekday BETWEEN 1 AND 7),
+    CONSTRAINT opening_hours_time_check CHECK (opens_at < closes_at)
+);
+
+CREATE INDEX idx_opening_hours_coworking ON opening_hours(coworking_id) WHERE coworking_id IS NOT NULL;
+CREATE INDEX idx_opening_hours_room ON opening_hours(room_id) WHERE room_id IS NOT NULL;
+
+COMMENT ON TABLE opening_hours IS 'Недельное расписание работы коворкинга или отдельной комнаты (местное время коворкинга)';
+COMMENT ON COLUMN opening_hours.room_id IS 'Расписание комнаты целиком заменяет расписание коворкинга';
+COMMENT ON COLUMN opening_hours.weekday IS 'День недели ISO: 1 — понедельник, 7 — воскресенье';
+COMMENT ON COLUMN opening_hours.closes_at IS 'Время закрытия; 24:00 — работа до конца суток';
+
+CREATE TABLE coworking_holiday (
+    holiday_id   SERIAL PRIMARY KEY,
+    coworking_id INTEGER NOT NULL,
+    day          DATE NOT NULL,
+    name         VARCHAR(255) NOT NULL,
+    opens_at     TIME,
+    closes_at    TIME,
+
+    CONSTRAINT fk_coworking_holiday_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT coworking_holiday_day_unique UNIQUE (coworking_id, day),
+    CONSTRAINT coworking_holiday_time_check CHECK (
+        (opens_at IS NULL AND closes_at IS NULL) OR opens_at < closes_at
+    )
+);
+
+COMMENT ON TABLE coworking_holiday IS 'Праздничные и сокращённые дни коворкинга';
+COMMENT ON COLUMN coworking_holiday.opens_at IS 'Часы сокращённого дня; NULL — коворкинг закрыт весь день';
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trigger_booking_blackout ON booking;
+DROP FUNCTION IF EXISTS booking_check_blackout();
+DROP TABLE IF EXISTS blackout;
@@ -0,0 +1,69 @@
+CREATE TABLE blackout (
+    blackout_id  SERIAL PRIMARY KEY,
+    coworking_id INTEGER,
+    room_id      INTEGER,
+    starts_at    TIMESTAMPTZ NOT NULL,
+    ends_at      TIMESTAMPTZ NOT NULL,
+    reason       VARCHAR(255) NOT NULL,
+    created_by   INTEGER NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_blackout_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_blackout_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_blackout_user FOREIGN KEY (created_by)
+        REFERENCES "user"(user_id),
+
+    CONSTRAINT blackout_owner_check CHECK ((coworking_id IS NULL) <> (room_id IS NULL)),
+    CONSTRAINT blackout_time_check CHECK (starts_at < ends_at)
+);
+
+CREATE INDEX idx_blackout_room ON blackout USING gist (room_id, tstzrange(starts_at, ends_at))
+    WHERE room_id IS NOT NULL;
+CREATE INDEX idx_blackout_coworking ON blackout USING gist (coworking_id, tstzrange(starts_at, ends_at))
+    WHERE coworking_id IS NOT NULL;
+
+COMMENT ON TABLE blackout IS 'Периоды, когда комната или весь коворкинг недоступны для бронирования (уборка, ремонт, мероприятие)';
+COMMENT ON COLUMN blackout.room_id IS 'Блокировка одной комнаты; при coworking_id — всех комнат коворкинга';
+
+-- Блокировка действует на бронирования так же, как booking_no_overlap: активное бронирование
+-- не может пересекаться с ней. EXCLUDE не работает между таблицами, поэтому проверку делает
+-- триггер, а гонку с созданием блокировки исключает блокировка строки комнаты: бронирование
+-- берёт её FOR SHARE, создание блокировки — FOR UPDATE (см. CreateBlackout)
+CREATE FUNCTION booking_check_blackout() RETURNS TRIGGER AS $$
+DECLARE
+    bl blackout%ROWTYPE;
+BEGIN
+    IF NEW.status NOT IN ('pending', 'confirmed') THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND NEW.room_id = OLD.room_id
+       AND NEW.starts_at = OLD.starts_at AND NEW.ends_at = OLD.ends_at THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM 1 FROM room WHERE room_id = NEW.room_id FOR SHARE;
+
+    SELECT b.* INTO bl
+    FROM blackout b
+    JOIN room r ON r.room_id = NEW.room_id
+    WHERE (b.room_id = r.room_id OR b.coworking_id = r.coworking_id)
+      AND tstzrange(b.starts_at, b.ends_at) && tstzrange(NEW.starts_at, NEW.ends_at)
+    ORDER BY b.starts_at
+    LIMIT 1;
+
+    IF FOUND THEN
+        RAISE EXCEPTION 'room % is blacked out from % to %: %', NEW.room_id, bl.starts_at, bl.ends_at, bl.reason
+            USING ERRCODE = 'exclusion_violation', CONSTRAINT = 'booking_blackout';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_booking_blackout
+BEFORE INSERT OR UPDATE OF room_id, starts_at, ends_at ON booking
+FOR EACH ROW
+EXECUTE FUNCTION booking_check_blackout();
@@ -0,0 +1,17 @@
+ALTER TABLE booking DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking ADD CONSTRAINT booking_no_overlap EXCLUDE USING gist (
+    room_id WITH =,
+    tstzrange(starts_at, ends_at) WITH &&
+) WHERE (status IN ('pending', 'confirmed'));
+
+COMMENT ON CONSTRAINT booking_no_overlap ON booking IS 'Предотвращает double-booking: одна комната не может быть забронирована на пересекающиеся интервалы времени';
+
+DROP TRIGGER IF EXISTS trigger_booking_blocked_range ON booking;
+DROP FUNCTION IF EXISTS booking_set_blocked_range();
+ALTER TABLE booking DROP COLUMN blocked_range;
+
+ALTER TABLE room
+    DROP CONSTRAINT room_buffer_check,
+    DROP COLUMN teardown_minutes,
+    DROP COLUMN setup_minutes;
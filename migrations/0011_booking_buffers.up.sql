@@ -0,0 +1,52 @@
+ALTER TABLE room
+    ADD COLUMN setup_minutes    INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN teardown_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD CONSTRAINT room_buffer_check CHECK (
+        setup_minutes BETWEEN 0 AND 240 AND teardown_minutes BETWEEN 0 AND 240
+    );
+
+COMMENT ON COLUMN room.setup_minutes IS 'Подготовка комнаты перед бронированием, минуты';
+COMMENT ON COLUMN room.teardown_minutes IS 'Уборка комнаты после бронирования, минуты';
+
+-- Интервал, который бронирование занимает вместе с буферами комнаты. Буферы фиксируются
+-- при создании и переносе бронирования; изменение буферов комнаты на существующие не влияет
+ALTER TABLE booking ADD COLUMN blocked_range TSTZRANGE;
+
+ALTER TABLE booking DISABLE TRIGGER trigger_booking_updated_at;
+UPDATE booking SET blocked_range = tstzrange(starts_at, ends_at);
+ALTER TABLE booking ENABLE TRIGGER trigger_booking_updated_at;
+
+ALTER TABLE booking ALTER COLUMN blocked_range SET NOT NULL;
+
+CREATE FUNCTION booking_set_blocked_range() RETURNS TRIGGER AS $$
+DECLARE
+    setup    INTEGER;
+    teardown INTEGER;
+BEGIN
+    SELECT setup_minutes, teardown_minutes INTO setup, teardown
+    FROM room
+    WHERE room_id = NEW.room_id;
+
+    NEW.blocked_range := tstzrange(
+        NEW.starts_at - make_interval(mins => COALESCE(setup, 0)),
+        NEW.ends_at + make_interval(mins => COALESCE(teardown, 0))
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_booking_blocked_range
+BEFORE INSERT OR UPDATE OF room_id, starts_at, ends_at ON booking
+FOR EACH ROW
+EXECUTE FUNCTION booking_set_blocked_range();
+
+-- Между соседними бронированиями комнаты остаётся teardown предыдущего + setup следующего
+ALTER TABLE booking DROP CONSTRAINT booking_no_overlap;
+
+ALTER TABLE booking ADD CONSTRAINT booking_no_overlap EXCLUDE USING gist (
+    room_id WITH =,
+    blocked_range WITH &&
+) WHERE (status IN ('pending', 'confirmed'));
+
+COMMENT ON CONSTRAINT booking_no_overlap ON booking IS 'Предотвращает double-booking: интервалы бронирований одной комнаты вместе с буферами подготовки и уборки не пересекаются';
+COMMENT ON COLUMN booking.blocked_range IS 'Время бронирования с буферами комнаты; заполняется триггером, в стоимость не входит';
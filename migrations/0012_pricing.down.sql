@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS booking_price_line;
+DROP TABLE IF EXISTS pricing_rule;
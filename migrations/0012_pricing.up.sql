@@ -0,0 +1,70 @@
+CREATE TABLE pricing_rule (
+    pricing_rule_id  SERIAL PRIMARY KEY,
+    coworking_id     INTEGER,
+    room_id          INTEGER,
+    kind             VARCHAR(20) NOT NULL,
+    name             VARCHAR(100) NOT NULL,
+    weekdays         SMALLINT[] NOT NULL DEFAULT '{}',
+    opens_at         TIME,
+    closes_at        TIME,
+    rate_percent     INTEGER,
+    min_minutes      INTEGER,
+    discount_percent INTEGER,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_pricing_rule_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_pricing_rule_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT pricing_rule_owner_check CHECK ((coworking_id IS NULL) <> (room_id IS NULL)),
+    CONSTRAINT pricing_rule_kind_check CHECK (kind IN ('time_window', 'weekend', 'min_duration', 'duration_discount')),
+    CONSTRAINT pricing_rule_weekdays_check CHECK (weekdays <@ ARRAY[1, 2, 3, 4, 5, 6, 7]::SMALLINT[]),
+    CONSTRAINT pricing_rule_rate_check CHECK (rate_percent BETWEEN 1 AND 1000),
+    CONSTRAINT pricing_rule_min_minutes_check CHECK (min_minutes > 0),
+    CONSTRAINT pricing_rule_discount_check CHECK (discount_percent BETWEEN 1 AND 100),
+    CONSTRAINT pricing_rule_fields_check CHECK (
+        CASE kind
+            WHEN 'time_window' THEN opens_at IS NOT NULL AND closes_at IS NOT NULL
+                                    AND opens_at < closes_at AND rate_percent IS NOT NULL
+            WHEN 'weekend' THEN rate_percent IS NOT NULL
+            WHEN 'min_duration' THEN min_minutes IS NOT NULL
+            WHEN 'duration_discount' THEN min_minutes IS NOT NULL AND discount_percent IS NOT NULL
+        END
+    )
+);
+
+CREATE INDEX idx_pricing_rule_coworking ON pricing_rule(coworking_id) WHERE coworking_id IS NOT NULL;
+CREATE INDEX idx_pricing_rule_room ON pricing_rule(room_id) WHERE room_id IS NOT NULL;
+
+COMMENT ON TABLE pricing_rule IS 'Правила цены коворкинга или комнаты: окна пиковых/непиковых часов, выходные, минимальная длительность, скидки за длинные блоки';
+COMMENT ON COLUMN pricing_rule.room_id IS 'Правила комнаты заменяют правила коворкинга того же вида (kind)';
+COMMENT ON COLUMN pricing_rule.rate_percent IS 'Ставка в процентах от room.hourly_rate: 125 — пиковые часы, 80 — непиковые';
+COMMENT ON COLUMN pricing_rule.min_minutes IS 'min_duration — минимальная оплачиваемая длительность; duration_discount — длина блока, с которой действует скидка';
+
+CREATE TABLE booking_price_line (
+    price_line_id SERIAL PRIMARY KEY,
+    booking_id    INTEGER NOT NULL,
+    position      SMALLINT NOT NULL,
+    kind          VARCHAR(20) NOT NULL,
+    description   VARCHAR(255) NOT NULL,
+    minutes       INTEGER NOT NULL,
+    hourly_rate   DECIMAL(10, 2) NOT NULL,
+    amount        DECIMAL(10, 2) NOT NULL,
+
+    CONSTRAINT fk_booking_price_line_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE CASCADE,
+
+    CONSTRAINT booking_price_line_position_unique UNIQUE (booking_id, position),
+    CONSTRAINT booking_price_line_kind_check CHECK (kind IN ('time', 'minimum', 'discount'))
+);
+
+COMMENT ON TABLE booking_price_line IS 'Расчёт стоимости бронирования: сумма amount по строкам равна booking.total_amount';
+
+-- Существующие бронирования рассчитаны по базовой ставке одной строкой
+INSERT INTO booking_price_line (booking_id, position, kind, description, minutes, hourly_rate, amount)
+SELECT b.booking_id, 1, 'time', 'Базовый тариф',
+       ROUND(EXTRACT(EPOCH FROM (b.ends_at - b.starts_at)) / 60), r.hourly_rate, b.total_amount
+FROM booking b
+JOIN room r ON b.room_id = r.room_id;
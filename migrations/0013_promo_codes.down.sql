@@ -0,0 +1,7 @@
+DELETE FROM booking_price_line WHERE kind = 'promo';
+ALTER TABLE booking_price_line DROP CONSTRAINT booking_price_line_kind_check;
+ALTER TABLE booking_price_line ADD CONSTRAINT booking_price_line_kind_check
+    CHECK (kind IN ('time', 'minimum', 'discount'));
+
+DROP TABLE IF EXISTS promo_redemption;
+DROP TABLE IF EXISTS promo_code;
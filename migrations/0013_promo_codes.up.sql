@@ -0,0 +1,70 @@
+CREATE TABLE promo_code (
+    promo_code_id    SERIAL PRIMARY KEY,
+    code             VARCHAR(50) NOT NULL,
+    description      VARCHAR(255),
+    discount_percent INTEGER,
+    discount_amount  DECIMAL(10, 2),
+    valid_from       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    valid_until      TIMESTAMPTZ,
+    max_redemptions  INTEGER,
+    max_per_user     INTEGER,
+    coworking_id     INTEGER,
+    room_id          INTEGER,
+    is_active        BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by       INTEGER NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_promo_code_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_promo_code_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_promo_code_user FOREIGN KEY (created_by)
+        REFERENCES "user"(user_id),
+
+    CONSTRAINT promo_code_code_check CHECK (code ~ '^[A-Z0-9_-]+$'),
+    CONSTRAINT promo_code_discount_check CHECK ((discount_percent IS NULL) <> (discount_amount IS NULL)),
+    CONSTRAINT promo_code_percent_check CHECK (discount_percent BETWEEN 1 AND 100),
+    CONSTRAINT promo_code_amount_check CHECK (discount_amount > 0),
+    CONSTRAINT promo_code_validity_check CHECK (valid_until > valid_from),
+    CONSTRAINT promo_code_limits_check CHECK (max_redemptions > 0 AND max_per_user > 0),
+    CONSTRAINT promo_code_scope_check CHECK (coworking_id IS NULL OR room_id IS NULL)
+);
+
+CREATE UNIQUE INDEX promo_code_code_unique ON promo_code(code);
+
+COMMENT ON TABLE promo_code IS 'Промокоды: скидка в процентах или фиксированной суммой, срок действия, лимиты погашений, ограничение коворкингом или комнатой';
+COMMENT ON COLUMN promo_code.code IS 'Код в верхнем регистре; вводится без учёта регистра';
+COMMENT ON COLUMN promo_code.max_redemptions IS 'Общий лимит погашений; погашения отменённых бронирований не учитываются. NULL — без лимита';
+COMMENT ON COLUMN promo_code.max_per_user IS 'Лимит погашений одним пользователем; NULL — без лимита';
+
+CREATE TABLE promo_redemption (
+    redemption_id SERIAL PRIMARY KEY,
+    promo_code_id INTEGER NOT NULL,
+    booking_id    INTEGER NOT NULL,
+    user_id       INTEGER NOT NULL,
+    amount        DECIMAL(10, 2) NOT NULL,
+    redeemed_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_promo_redemption_code FOREIGN KEY (promo_code_id)
+        REFERENCES promo_code(promo_code_id),
+
+    CONSTRAINT fk_promo_redemption_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_promo_redemption_user FOREIGN KEY (user_id)
+        REFERENCES "user"(user_id),
+
+    CONSTRAINT promo_redemption_booking_unique UNIQUE (booking_id),
+    CONSTRAINT promo_redemption_amount_check CHECK (amount >= 0)
+);
+
+CREATE INDEX idx_promo_redemption_code_user ON promo_redemption(promo_code_id, user_id);
+
+COMMENT ON TABLE promo_redemption IS 'Погашения промокодов: одно на бронирование; amount — скидка, вошедшая в booking.total_amount';
+
+-- Скидка по промокоду — отдельная строка расчёта цены
+ALTER TABLE booking_price_line DROP CONSTRAINT booking_price_line_kind_check;
+ALTER TABLE booking_price_line ADD CONSTRAINT booking_price_line_kind_check
+    CHECK (kind IN ('time', 'minimum', 'discount', 'promo'));
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS refund;
+DROP TABLE IF EXISTS cancellation_tier;
@@ -0,0 +1,53 @@
+CREATE TABLE cancellation_tier (
+    tier_id          SERIAL PRIMARY KEY,
+    coworking_id     INTEGER,
+    room_id          INTEGER,
+    min_hours_before INTEGER NOT NULL,
+    refund_percent   INTEGER NOT NULL,
+
+    CONSTRAINT fk_cancellation_tier_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT fk_cancellation_tier_room FOREIGN KEY (room_id)
+        REFERENCES room(room_id) ON DELETE CASCADE,
+
+    CONSTRAINT cancellation_tier_owner_check CHECK ((coworking_id IS NULL) <> (room_id IS NULL)),
+    CONSTRAINT cancellation_tier_hours_check CHECK (min_hours_before >= 0),
+    CONSTRAINT cancellation_tier_percent_check CHECK (refund_percent BETWEEN 0 AND 100),
+    CONSTRAINT cancellation_tier_coworking_unique UNIQUE (coworking_id, min_hours_before),
+    CONSTRAINT cancellation_tier_room_unique UNIQUE (room_id, min_hours_before)
+);
+
+COMMENT ON TABLE cancellation_tier IS 'Политика отмены коворкинга или комнаты: при отмене не позднее чем за min_hours_before часов до начала возвращается refund_percent оплаты';
+COMMENT ON COLUMN cancellation_tier.room_id IS 'Ступени комнаты целиком заменяют политику коворкинга';
+
+CREATE TABLE refund (
+    refund_id      SERIAL PRIMARY KEY,
+    booking_id     INTEGER NOT NULL,
+    payment_id     INTEGER NOT NULL,
+    amount         DECIMAL(10, 2) NOT NULL,
+    refund_percent INTEGER NOT NULL,
+    reason         VARCHAR(50) NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_refund_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_refund_payment FOREIGN KEY (payment_id)
+        REFERENCES payment(payment_id) ON DELETE RESTRICT,
+
+    CONSTRAINT refund_amount_check CHECK (amount > 0),
+    CONSTRAINT refund_percent_check CHECK (refund_percent BETWEEN 1 AND 100)
+);
+
+CREATE INDEX idx_refund_booking ON refund(booking_id);
+CREATE INDEX idx_refund_payment ON refund(payment_id);
+
+COMMENT ON TABLE refund IS 'Возвраты при отмене бронирования по политике отмены; частичный возврат оставляет платёж в статусе paid';
+
+-- Возвраты, сделанные до появления политик, были полными
+INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason, created_at)
+SELECT p.booking_id, p.payment_id, p.amount, 100, 'cancelled_by_user', b.updated_at
+FROM payment p
+JOIN booking b ON p.booking_id = b.booking_id
+WHERE p.status = 'refunded' AND p.amount > 0;
@@ -0,0 +1,97 @@
+DROP VIEW IF EXISTS booking_details;
+DROP VIEW IF EXISTS booking_balance;
+
+CREATE VIEW booking_details AS
+SELECT
+    b.booking_id,
+    b.starts_at,
+    b.ends_at,
+    b.total_amount,
+    b.status AS booking_status,
+    b.created_at,
+    b.updated_at,
+    u.user_id,
+    u.email AS user_email,
+    u.full_name AS user_name,
+    r.room_id,
+    r.name AS room_name,
+    r.capacity,
+    r.hourly_rate,
+    c.coworking_id,
+    c.name AS coworking_name,
+    c.address AS coworking_address,
+    p.payment_id,
+    p.status AS payment_status,
+    p.paid_at
+FROM booking b
+JOIN "user" u ON b.user_id = u.user_id
+JOIN room r ON b.room_id = r.room_id
+JOIN coworking c ON r.coworking_id = c.coworking_id
+LEFT JOIN payment p ON b.booking_id = p.booking_id;
+
+COMMENT ON VIEW booking_details IS 'Полная информация о бронированиях с деталями комнат, пользователей и платежей';
+
+CREATE TABLE payment_adjustment (
+    adjustment_id SERIAL PRIMARY KEY,
+    booking_id    INTEGER NOT NULL,
+    payment_id    INTEGER NOT NULL,
+    amount        DECIMAL(10, 2) NOT NULL,
+    status        VARCHAR(20) NOT NULL,
+    reason        VARCHAR(50) NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT fk_payment_adjustment_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_payment_adjustment_payment FOREIGN KEY (payment_id)
+        REFERENCES payment(payment_id) ON DELETE RESTRICT,
+
+    CONSTRAINT payment_adjustment_amount_check CHECK (amount <> 0),
+    CONSTRAINT payment_adjustment_status_check CHECK (status IN ('pending', 'paid', 'refunded'))
+);
+
+CREATE INDEX idx_payment_adjustment_booking ON payment_adjustment(booking_id);
+
+COMMENT ON TABLE payment_adjustment IS 'Доплаты и возвраты по уже оплаченным бронированиям (перенос, продление)';
+COMMENT ON COLUMN payment_adjustment.amount IS 'Положительная — клиент доплачивает, отрицательная — возврат клиенту';
+COMMENT ON COLUMN payment_adjustment.status IS 'Статус: pending (доплата ожидается), paid (доплата получена), refunded (разница возвращена)';
+
+-- Дополнительные платежи и возвраты без процента сворачиваются в корректировки первого платежа
+CREATE TEMP TABLE first_payment ON COMMIT DROP AS
+SELECT DISTINCT ON (booking_id) booking_id, payment_id
+FROM payment
+ORDER BY booking_id, created_at, payment_id;
+
+INSERT INTO payment_adjustment (booking_id, payment_id, amount, status, reason, created_at)
+SELECT p.booking_id, f.payment_id, p.amount,
+       CASE WHEN p.status = 'paid' THEN 'paid' ELSE 'pending' END, 'reschedule', p.created_at
+FROM payment p
+JOIN first_payment f ON p.booking_id = f.booking_id
+WHERE p.payment_id <> f.payment_id AND p.status IN ('pending', 'paid') AND p.amount > 0
+UNION ALL
+SELECT rf.booking_id, f.payment_id, -rf.amount, 'refunded', rf.reason, rf.created_at
+FROM refund rf
+JOIN first_payment f ON rf.booking_id = f.booking_id
+WHERE rf.refund_percent IS NULL;
+
+DELETE FROM refund WHERE refund_percent IS NULL;
+UPDATE refund rf SET payment_id = f.payment_id
+FROM first_payment f
+WHERE rf.booking_id = f.booking_id;
+DELETE FROM payment p
+WHERE NOT EXISTS (SELECT 1 FROM first_payment f WHERE f.payment_id = p.payment_id);
+ALTER TABLE refund ALTER COLUMN refund_percent SET NOT NULL;
+
+ALTER TABLE payment DROP CONSTRAINT payment_status_check;
+UPDATE payment p SET status = 'refunded'
+WHERE p.status = 'paid'
+  AND (SELECT COALESCE(SUM(rf.amount), 0) FROM refund rf WHERE rf.payment_id = p.payment_id) >= p.amount;
+UPDATE payment SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE payment ADD CONSTRAINT payment_status_check
+    CHECK (status IN ('pending', 'paid', 'failed', 'refunded'));
+
+COMMENT ON TABLE payment IS 'Платежи за бронирования';
+COMMENT ON COLUMN payment.status IS 'Статус: pending (ожидает оплаты), paid (оплачено), failed (ошибка), refunded (возврат)';
+COMMENT ON TABLE refund IS 'Возвраты при отмене бронирования по политике отмены; частичный возврат оставляет платёж в статусе paid';
+
+ALTER TABLE payment ADD CONSTRAINT payment_booking_id_key UNIQUE (booking_id);
@@ -0,0 +1,118 @@
+-- Бронирование может иметь несколько платежей: доплаты, депозиты, оплату частями
+ALTER TABLE payment DROP CONSTRAINT payment_booking_id_key;
+
+-- Доплаты за перенос становятся отдельными платежами
+INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, created_at)
+SELECT pa.booking_id, pa.amount, pa.status, p.payment_method,
+       CASE WHEN pa.status = 'paid' THEN pa.created_at END, pa.created_at
+FROM payment_adjustment pa
+JOIN payment p ON pa.payment_id = p.payment_id
+WHERE pa.amount > 0;
+
+-- Возвраты разницы при переносе — возвратами без процента политики отмены
+ALTER TABLE refund ALTER COLUMN refund_percent DROP NOT NULL;
+
+INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason, created_at)
+SELECT pa.booking_id, pa.payment_id, -pa.amount, NULL, pa.reason, pa.created_at
+FROM payment_adjustment pa
+WHERE pa.amount < 0;
+
+DROP TABLE payment_adjustment;
+
+-- Возврат записывается в refund, сам платёж остаётся оплаченным;
+-- cancelled — ожидавшийся платёж больше не нужен (отмена или удешевление бронирования)
+ALTER TABLE payment DROP CONSTRAINT payment_status_check;
+UPDATE payment SET status = 'paid' WHERE status = 'refunded';
+UPDATE payment p SET status = 'cancelled'
+FROM booking b
+WHERE p.booking_id = b.booking_id AND p.status = 'pending' AND b.status = 'cancelled';
+ALTER TABLE payment ADD CONSTRAINT payment_status_check
+    CHECK (status IN ('pending', 'paid', 'failed', 'cancelled'));
+
+COMMENT ON TABLE payment IS 'Платежи за бронирования; у бронирования может быть несколько платежей';
+COMMENT ON COLUMN payment.status IS 'Статус: pending (ожидает оплаты), paid (оплачено), failed (ошибка или истёкшая бронь), cancelled (больше не требуется)';
+COMMENT ON TABLE refund IS 'Возвраты по оплаченным платежам: при отмене по политике отмены (refund_percent) и при удешевлении бронирования';
+
+-- Баланс бронирования по всем платежам и возвратам
+CREATE VIEW booking_balance AS
+SELECT
+    s.booking_id,
+    s.total_amount,
+    s.paid_amount,
+    s.pending_amount,
+    s.refunded_amount,
+    s.paid_amount - s.refunded_amount AS balance,
+    s.total_amount - (s.paid_amount - s.refunded_amount) AS amount_due,
+    CASE
+        WHEN NOT s.has_payments THEN 'no_payment'
+        WHEN s.has_paid AND s.paid_amount - s.refunded_amount >= s.total_amount THEN 'paid'
+        WHEN s.refunded_amount > 0 AND s.paid_amount = s.refunded_amount THEN 'refunded'
+        WHEN s.refunded_amount > 0 THEN 'partially_refunded'
+        WHEN s.paid_amount > 0 THEN 'partially_paid'
+        WHEN s.has_pending THEN 'pending'
+        ELSE 'failed'
+    END AS payment_status,
+    s.last_paid_at
+FROM (
+    SELECT
+        b.booking_id,
+        b.total_amount,
+        COALESCE(p.paid, 0) AS paid_amount,
+        COALESCE(p.pending, 0) AS pending_amount,
+        COALESCE(rf.refunded, 0) AS refunded_amount,
+        p.booking_id IS NOT NULL AS has_payments,
+        p.paid IS NOT NULL AS has_paid,
+        p.pending IS NOT NULL AS has_pending,
+        p.last_paid_at
+    FROM booking b
+    LEFT JOIN (
+        SELECT
+            booking_id,
+            SUM(amount) FILTER (WHERE status = 'paid') AS paid,
+            SUM(amount) FILTER (WHERE status = 'pending') AS pending,
+            MAX(paid_at) AS last_paid_at
+        FROM payment
+        GROUP BY booking_id
+    ) p ON p.booking_id = b.booking_id
+    LEFT JOIN (
+        SELECT booking_id, SUM(amount) AS refunded
+        FROM refund
+        GROUP BY booking_id
+    ) rf ON rf.booking_id = b.booking_id
+) s;
+
+COMMENT ON VIEW booking_balance IS 'Баланс бронирования: balance — оплачено за вычетом возвратов, amount_due — остаток к оплате (< 0 — переплата)';
+
+-- Сведения о платеже в booking_details берутся из баланса
+DROP VIEW booking_details;
+
+CREATE VIEW booking_details AS
+SELECT
+    b.booking_id,
+    b.starts_at,
+    b.ends_at,
+    b.total_amount,
+    b.status AS booking_status,
+    b.created_at,
+    b.updated_at,
+    u.user_id,
+    u.email AS user_email,
+    u.full_name AS user_name,
+    r.room_id,
+    r.name AS room_name,
+    r.capacity,
+    r.hourly_rate,
+    c.coworking_id,
+    c.name AS coworking_name,
+    c.address AS coworking_address,
+    bb.payment_status,
+    bb.balance,
+    bb.amount_due,
+    bb.last_paid_at AS paid_at
+FROM booking b
+JOIN "user" u ON b.user_id = u.user_id
+JOIN room r ON b.room_id = r.room_id
+JOIN coworking c ON r.coworking_id = c.coworking_id
+JOIN booking_balance bb ON b.booking_id = bb.booking_id;
+
+COMMENT ON VIEW booking_details IS 'Полная информация о бронированиях с деталями комнат, пользователей и балансом оплаты';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS payment_event;
+
+DROP INDEX IF EXISTS idx_payment_provider_ref;
+
+ALTER TABLE payment
+    DROP CONSTRAINT IF EXISTS payment_provider_ref_check,
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS provider_ref,
+    DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,32 @@
+-- Платёж, проводимый через платёжный шлюз, хранит идентификатор намерения оплаты в шлюзе
+ALTER TABLE payment
+    ADD COLUMN provider       VARCHAR(32),
+    ADD COLUMN provider_ref   VARCHAR(128),
+    ADD COLUMN failure_reason VARCHAR(255),
+    ADD CONSTRAINT payment_provider_ref_check CHECK ((provider IS NULL) = (provider_ref IS NULL));
+
+CREATE UNIQUE INDEX idx_payment_provider_ref ON payment(provider, provider_ref) WHERE provider_ref IS NOT NULL;
+
+COMMENT ON COLUMN payment.provider IS 'Платёжный шлюз, через который проводится платёж (NULL — подтверждается вручную)';
+COMMENT ON COLUMN payment.provider_ref IS 'Идентификатор намерения оплаты в шлюзе';
+COMMENT ON COLUMN payment.failure_reason IS 'Причина отказа, сообщённая шлюзом';
+
+-- Уведомления шлюза: повторная доставка того же события не применяется дважды
+CREATE TABLE payment_event (
+    provider    VARCHAR(32) NOT NULL,
+    event_id    VARCHAR(128) NOT NULL,
+    event_type  VARCHAR(32) NOT NULL,
+    payment_id  INTEGER,
+    outcome     VARCHAR(32),
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (provider, event_id),
+
+    CONSTRAINT fk_payment_event_payment FOREIGN KEY (payment_id)
+        REFERENCES payment(payment_id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_payment_event_payment ON payment_event(payment_id);
+
+COMMENT ON TABLE payment_event IS 'Обработанные уведомления платёжного шлюза';
+COMMENT ON COLUMN payment_event.outcome IS 'Результат: confirmed, paid, failed, refunded или ignored (событие не меняет платёж)';
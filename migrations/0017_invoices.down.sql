@@ -0,0 +1,11 @@
+DROP FUNCTION IF EXISTS issue_document(INTEGER, VARCHAR, TIMESTAMPTZ);
+
+DROP TABLE IF EXISTS invoice_line;
+DROP TABLE IF EXISTS invoice;
+DROP TABLE IF EXISTS document_counter;
+
+ALTER TABLE coworking
+    DROP CONSTRAINT IF EXISTS coworking_vat_percent_check,
+    DROP COLUMN IF EXISTS vat_percent,
+    DROP COLUMN IF EXISTS tax_id,
+    DROP COLUMN IF EXISTS legal_name;
@@ -0,0 +1,162 @@
+-- Реквизиты юридического лица коворкинга для счетов и квитанций
+ALTER TABLE coworking
+    ADD COLUMN legal_name  VARCHAR(255),
+    ADD COLUMN tax_id      VARCHAR(12),
+    ADD COLUMN vat_percent INTEGER NOT NULL DEFAULT 20,
+    ADD CONSTRAINT coworking_vat_percent_check CHECK (vat_percent BETWEEN 0 AND 100);
+
+COMMENT ON COLUMN coworking.legal_name IS 'Юридическое лицо, от имени которого выставляются счета (NULL — название коворкинга)';
+COMMENT ON COLUMN coworking.tax_id IS 'ИНН юридического лица';
+COMMENT ON COLUMN coworking.vat_percent IS 'Ставка НДС, включённого в цены, %; 0 — без НДС';
+
+-- Сквозная нумерация документов каждого вида в пределах коворкинга
+CREATE TABLE document_counter (
+    coworking_id INTEGER NOT NULL,
+    kind         VARCHAR(10) NOT NULL,
+    last_number  INTEGER NOT NULL,
+
+    PRIMARY KEY (coworking_id, kind),
+
+    CONSTRAINT fk_document_counter_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE,
+
+    CONSTRAINT document_counter_kind_check CHECK (kind IN ('invoice', 'receipt'))
+);
+
+COMMENT ON TABLE document_counter IS 'Последний выданный номер счёта или квитанции коворкинга; строка блокируется на время выдачи номера';
+
+-- Счёт выставляется на каждый ожидающий платёж, квитанция — на каждый оплаченный.
+-- Документ — неизменяемый снимок: реквизиты, комната, период и расчёт цены на момент выдачи
+CREATE TABLE invoice (
+    invoice_id     SERIAL PRIMARY KEY,
+    kind           VARCHAR(10) NOT NULL,
+    coworking_id   INTEGER NOT NULL,
+    number         INTEGER NOT NULL,
+    booking_id     INTEGER NOT NULL,
+    payment_id     INTEGER NOT NULL,
+    issued_at      TIMESTAMPTZ NOT NULL,
+    seller_name    VARCHAR(255) NOT NULL,
+    seller_tax_id  VARCHAR(12),
+    seller_address VARCHAR(500) NOT NULL,
+    buyer_name     VARCHAR(255) NOT NULL,
+    buyer_email    VARCHAR(255) NOT NULL,
+    room_name      VARCHAR(255) NOT NULL,
+    starts_at      TIMESTAMPTZ NOT NULL,
+    ends_at        TIMESTAMPTZ NOT NULL,
+    time_zone      VARCHAR(64) NOT NULL,
+    booking_total  DECIMAL(10, 2) NOT NULL,
+    amount         DECIMAL(10, 2) NOT NULL,
+    vat_percent    INTEGER NOT NULL,
+    vat_amount     DECIMAL(10, 2) NOT NULL,
+    payment_method VARCHAR(50),
+    paid_at        TIMESTAMPTZ,
+
+    CONSTRAINT fk_invoice_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_invoice_booking FOREIGN KEY (booking_id)
+        REFERENCES booking(booking_id) ON DELETE RESTRICT,
+
+    CONSTRAINT fk_invoice_payment FOREIGN KEY (payment_id)
+        REFERENCES payment(payment_id) ON DELETE RESTRICT,
+
+    CONSTRAINT invoice_kind_check CHECK (kind IN ('invoice', 'receipt')),
+    CONSTRAINT invoice_amount_check CHECK (amount > 0),
+    CONSTRAINT invoice_receipt_paid_check CHECK ((kind = 'receipt') = (paid_at IS NOT NULL)),
+    CONSTRAINT invoice_number_unique UNIQUE (coworking_id, kind, number),
+    CONSTRAINT invoice_payment_unique UNIQUE (payment_id, kind)
+);
+
+CREATE INDEX idx_invoice_booking ON invoice(booking_id);
+
+COMMENT ON TABLE invoice IS 'Счета (kind = invoice) и квитанции об оплате (kind = receipt) с номером, сквозным в пределах коворкинга и вида';
+COMMENT ON COLUMN invoice.amount IS 'Сумма документа — сумма платежа; НДС включён в неё';
+
+CREATE TABLE invoice_line (
+    invoice_id  INTEGER NOT NULL,
+    position    SMALLINT NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    minutes     INTEGER NOT NULL,
+    hourly_rate DECIMAL(10, 2) NOT NULL,
+    amount      DECIMAL(10, 2) NOT NULL,
+
+    PRIMARY KEY (invoice_id, position),
+
+    CONSTRAINT fk_invoice_line_invoice FOREIGN KEY (invoice_id)
+        REFERENCES invoice(invoice_id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE invoice_line IS 'Расчёт стоимости бронирования на момент выдачи документа';
+
+-- Выдаёт документ kind по платежу и возвращает его ID; повторный вызов возвращает
+-- уже выданный документ, не расходуя номер
+CREATE FUNCTION issue_document(p_payment_id INTEGER, p_kind VARCHAR, p_issued_at TIMESTAMPTZ)
+RETURNS INTEGER AS $$
+DECLARE
+    v_coworking_id INTEGER;
+    v_number       INTEGER;
+    v_invoice_id   INTEGER;
+BEGIN
+    SELECT invoice_id INTO v_invoice_id
+    FROM invoice
+    WHERE payment_id = p_payment_id AND kind = p_kind;
+    IF FOUND THEN
+        RETURN v_invoice_id;
+    END IF;
+
+    SELECT r.coworking_id INTO STRICT v_coworking_id
+    FROM payment p
+    JOIN booking b ON p.booking_id = b.booking_id
+    JOIN room r ON b.room_id = r.room_id
+    WHERE p.payment_id = p_payment_id;
+
+    INSERT INTO document_counter (coworking_id, kind, last_number)
+    VALUES (v_coworking_id, p_kind, 1)
+    ON CONFLICT (coworking_id, kind) DO UPDATE SET last_number = document_counter.last_number + 1
+    RETURNING last_number INTO v_number;
+
+    INSERT INTO invoice (
+        kind, coworking_id, number, booking_id, payment_id, issued_at,
+        seller_name, seller_tax_id, seller_address, buyer_name, buyer_email,
+        room_name, starts_at, ends_at, time_zone, booking_total,
+        amount, vat_percent, vat_amount, payment_method, paid_at
+    )
+    SELECT p_kind, c.coworking_id, v_number, b.booking_id, p.payment_id, p_issued_at,
+           COALESCE(c.legal_name, c.name), c.tax_id, c.address, u.full_name, u.email,
+           r.name, b.starts_at, b.ends_at, c.time_zone, b.total_amount,
+           p.amount, c.vat_percent, ROUND(p.amount * c.vat_percent / (100 + c.vat_percent), 2),
+           p.payment_method, CASE WHEN p_kind = 'receipt' THEN COALESCE(p.paid_at, p_issued_at) END
+    FROM payment p
+    JOIN booking b ON p.booking_id = b.booking_id
+    JOIN room r ON b.room_id = r.room_id
+    JOIN coworking c ON r.coworking_id = c.coworking_id
+    JOIN "user" u ON b.user_id = u.user_id
+    WHERE p.payment_id = p_payment_id
+    RETURNING invoice_id INTO v_invoice_id;
+
+    INSERT INTO invoice_line (invoice_id, position, description, minutes, hourly_rate, amount)
+    SELECT v_invoice_id, l.position, l.description, l.minutes, l.hourly_rate, l.amount
+    FROM booking_price_line l
+    JOIN payment p ON p.booking_id = l.booking_id
+    WHERE p.payment_id = p_payment_id;
+
+    RETURN v_invoice_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Документы по существующим платежам в порядке их создания и оплаты; на нулевые платежи
+-- (бесплатные бронирования до этой миграции) документы не выдаются — см. invoice_amount_check
+DO $$
+DECLARE
+    d RECORD;
+BEGIN
+    FOR d IN
+        SELECT payment_id, 'invoice' AS kind, created_at AS issued_at FROM payment WHERE amount > 0
+        UNION ALL
+        SELECT payment_id, 'receipt', COALESCE(paid_at, created_at) FROM payment WHERE status = 'paid' AND amount > 0
+        ORDER BY issued_at, payment_id, kind
+    LOOP
+        PERFORM issue_document(d.payment_id, d.kind, d.issued_at);
+    END LOOP;
+END;
+$$;
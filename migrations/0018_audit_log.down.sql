@@ -0,0 +1,26 @@
+DROP TRIGGER IF EXISTS trigger_audit_waitlist_entry ON waitlist_entry;
+DROP TRIGGER IF EXISTS trigger_audit_invoice ON invoice;
+DROP TRIGGER IF EXISTS trigger_audit_refund ON refund;
+DROP TRIGGER IF EXISTS trigger_audit_payment ON payment;
+DROP TRIGGER IF EXISTS trigger_audit_promo_redemption ON promo_redemption;
+DROP TRIGGER IF EXISTS trigger_audit_booking ON booking;
+DROP TRIGGER IF EXISTS trigger_audit_booking_series ON booking_series;
+DROP TRIGGER IF EXISTS trigger_audit_cancellation_tier ON cancellation_tier;
+DROP TRIGGER IF EXISTS trigger_audit_promo_code ON promo_code;
+DROP TRIGGER IF EXISTS trigger_audit_pricing_rule ON pricing_rule;
+DROP TRIGGER IF EXISTS trigger_audit_blackout ON blackout;
+DROP TRIGGER IF EXISTS trigger_audit_coworking_holiday ON coworking_holiday;
+DROP TRIGGER IF EXISTS trigger_audit_opening_hours ON opening_hours;
+DROP TRIGGER IF EXISTS trigger_audit_room_equipment ON room_equipment;
+DROP TRIGGER IF EXISTS trigger_audit_equipment ON equipment;
+DROP TRIGGER IF EXISTS trigger_audit_room ON room;
+DROP TRIGGER IF EXISTS trigger_audit_coworking_manager ON coworking_manager;
+DROP TRIGGER IF EXISTS trigger_audit_coworking ON coworking;
+DROP TRIGGER IF EXISTS trigger_audit_user ON "user";
+DROP FUNCTION IF EXISTS audit_row_change();
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_immutable();
+
+DROP TRIGGER IF EXISTS trigger_payment_updated_at ON payment;
+ALTER TABLE payment DROP COLUMN IF EXISTS updated_at;
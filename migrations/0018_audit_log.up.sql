@@ -0,0 +1,142 @@
+-- У платежа появляется время последнего изменения, как у бронирования
+ALTER TABLE payment ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+UPDATE payment SET updated_at = COALESCE(paid_at, created_at);
+
+CREATE TRIGGER trigger_payment_updated_at
+BEFORE UPDATE ON payment
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Журнал изменений: одна запись на каждую вставленную, изменённую или удалённую строку
+-- отслеживаемых таблиц, в той же транзакции, что и само изменение
+CREATE TABLE audit_log (
+    audit_id      BIGSERIAL PRIMARY KEY,
+    occurred_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    tx_id         BIGINT NOT NULL DEFAULT txid_current(),
+    actor_user_id INTEGER,
+    actor         VARCHAR(255) NOT NULL,
+    action        VARCHAR(10) NOT NULL CHECK (action IN ('insert', 'update', 'delete')),
+    entity        VARCHAR(50) NOT NULL,
+    entity_id     INTEGER,
+    booking_id    INTEGER,
+    old_values    JSONB,
+    new_values    JSONB
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id, audit_id);
+CREATE INDEX idx_audit_log_booking ON audit_log(booking_id, audit_id) WHERE booking_id IS NOT NULL;
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_user_id, audit_id) WHERE actor_user_id IS NOT NULL;
+
+COMMENT ON TABLE audit_log IS 'Журнал изменений (только добавление): кто, когда и как изменил строки отслеживаемых таблиц';
+COMMENT ON COLUMN audit_log.tx_id IS 'Транзакция изменения: записи одной операции имеют одинаковый tx_id';
+COMMENT ON COLUMN audit_log.actor_user_id IS 'Пользователь, выполнивший изменение; NULL — системный процесс или SQL вне приложения';
+COMMENT ON COLUMN audit_log.actor IS 'Исполнитель: email пользователя, имя системного процесса (scheduler, payment_gateway:<шлюз>) или sql:<роль БД>';
+COMMENT ON COLUMN audit_log.entity IS 'Таблица изменённой строки';
+COMMENT ON COLUMN audit_log.entity_id IS 'Первичный ключ строки; NULL для таблиц с составным ключом (room_equipment, coworking_manager)';
+COMMENT ON COLUMN audit_log.booking_id IS 'Бронирование, к которому относится строка (платежи, возвраты, документы), — для истории бронирования';
+COMMENT ON COLUMN audit_log.old_values IS 'Значения до изменения: вся строка при delete, только изменившиеся колонки при update';
+COMMENT ON COLUMN audit_log.new_values IS 'Значения после изменения: вся строка при insert, только изменившиеся колонки при update';
+
+-- Записи журнала не изменяются и не удаляются
+CREATE FUNCTION audit_log_immutable() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only'
+        USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_audit_log_immutable
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW
+EXECUTE FUNCTION audit_log_immutable();
+
+-- Запись изменения строки. Аргумент триггера — колонка первичного ключа (без аргумента — ключ
+-- составной). Исполнителя задаёт приложение в начале транзакции (database.DB.BeginTx):
+-- app.actor_user_id и app.actor; изменения вне приложения записываются как sql:<роль БД>.
+-- Хеш пароля, служебные updated_at и blocked_range в журнал не попадают, а update без изменений
+-- значимых колонок не записывается
+CREATE FUNCTION audit_row_change() RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB;
+    new_row JSONB;
+    changed_old JSONB;
+    changed_new JSONB;
+    key_row JSONB;
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        old_row := to_jsonb(OLD) - 'password_hash' - 'updated_at' - 'blocked_range';
+    END IF;
+    IF TG_OP <> 'DELETE' THEN
+        new_row := to_jsonb(NEW) - 'password_hash' - 'updated_at' - 'blocked_range';
+    END IF;
+    key_row := COALESCE(new_row, old_row);
+
+    IF TG_OP = 'UPDATE' THEN
+        SELECT jsonb_object_agg(o.key, o.value), jsonb_object_agg(o.key, n.value)
+        INTO changed_old, changed_new
+        FROM jsonb_each(old_row) o
+        JOIN jsonb_each(new_row) n ON n.key = o.key
+        WHERE o.value IS DISTINCT FROM n.value;
+
+        IF changed_old IS NULL THEN
+            RETURN NULL;
+        END IF;
+        old_row := changed_old;
+        new_row := changed_new;
+    END IF;
+
+    INSERT INTO audit_log (actor_user_id, actor, action, entity, entity_id, booking_id, old_values, new_values)
+    VALUES (
+        NULLIF(current_setting('app.actor_user_id', true), '')::INTEGER,
+        COALESCE(NULLIF(current_setting('app.actor', true), ''), 'sql:' || session_user),
+        lower(TG_OP),
+        TG_TABLE_NAME,
+        CASE WHEN TG_NARGS > 0 THEN (key_row ->> TG_ARGV[0])::INTEGER END,
+        (key_row ->> 'booking_id')::INTEGER,
+        old_row,
+        new_row
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION audit_row_change() IS 'Записывает изменение строки в audit_log; аргумент — колонка первичного ключа';
+
+CREATE TRIGGER trigger_audit_user AFTER INSERT OR UPDATE OR DELETE ON "user"
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('user_id');
+CREATE TRIGGER trigger_audit_coworking AFTER INSERT OR UPDATE OR DELETE ON coworking
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('coworking_id');
+CREATE TRIGGER trigger_audit_coworking_manager AFTER INSERT OR UPDATE OR DELETE ON coworking_manager
+FOR EACH ROW EXECUTE FUNCTION audit_row_change();
+CREATE TRIGGER trigger_audit_room AFTER INSERT OR UPDATE OR DELETE ON room
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('room_id');
+CREATE TRIGGER trigger_audit_equipment AFTER INSERT OR UPDATE OR DELETE ON equipment
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('equipment_id');
+CREATE TRIGGER trigger_audit_room_equipment AFTER INSERT OR UPDATE OR DELETE ON room_equipment
+FOR EACH ROW EXECUTE FUNCTION audit_row_change();
+CREATE TRIGGER trigger_audit_opening_hours AFTER INSERT OR UPDATE OR DELETE ON opening_hours
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('opening_hours_id');
+CREATE TRIGGER trigger_audit_coworking_holiday AFTER INSERT OR UPDATE OR DELETE ON coworking_holiday
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('holiday_id');
+CREATE TRIGGER trigger_audit_blackout AFTER INSERT OR UPDATE OR DELETE ON blackout
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('blackout_id');
+CREATE TRIGGER trigger_audit_pricing_rule AFTER INSERT OR UPDATE OR DELETE ON pricing_rule
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('pricing_rule_id');
+CREATE TRIGGER trigger_audit_promo_code AFTER INSERT OR UPDATE OR DELETE ON promo_code
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('promo_code_id');
+CREATE TRIGGER trigger_audit_cancellation_tier AFTER INSERT OR UPDATE OR DELETE ON cancellation_tier
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('tier_id');
+CREATE TRIGGER trigger_audit_booking_series AFTER INSERT OR UPDATE OR DELETE ON booking_series
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('series_id');
+CREATE TRIGGER trigger_audit_booking AFTER INSERT OR UPDATE OR DELETE ON booking
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('booking_id');
+CREATE TRIGGER trigger_audit_promo_redemption AFTER INSERT OR UPDATE OR DELETE ON promo_redemption
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('redemption_id');
+CREATE TRIGGER trigger_audit_payment AFTER INSERT OR UPDATE OR DELETE ON payment
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('payment_id');
+CREATE TRIGGER trigger_audit_refund AFTER INSERT OR UPDATE OR DELETE ON refund
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('refund_id');
+CREATE TRIGGER trigger_audit_invoice AFTER INSERT OR UPDATE OR DELETE ON invoice
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('invoice_id');
+CREATE TRIGGER trigger_audit_waitlist_entry AFTER INSERT OR UPDATE OR DELETE ON waitlist_entry
+FOR EACH ROW EXECUTE FUNCTION audit_row_change('entry_id');
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS trigger_booking_archived ON booking;
+DROP FUNCTION IF EXISTS booking_check_archived();
+
+DROP INDEX IF EXISTS idx_booking_needs_relocation;
+ALTER TABLE booking   DROP COLUMN IF EXISTS needs_relocation;
+ALTER TABLE room      DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE coworking DROP COLUMN IF EXISTS archived_at;
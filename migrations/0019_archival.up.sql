@@ -0,0 +1,45 @@
+ALTER TABLE coworking ADD COLUMN archived_at TIMESTAMPTZ;
+ALTER TABLE room      ADD COLUMN archived_at TIMESTAMPTZ;
+ALTER TABLE booking   ADD COLUMN needs_relocation BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN coworking.archived_at IS 'Коворкинг закрыт: скрыт из поиска, новые бронирования его комнат запрещены; NULL — работает';
+COMMENT ON COLUMN room.archived_at IS 'Комната выведена из работы: скрыта из поиска, новые бронирования запрещены; NULL — работает';
+COMMENT ON COLUMN booking.needs_relocation IS 'Комната или коворкинг бронирования архивированы: бронирование нужно перенести или отменить';
+
+CREATE INDEX idx_booking_needs_relocation ON booking(room_id) WHERE needs_relocation;
+
+-- Архивная комната (или комната архивного коворкинга) не принимает новые активные бронирования
+-- и переносы на неё. Как и в booking_check_blackout, строки комнаты и коворкинга берутся
+-- FOR SHARE, а архивирование меняет их (FOR UPDATE), поэтому параллельное бронирование
+-- либо будет отклонено, либо попадёт в список на перенос
+CREATE FUNCTION booking_check_archived() RETURNS TRIGGER AS $$
+DECLARE
+    room_archived      TIMESTAMPTZ;
+    coworking_archived TIMESTAMPTZ;
+BEGIN
+    IF NEW.status NOT IN ('pending', 'confirmed') THEN
+        RETURN NEW;
+    END IF;
+    IF TG_OP = 'UPDATE' AND NEW.room_id = OLD.room_id
+       AND NEW.starts_at = OLD.starts_at AND NEW.ends_at = OLD.ends_at THEN
+        RETURN NEW;
+    END IF;
+
+    SELECT r.archived_at, c.archived_at INTO room_archived, coworking_archived
+    FROM room r
+    JOIN coworking c ON c.coworking_id = r.coworking_id
+    WHERE r.room_id = NEW.room_id
+    FOR SHARE;
+
+    IF room_archived IS NOT NULL OR coworking_archived IS NOT NULL THEN
+        RAISE EXCEPTION 'room % is archived', NEW.room_id
+            USING ERRCODE = 'exclusion_violation', CONSTRAINT = 'booking_room_archived';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_booking_archived
+BEFORE INSERT OR UPDATE OF room_id, starts_at, ends_at ON booking
+FOR EACH ROW
+EXECUTE FUNCTION booking_check_archived();
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_coworking_city;
+ALTER TABLE coworking DROP COLUMN IF EXISTS city;
@@ -0,0 +1,8 @@
+-- Город коворкинга для фильтра поиска: первая часть адреса до запятой
+-- («Москва, ул. Тверская, д. 10» → «Москва»); пересчитывается при изменении адреса
+ALTER TABLE coworking
+    ADD COLUMN city VARCHAR(500) GENERATED ALWAYS AS (btrim(split_part(address, ',', 1))) STORED;
+
+COMMENT ON COLUMN coworking.city IS 'Город — первая часть адреса до запятой; вычисляется из address';
+
+CREATE INDEX idx_coworking_city ON coworking(lower(city));
@@ -0,0 +1,7 @@
+-- Коворкинг из критериев поиска заявки: освободившийся слот предлагается только в нём
+ALTER TABLE waitlist_entry
+    ADD COLUMN coworking_id INTEGER,
+    ADD CONSTRAINT fk_waitlist_entry_coworking FOREIGN KEY (coworking_id)
+        REFERENCES coworking(coworking_id) ON DELETE CASCADE;
+
+COMMENT ON COLUMN waitlist_entry.coworking_id IS 'Коворкинг из критериев поиска; NULL — любой коворкинг';
@@ -0,0 +1 @@
+ALTER TABLE waitlist_entry DROP COLUMN IF EXISTS city;
@@ -0,0 +1,4 @@
+-- Город из критериев поиска заявки: освободившийся слот предлагается только в его коворкингах
+ALTER TABLE waitlist_entry ADD COLUMN city VARCHAR(500);
+
+COMMENT ON COLUMN waitlist_entry.city IS 'Город из критериев поиска (без учёта регистра, как coworking.city); NULL — любой город';
@@ -0,0 +1,12 @@
+// Package migrations содержит версионированные миграции схемы БД.
+//
+// Файлы именуются NNNN_описание.up.sql / NNNN_описание.down.sql и
+// встраиваются в бинарник; применяются командой `migrate up`.
+package migrations
+
+import "embed"
+
+// FS — встроенные файлы миграций
+//
+//go:embed *.up.sql *.down.sql
+var FS embed.FS
@@ -69,12 +69,22 @@ DELETE FROM room_equipment
 WHERE room_id = 1 AND equipment_id = 1;
 
 -- Поиск свободных комнат на конкретное время
--- Параметры: starts_at = '2024-12-25 10:00:00', ends_at = '2024-12-25 14:00:00'
+-- Параметры: starts_at = '2024-12-25 10:00:00+03', ends_at = '2024-12-25 14:00:00+03' (Europe/Moscow)
+-- Интервал расширяется буферами комнаты так же, как blocked_range в booking_no_overlap
 WITH occupied_rooms AS (
-    SELECT DISTINCT room_id
-    FROM booking
-    WHERE status IN ('pending', 'confirmed')
-      AND tsrange(starts_at, ends_at) && tsrange('2024-12-25 10:00:00', '2024-12-25 14:00:00')
+    SELECT DISTINCT b.room_id
+    FROM booking b
+    JOIN room r ON b.room_id = r.room_id
+    WHERE b.status IN ('pending', 'confirmed')
+      AND b.blocked_range && tstzrange(
+          TIMESTAMPTZ '2024-12-25 10:00:00+03' - make_interval(mins => r.setup_minutes),
+          TIMESTAMPTZ '2024-12-25 14:00:00+03' + make_interval(mins => r.teardown_minutes))
+),
+blacked_out_rooms AS (
+    SELECT r.room_id
+    FROM room r
+    JOIN blackout bl ON bl.room_id = r.room_id OR bl.coworking_id = r.coworking_id
+    WHERE tstzrange(bl.starts_at, bl.ends_at) && tstzrange('2024-12-25 10:00:00+03', '2024-12-25 14:00:00+03')
 )
 SELECT
     r.room_id,
@@ -90,6 +100,7 @@ JOIN coworking c ON r.coworking_id = c.coworking_id
 LEFT JOIN room_equipment re ON r.room_id = re.room_id
 LEFT JOIN equipment e ON re.equipment_id = e.equipment_id
 WHERE r.room_id NOT IN (SELECT room_id FROM occupied_rooms)
+  AND r.room_id NOT IN (SELECT room_id FROM blacked_out_rooms)
 GROUP BY r.room_id, r.name, r.capacity, r.area_sqm, r.hourly_rate, c.name, c.address
 ORDER BY r.hourly_rate;
 
@@ -109,7 +120,7 @@ occupied_rooms AS (
     SELECT DISTINCT room_id
     FROM booking
     WHERE status IN ('pending', 'confirmed')
-      AND tsrange(starts_at, ends_at) && tsrange('2024-12-25 10:00:00', '2024-12-25 14:00:00')
+      AND tstzrange(starts_at, ends_at) && tstzrange('2024-12-25 10:00:00+03', '2024-12-25 14:00:00+03')
 )
 SELECT
     r.room_id,
@@ -133,7 +144,8 @@ ORDER BY r.hourly_rate;
 -- Параметры: room_id=1, user_id=3, starts_at='2024-12-25 10:00:00', ends_at='2024-12-25 14:00:00'
 INSERT INTO booking (room_id, user_id, starts_at, ends_at, total_amount, status)
 SELECT 1, 3, '2024-12-25 10:00:00', '2024-12-25 14:00:00',
-       r.hourly_rate * EXTRACT(EPOCH FROM ('2024-12-25 14:00:00'::timestamp - '2024-12-25 10:00:00'::timestamp)) / 3600,
+       -- неполный час оплачивается пропорционально, округление до копейки half-up (как в money.ForDuration)
+       ROUND(r.hourly_rate * EXTRACT(EPOCH FROM ('2024-12-25 14:00:00'::timestamp - '2024-12-25 10:00:00'::timestamp)) / 3600, 2),
        'pending'
 FROM room r
 WHERE r.room_id = 1
@@ -149,7 +161,7 @@ SELECT COUNT(*) AS conflicts
 FROM booking
 WHERE room_id = 1
   AND status IN ('pending', 'confirmed')
-  AND tsrange(starts_at, ends_at) && tsrange('2024-12-18 11:00:00', '2024-12-18 13:00:00');
+  AND tstzrange(starts_at, ends_at) && tstzrange('2024-12-18 11:00:00+03', '2024-12-18 13:00:00+03');
 -- Если conflicts > 0, то есть пересечение
 
 -- Отмена бронирования пользователем
@@ -166,35 +178,94 @@ SELECT booking_id, room_id, user_id, starts_at, ends_at, status, updated_at
 FROM booking
 WHERE booking_id = 12;
 
--- Создание платежа для бронирования
+-- Создание платежа на остаток к оплате (у бронирования может быть несколько платежей)
 -- Параметры: booking_id=12, payment_method='card'
 INSERT INTO payment (booking_id, amount, status, payment_method)
-VALUES (
-    12,
-    (SELECT total_amount FROM booking WHERE booking_id = 12),
-    'pending',
-    'card'
-)
+SELECT booking_id, amount_due - pending_amount, 'pending', 'card'
+FROM booking_balance
+WHERE booking_id = 12 AND amount_due > pending_amount
 RETURNING payment_id, booking_id, amount, status, payment_method, created_at;
 
 -- Подтверждение оплаты (обновление статуса на 'paid')
--- Параметры: payment_id=17
+-- Параметры: payment_id=18
 UPDATE payment
 SET status = 'paid', paid_at = NOW()
-WHERE payment_id = 17 AND status = 'pending'
+WHERE payment_id = 18 AND status = 'pending'
 RETURNING payment_id, booking_id, status, paid_at;
 
--- Возврат средств (при отмене)
--- Параметры: payment_id=17
-UPDATE payment
-SET status = 'refunded'
-WHERE payment_id = 17 AND status = 'paid'
-RETURNING payment_id, booking_id, status;
+-- Возврат средств: отдельная запись, платёж остаётся оплаченным
+-- Параметры: payment_id=18
+INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason)
+SELECT booking_id, payment_id, amount, 100, 'cancelled_by_user'
+FROM payment
+WHERE payment_id = 18 AND status = 'paid'
+RETURNING refund_id, booking_id, payment_id, amount;
+
+-- Уведомление платёжного шлюза: событие регистрируется один раз,
+-- повторная доставка того же event_id ничего не вставляет
+-- Параметры: provider='fake', event_id='evt_demo', intent_id='fake_pi_demo'
+INSERT INTO payment_event (provider, event_id, event_type)
+VALUES ('fake', 'evt_demo', 'payment.failed')
+ON CONFLICT (provider, event_id) DO NOTHING;
 
--- Получение информации о платеже по бронированию
-SELECT p.payment_id, p.amount, p.status, p.payment_method, p.paid_at, p.created_at
+UPDATE payment
+SET status = 'failed', failure_reason = 'card_declined'
+WHERE provider = 'fake' AND provider_ref = 'fake_pi_demo' AND status = 'pending'
+RETURNING payment_id, booking_id, status, failure_reason;
+
+-- Ожидающие платежи, для которых ещё не создано намерение оплаты в шлюзе
+SELECT payment_id, booking_id, amount, payment_method, created_at
+FROM payment
+WHERE status = 'pending' AND provider_ref IS NULL
+  AND payment_method IS DISTINCT FROM 'cash'
+ORDER BY payment_id;
+
+-- Счёт на ожидающий платёж и квитанция на оплаченный: номер — следующий в коворкинге
+-- Параметры: payment_id=18
+SELECT issue_document(18, 'invoice', NOW());
+SELECT issue_document(18, 'receipt', NOW());
+
+-- Счета и квитанции бронирования
+SELECT i.invoice_id, i.kind, i.coworking_id || '-' || lpad(i.number::text, 6, '0') AS number,
+       i.issued_at, i.amount, i.vat_percent, i.vat_amount, i.payment_method, i.paid_at, p.status AS payment_status
+FROM invoice i
+JOIN payment p ON i.payment_id = p.payment_id
+WHERE i.booking_id = 12
+ORDER BY i.issued_at, i.invoice_id;
+
+-- Платежи, возвраты и баланс бронирования
+SELECT p.payment_id, p.amount, p.status, p.payment_method, p.paid_at, p.created_at,
+       p.provider_ref, p.failure_reason
 FROM payment p
-WHERE p.booking_id = 12;
+WHERE p.booking_id = 12
+ORDER BY p.created_at, p.payment_id;
+
+SELECT refund_id, payment_id, amount, refund_percent, reason, created_at
+FROM refund
+WHERE booking_id = 12
+ORDER BY created_at, refund_id;
+
+SELECT * FROM booking_balance WHERE booking_id = 12;
+
+-- История бронирования по журналу изменений: само бронирование, его платежи, возвраты и документы
+-- Параметры: booking_id=12
+SELECT audit_id, occurred_at, tx_id, actor, action, entity, entity_id, old_values, new_values
+FROM audit_log
+WHERE booking_id = 12
+ORDER BY audit_id;
+
+-- Переходы статусов платежа с исполнителями
+-- Параметры: payment_id=18
+SELECT occurred_at, actor, old_values ->> 'status' AS old_status, new_values ->> 'status' AS new_status
+FROM audit_log
+WHERE entity = 'payment' AND entity_id = 18 AND new_values ? 'status'
+ORDER BY audit_id;
+
+-- Изменения вне приложения задают исполнителя сами (до конца транзакции)
+BEGIN;
+SELECT set_config('app.actor', 'support:manual-fix', true);
+UPDATE payment SET status = 'cancelled' WHERE payment_id = 18 AND status = 'pending';
+COMMIT;
 
 -- Отчёт о загрузке комнат за декабрь 2024
 -- Параметры: start_date='2024-12-01', end_date='2024-12-31 23:59:59'
@@ -233,16 +304,16 @@ SELECT
     c.name AS coworking_name,
     c.address,
     COUNT(DISTINCT b.booking_id) AS total_bookings,
-    COALESCE(SUM(p.amount), 0) AS total_revenue,
-    COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS confirmed_revenue,
-    COALESCE(SUM(CASE WHEN p.status = 'pending' THEN p.amount ELSE 0 END), 0) AS pending_revenue,
-    COALESCE(SUM(CASE WHEN p.status = 'refunded' THEN p.amount ELSE 0 END), 0) AS refunded_amount
+    COALESCE(SUM(bb.paid_amount + bb.pending_amount), 0) AS total_revenue,
+    COALESCE(SUM(bb.balance), 0) AS confirmed_revenue,
+    COALESCE(SUM(bb.pending_amount), 0) AS pending_revenue,
+    COALESCE(SUM(bb.refunded_amount), 0) AS refunded_amount
 FROM coworking c
 LEFT JOIN room r ON c.coworking_id = r.coworking_id
 LEFT JOIN booking b ON r.room_id = b.room_id
     AND b.created_at >= '2024-12-01'
     AND b.created_at <= '2024-12-31 23:59:59'
-LEFT JOIN payment p ON b.booking_id = p.booking_id
+LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
 GROUP BY c.coworking_id, c.name, c.address
 ORDER BY total_revenue DESC;
 
@@ -253,14 +324,14 @@ SELECT
     r.name AS room_name,
     COUNT(b.booking_id) AS total_bookings,
     COALESCE(SUM(b.total_amount), 0) AS total_booking_amount,
-    COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS paid_amount,
-    COALESCE(SUM(CASE WHEN p.status = 'pending' THEN p.amount ELSE 0 END), 0) AS pending_amount
+    COALESCE(SUM(bb.balance), 0) AS paid_amount,
+    COALESCE(SUM(bb.pending_amount), 0) AS pending_amount
 FROM room r
 JOIN coworking c ON r.coworking_id = c.coworking_id
 LEFT JOIN booking b ON r.room_id = b.room_id
     AND b.created_at >= '2024-12-01'
     AND b.created_at <= '2024-12-31 23:59:59'
-LEFT JOIN payment p ON b.booking_id = p.booking_id
+LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
 GROUP BY c.name, r.room_id, r.name
 ORDER BY c.name, paid_amount DESC;
 
@@ -275,14 +346,14 @@ SELECT
     b.ends_at,
     b.total_amount,
     b.status AS booking_status,
-    COALESCE(p.status, 'no_payment') AS payment_status,
-    p.paid_at,
+    bb.payment_status,
+    bb.last_paid_at AS paid_at,
     b.created_at,
     b.updated_at
 FROM booking b
 JOIN room r ON b.room_id = r.room_id
 JOIN coworking c ON r.coworking_id = c.coworking_id
-LEFT JOIN payment p ON b.booking_id = p.booking_id
+JOIN booking_balance bb ON b.booking_id = bb.booking_id
 WHERE b.user_id = 3
 ORDER BY b.created_at DESC;
 
@@ -297,10 +368,10 @@ SELECT
     COUNT(CASE WHEN b.status = 'completed' THEN 1 END) AS completed_bookings,
     COUNT(CASE WHEN b.status = 'cancelled' THEN 1 END) AS cancelled_bookings,
     COALESCE(SUM(b.total_amount), 0) AS total_spent,
-    COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS total_paid
+    COALESCE(SUM(bb.balance), 0) AS total_paid
 FROM "user" u
 LEFT JOIN booking b ON u.user_id = b.user_id
-LEFT JOIN payment p ON b.booking_id = p.booking_id
+LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
 WHERE u.user_id = 3
 GROUP BY u.user_id, u.full_name, u.email;
 
@@ -325,10 +396,10 @@ SELECT
     u.full_name,
     u.email,
     COUNT(b.booking_id) AS total_bookings,
-    COALESCE(SUM(CASE WHEN p.status = 'paid' THEN p.amount ELSE 0 END), 0) AS total_paid
+    COALESCE(SUM(bb.balance), 0) AS total_paid
 FROM "user" u
 LEFT JOIN booking b ON u.user_id = b.user_id
-LEFT JOIN payment p ON b.booking_id = p.booking_id
+LEFT JOIN booking_balance bb ON b.booking_id = bb.booking_id
 GROUP BY u.user_id, u.full_name, u.email
 HAVING COUNT(b.booking_id) > 0
 ORDER BY total_paid DESC
@@ -347,7 +418,7 @@ WHERE b.status IN ('confirmed', 'completed')
 GROUP BY c.name
 ORDER BY avg_booking_amount DESC;
 
--- Проверка целостности данных: бронирования без платежей
+-- Проверка целостности данных: подтверждённые бронирования, оплаченные не полностью
 SELECT
     b.booking_id,
     b.room_id,
@@ -355,10 +426,11 @@ SELECT
     b.starts_at,
     b.ends_at,
     b.status,
-    b.total_amount
+    b.total_amount,
+    bb.balance
 FROM booking b
-LEFT JOIN payment p ON b.booking_id = p.booking_id
-WHERE p.payment_id IS NULL
+JOIN booking_balance bb ON b.booking_id = bb.booking_id
+WHERE bb.balance < b.total_amount
   AND b.status IN ('confirmed', 'completed');
 
 -- Транзакция 1: Создание бронирования с платежом
@@ -391,9 +463,12 @@ WHERE payment_id = 100 AND status = 'pending'
 RETURNING booking_id;
 -- booking_id = 100
 
-UPDATE booking
+-- Бронирование подтверждается, только когда баланс покрывает стоимость
+UPDATE booking b
 SET status = 'confirmed', updated_at = NOW()
-WHERE booking_id = 100 AND status = 'pending';
+FROM booking_balance bb
+WHERE b.booking_id = 100 AND b.status = 'pending'
+  AND bb.booking_id = b.booking_id AND bb.balance >= b.total_amount;
 
 COMMIT;
 
@@ -405,9 +480,14 @@ SET status = 'cancelled', updated_at = NOW()
 WHERE booking_id = 100 AND user_id = 5 AND status IN ('pending', 'confirmed')
 RETURNING booking_id;
 
-UPDATE payment
-SET status = 'refunded'
+INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason)
+SELECT booking_id, payment_id, amount, 100, 'cancelled_by_user'
+FROM payment
 WHERE booking_id = 100 AND status = 'paid';
 
+UPDATE payment
+SET status = 'cancelled'
+WHERE booking_id = 100 AND status = 'pending';
+
 COMMIT;
 
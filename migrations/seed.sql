@@ -1,9 +1,34 @@
 
+-- Времена ниже — местное время коворкингов (все три живут по московскому времени)
+SET TIME ZONE 'Europe/Moscow';
+
+-- Исполнитель изменений в audit_log
+SET app.actor = 'seed';
+
 -- Очистка данных (для повторного запуска)
+TRUNCATE TABLE audit_log;
+TRUNCATE TABLE session CASCADE;
+TRUNCATE TABLE blackout CASCADE;
+TRUNCATE TABLE invoice_line CASCADE;
+TRUNCATE TABLE invoice CASCADE;
+TRUNCATE TABLE document_counter CASCADE;
+TRUNCATE TABLE payment_event CASCADE;
+TRUNCATE TABLE refund CASCADE;
+TRUNCATE TABLE cancellation_tier CASCADE;
+TRUNCATE TABLE promo_redemption CASCADE;
+TRUNCATE TABLE promo_code CASCADE;
+TRUNCATE TABLE booking_price_line CASCADE;
+TRUNCATE TABLE pricing_rule CASCADE;
+TRUNCATE TABLE coworking_holiday CASCADE;
+TRUNCATE TABLE opening_hours CASCADE;
+TRUNCATE TABLE waitlist_entry CASCADE;
+TRUNCATE TABLE booking_status_history CASCADE;
 TRUNCATE TABLE payment CASCADE;
 TRUNCATE TABLE booking CASCADE;
+TRUNCATE TABLE booking_series CASCADE;
 TRUNCATE TABLE room_equipment CASCADE;
 TRUNCATE TABLE equipment CASCADE;
+TRUNCATE TABLE coworking_manager CASCADE;
 TRUNCATE TABLE room CASCADE;
 TRUNCATE TABLE coworking CASCADE;
 TRUNCATE TABLE "user" CASCADE;
@@ -15,24 +40,44 @@ ALTER SEQUENCE room_room_id_seq RESTART WITH 1;
 ALTER SEQUENCE equipment_equipment_id_seq RESTART WITH 1;
 ALTER SEQUENCE booking_booking_id_seq RESTART WITH 1;
 ALTER SEQUENCE payment_payment_id_seq RESTART WITH 1;
+ALTER SEQUENCE session_session_id_seq RESTART WITH 1;
+ALTER SEQUENCE booking_status_history_history_id_seq RESTART WITH 1;
+ALTER SEQUENCE booking_series_series_id_seq RESTART WITH 1;
+ALTER SEQUENCE waitlist_entry_entry_id_seq RESTART WITH 1;
+ALTER SEQUENCE opening_hours_opening_hours_id_seq RESTART WITH 1;
+ALTER SEQUENCE coworking_holiday_holiday_id_seq RESTART WITH 1;
+ALTER SEQUENCE blackout_blackout_id_seq RESTART WITH 1;
+ALTER SEQUENCE pricing_rule_pricing_rule_id_seq RESTART WITH 1;
+ALTER SEQUENCE booking_price_line_price_line_id_seq RESTART WITH 1;
+ALTER SEQUENCE promo_code_promo_code_id_seq RESTART WITH 1;
+ALTER SEQUENCE promo_redemption_redemption_id_seq RESTART WITH 1;
+ALTER SEQUENCE cancellation_tier_tier_id_seq RESTART WITH 1;
+ALTER SEQUENCE refund_refund_id_seq RESTART WITH 1;
+ALTER SEQUENCE invoice_invoice_id_seq RESTART WITH 1;
+ALTER SEQUENCE audit_log_audit_id_seq RESTART WITH 1;
 
 -- Пароль для всех: 'password123' (bcrypt hash)
 INSERT INTO "user" (email, password_hash, full_name, role) VALUES
-('admin@coworking.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Иван Админов', 'admin'),
-('manager@coworking.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Мария Менеджерова', 'manager'),
-('alice@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Алиса Петрова', 'user'),
-('bob@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Борис Сидоров', 'user'),
-('charlie@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Чарли Иванов', 'user'),
-('diana@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Диана Ковалёва', 'user'),
-('eve@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Ева Смирнова', 'user'),
-('frank@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Франк Морозов', 'user'),
-('grace@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Грейс Новикова', 'user'),
-('hank@example.com', '$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy', 'Ханк Волков', 'user');
-
-INSERT INTO coworking (name, address, description) VALUES
-('Центральный Hub', 'Москва, ул. Тверская, д. 10', 'Коворкинг в центре города с современной инфраструктурой'),
-('Tech Valley', 'Санкт-Петербург, Невский проспект, д. 50', 'Коворкинг для IT-компаний с высокоскоростным интернетом'),
-('Creative Space', 'Казань, ул. Баумана, д. 25', 'Креативное пространство для дизайнеров и фрилансеров');
+('admin@coworking.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Иван Админов', 'admin'),
+('manager@coworking.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Мария Менеджерова', 'manager'),
+('alice@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Алиса Петрова', 'user'),
+('bob@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Борис Сидоров', 'user'),
+('charlie@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Чарли Иванов', 'user'),
+('diana@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Диана Ковалёва', 'user'),
+('eve@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Ева Смирнова', 'user'),
+('frank@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Франк Морозов', 'user'),
+('grace@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Грейс Новикова', 'user'),
+('hank@example.com', '$2a$10$S6IZaNw7H7DlUxSMokADT.RgA1GsmDlfSsdRvtMZt26liPddDxYX2', 'Ханк Волков', 'user');
+
+INSERT INTO coworking (name, address, description, time_zone, legal_name, tax_id, vat_percent) VALUES
+('Центральный Hub', 'Москва, ул. Тверская, д. 10', 'Коворкинг в центре города с современной инфраструктурой', 'Europe/Moscow', 'ООО «Центральный Хаб»', '7707123456', 20),
+('Tech Valley', 'Санкт-Петербург, Невский проспект, д. 50', 'Коворкинг для IT-компаний с высокоскоростным интернетом', 'Europe/Moscow', 'ООО «Тех Вэлли»', '7841654321', 20),
+('Creative Space', 'Казань, ул. Баумана, д. 25', 'Креативное пространство для дизайнеров и фрилансеров', 'Europe/Moscow', 'ИП Сафина Алия Рустамовна', '165512345678', 0);
+
+-- Менеджер отвечает за Центральный Hub и Tech Valley
+INSERT INTO coworking_manager (coworking_id, user_id) VALUES
+(1, 2),
+(2, 2);
 
 INSERT INTO equipment (name, description) VALUES
 ('Проектор', 'HD проектор с HDMI входом'),
@@ -61,6 +106,9 @@ INSERT INTO room (coworking_id, name, capacity, area_sqm, hourly_rate) VALUES
 (3, 'Workshop Hall', 15, 50.00, 3000.00),
 (3, 'Quiet Room', 3, 12.00, 800.00);
 
+-- Большим залам нужно 15 минут на уборку между встречами
+UPDATE room SET teardown_minutes = 15 WHERE room_id IN (4, 7);
+
 -- Alpha: Проектор, Белая доска, Wi-Fi
 INSERT INTO room_equipment (room_id, equipment_id) VALUES
 (1, 1), (1, 2), (1, 7);
@@ -101,6 +149,62 @@ INSERT INTO room_equipment (room_id, equipment_id) VALUES
 INSERT INTO room_equipment (room_id, equipment_id) VALUES
 (10, 7);
 
+-- Часы работы: Центральный Hub — 08:00–22:00 в будни и 10:00–20:00 в выходные,
+-- Tech Valley — круглосуточно, Creative Space — ежедневно 09:00–21:00
+INSERT INTO opening_hours (coworking_id, weekday, opens_at, closes_at)
+SELECT 1, d, CASE WHEN d <= 5 THEN TIME '08:00' ELSE TIME '10:00' END,
+             CASE WHEN d <= 5 THEN TIME '22:00' ELSE TIME '20:00' END
+FROM generate_series(1, 7) AS d
+UNION ALL
+SELECT 2, d, TIME '00:00', TIME '24:00' FROM generate_series(1, 7) AS d
+UNION ALL
+SELECT 3, d, TIME '09:00', TIME '21:00' FROM generate_series(1, 7) AS d;
+
+-- Конференц-зал Delta доступен только в будни 09:00–19:00
+INSERT INTO opening_hours (room_id, weekday, opens_at, closes_at)
+SELECT 4, d, TIME '09:00', TIME '19:00' FROM generate_series(1, 5) AS d;
+
+-- Новогодние каникулы и сокращённый день 31 декабря
+INSERT INTO coworking_holiday (coworking_id, day, name, opens_at, closes_at)
+SELECT c.coworking_id, '2024-12-31', 'Предновогодний день', TIME '10:00', TIME '16:00'
+FROM coworking c
+UNION ALL
+SELECT c.coworking_id, d::date, 'Новогодние каникулы', NULL, NULL
+FROM coworking c, generate_series(DATE '2025-01-01', DATE '2025-01-08', INTERVAL '1 day') AS d;
+
+-- Блокировки: замена мебели в Gamma и закрытое мероприятие во всём Creative Space
+INSERT INTO blackout (room_id, coworking_id, starts_at, ends_at, reason, created_by) VALUES
+(3, NULL, '2024-12-23 08:00:00', '2024-12-23 14:00:00', 'Замена мебели', 2),
+(NULL, 3, '2024-12-27 09:00:00', '2024-12-27 21:00:00', 'Корпоративное мероприятие', 1);
+
+-- Правила цены: в Центральном Hub пиковые часы в будни, вечерняя скидка, надбавка
+-- за выходные, минимум час и скидки за полдня и день; в Tech Valley — ночной тариф;
+-- Конференц-зал Delta бронируется минимум на 2 часа
+INSERT INTO pricing_rule (coworking_id, room_id, kind, name, weekdays, opens_at, closes_at, rate_percent, min_minutes, discount_percent) VALUES
+(1, NULL, 'time_window', 'Пиковые часы', '{1,2,3,4,5}', '11:00', '15:00', 125, NULL, NULL),
+(1, NULL, 'time_window', 'Вечерний тариф', '{}', '19:00', '22:00', 80, NULL, NULL),
+(1, NULL, 'weekend', 'Выходной день', '{}', NULL, NULL, 120, NULL, NULL),
+(1, NULL, 'min_duration', 'Минимум 1 час', '{}', NULL, NULL, NULL, 60, NULL),
+(1, NULL, 'duration_discount', 'Скидка за полдня', '{}', NULL, NULL, NULL, 240, 10),
+(1, NULL, 'duration_discount', 'Скидка за полный день', '{}', NULL, NULL, NULL, 480, 20),
+(2, NULL, 'time_window', 'Ночной тариф', '{}', '00:00', '08:00', 50, NULL, NULL),
+(NULL, 4, 'min_duration', 'Минимум 2 часа', '{}', NULL, NULL, NULL, 120, NULL);
+
+-- Промокоды: общий на первое бронирование, фиксированная скидка в Hub и скидка на зал Tech Valley
+INSERT INTO promo_code (code, description, discount_percent, discount_amount, valid_from, valid_until,
+                        max_redemptions, max_per_user, coworking_id, room_id, created_by) VALUES
+('WELCOME10', 'Скидка 10% на первое бронирование', 10, NULL, '2024-12-01 00:00:00+03', NULL, NULL, 1, NULL, NULL, 1),
+('HUB500', 'Минус 500 руб в Hub', NULL, 500.00, '2024-12-01 00:00:00+03', '2025-03-01 00:00:00+03', 100, 2, 1, NULL, 2),
+('HALL25', 'Large Conference Hall со скидкой 25%', 25, NULL, '2024-12-15 00:00:00+03', '2025-01-15 00:00:00+03', 20, NULL, NULL, 7, 1);
+
+-- Политики отмены: Hub — полный возврат за 48 ч, половина за 24 ч; Tech Valley — полный за 24 ч,
+-- половина до начала; у Creative Space политики нет (возврат полный)
+INSERT INTO cancellation_tier (coworking_id, room_id, min_hours_before, refund_percent) VALUES
+(1, NULL, 48, 100),
+(1, NULL, 24, 50),
+(2, NULL, 24, 100),
+(2, NULL, 0, 50);
+
 -- Используем реалистичные даты (относительно текущего времени)
 -- Бронирования на прошлую неделю, текущую неделю и будущую неделю
 
@@ -132,6 +236,13 @@ INSERT INTO booking (room_id, user_id, starts_at, ends_at, total_amount, status,
 (1, 4, '2024-12-13 10:00:00', '2024-12-13 12:00:00', 3000.00, 'cancelled', '2024-12-12 10:00:00', '2024-12-12 15:00:00'),
 (2, 5, '2024-12-14 14:00:00', '2024-12-14 16:00:00', 5000.00, 'cancelled', '2024-12-13 09:00:00', '2024-12-13 18:00:00');
 
+-- Исторические бронирования рассчитаны по базовой ставке одной строкой
+INSERT INTO booking_price_line (booking_id, position, kind, description, minutes, hourly_rate, amount)
+SELECT b.booking_id, 1, 'time', 'Базовый тариф',
+       ROUND(EXTRACT(EPOCH FROM (b.ends_at - b.starts_at)) / 60), r.hourly_rate, b.total_amount
+FROM booking b
+JOIN room r ON b.room_id = r.room_id;
+
 -- Платежи для completed бронирований (paid)
 INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, created_at) VALUES
 (1, 3000.00, 'paid', 'card', '2024-12-09 15:35:00', '2024-12-09 15:35:00'),
@@ -146,7 +257,7 @@ INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, create
 (7, 5000.00, 'paid', 'card', '2024-12-15 11:05:00', '2024-12-15 11:05:00'),
 (8, 16000.00, 'paid', 'bank_transfer', '2024-12-16 09:10:00', '2024-12-16 09:10:00'),
 (9, 4000.00, 'paid', 'card', '2024-12-16 10:10:00', '2024-12-16 10:10:00'),
-(10, 40000.00, 'paid', 'bank_transfer', '2024-12-16 15:15:00', '2024-12-16 15:15:00'),
+(10, 20000.00, 'paid', 'bank_transfer', '2024-12-16 15:15:00', '2024-12-16 15:15:00'),
 (11, 9000.00, 'paid', 'card', '2024-12-17 08:10:00', '2024-12-17 08:10:00');
 
 -- Платежи для pending бронирований (pending)
@@ -155,10 +266,37 @@ INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, create
 (13, 2400.00, 'pending', 'card', NULL, '2024-12-17 11:05:00'),
 (14, 1600.00, 'pending', 'bank_transfer', NULL, '2024-12-17 12:05:00');
 
--- Платежи для отменённых бронирований (refunded)
+-- Платежи для отменённых бронирований (возвращены полностью)
 INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, created_at) VALUES
-(15, 3000.00, 'refunded', 'card', '2024-12-12 10:05:00', '2024-12-12 10:05:00'),
-(16, 5000.00, 'refunded', 'card', '2024-12-13 09:05:00', '2024-12-13 09:05:00');
+(15, 3000.00, 'paid', 'card', '2024-12-12 10:05:00', '2024-12-12 10:05:00'),
+(16, 5000.00, 'paid', 'card', '2024-12-13 09:05:00', '2024-12-13 09:05:00');
+
+-- Бронирование 10 оплачено двумя частями: депозит и остаток
+INSERT INTO payment (booking_id, amount, status, payment_method, paid_at, created_at) VALUES
+(10, 20000.00, 'paid', 'card', '2024-12-16 17:40:00', '2024-12-16 17:40:00');
+
+-- Полные возвраты отменённых бронирований
+INSERT INTO refund (booking_id, payment_id, amount, refund_percent, reason, created_at)
+SELECT p.booking_id, p.payment_id, p.amount, 100, 'cancelled_by_user', b.updated_at
+FROM payment p
+JOIN booking b ON p.booking_id = b.booking_id
+WHERE p.booking_id IN (15, 16);
+
+-- Счета на все платежи и квитанции на оплаченные в порядке создания и оплаты
+DO $$
+DECLARE
+    d RECORD;
+BEGIN
+    FOR d IN
+        SELECT payment_id, 'invoice' AS kind, created_at AS issued_at FROM payment
+        UNION ALL
+        SELECT payment_id, 'receipt', paid_at FROM payment WHERE status = 'paid'
+        ORDER BY issued_at, payment_id, kind
+    LOOP
+        PERFORM issue_document(d.payment_id, d.kind, d.issued_at);
+    END LOOP;
+END;
+$$;
 
 SELECT 'Пользователей:' AS metric, COUNT(*) AS count FROM "user"
 UNION ALL
@@ -170,5 +308,9 @@ SELECT 'Типов оборудования:', COUNT(*) FROM equipment
 UNION ALL
 SELECT 'Бронирований:', COUNT(*) FROM booking
 UNION ALL
-SELECT 'Платежей:', COUNT(*) FROM payment;
+SELECT 'Платежей:', COUNT(*) FROM payment
+UNION ALL
+SELECT 'Счетов и квитанций:', COUNT(*) FROM invoice
+UNION ALL
+SELECT 'Записей журнала изменений:', COUNT(*) FROM audit_log;
 